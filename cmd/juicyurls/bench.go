@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"juicyurls/internal/bench"
+	"juicyurls/internal/corpus"
+	"juicyurls/pkg/juicyurls"
+)
+
+// runBench implements `juicyurls bench`
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	countStr := fs.String("n", "100K", "Number of URLs to scan (supports K/M/G suffixes)")
+	ratio := fs.Float64("suspicious-ratio", 0.01, "Fraction of suspicious URLs (0-1)")
+	seed := fs.Int64("seed", 1, "Random seed for the corpus")
+	rounds := fs.Int("rounds", 3, "Timed scans; the median is reported")
+	savePath := fs.String("save", "", "Write the results to this JSON file")
+	comparePath := fs.String("compare", "", "Compare with results saved by -save")
+	maxRegression := fs.Float64("max-regression", 0, "Exit 1 when throughput drops more than this percent below -compare (0: report only)")
+	scanner := scannerFlags(fs, nil)
+	fs.Parse(args)
+
+	count, err := parseCount(*countStr)
+	if err != nil || count == 0 {
+		log.Printf("Invalid count: %s", *countStr)
+		return 1
+	}
+	var base *bench.Report
+	if *comparePath != "" {
+		r, err := bench.Load(*comparePath)
+		if err != nil {
+			log.Printf("Invalid baseline: %v", err)
+			return 1
+		}
+		base = &r
+	}
+
+	report, err := bench.Run(context.Background(), scanner(), bench.Options{
+		Corpus: corpus.Options{Count: count, SuspiciousRatio: *ratio, Seed: *seed},
+		Rounds: *rounds,
+		Only:   func(category string) *juicyurls.Scanner { return scanner(category) },
+	})
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+	bench.Write(os.Stdout, report, base)
+
+	if *savePath != "" {
+		if err := bench.Save(*savePath, report); err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Saved results to %s\n", *savePath)
+	}
+	if base != nil && *maxRegression > 0 && -bench.Change(*base, report) > *maxRegression {
+		fmt.Fprintf(os.Stderr, "Throughput regressed more than %.1f%%\n", *maxRegression)
+		return 1
+	}
+	return 0
+}
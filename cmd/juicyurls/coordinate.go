@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"juicyurls/internal/coordinator"
+	"juicyurls/internal/netclient"
+	"juicyurls/internal/types"
+	"juicyurls/pkg/writer"
+)
+
+// heartbeat is how often a worker registers again with its coordinator
+const heartbeat = 30 * time.Second
+
+// runCoordinate implements `juicyurls coordinate`
+func runCoordinate(args []string) int {
+	fs := flag.NewFlagSet("coordinate", flag.ExitOnError)
+	inputPath := fs.String("l", "", "Path to the list of URLs (default: stdin)")
+	workers := fs.String("workers", "", "Comma-separated serve http base URLs of the workers")
+	addr := fs.String("addr", "", "Listen on host:port for workers registering with serve http -coordinator")
+	unit := fs.Int("unit", coordinator.DefaultUnit, "URLs per work unit")
+	retries := fs.Int("retries", 2, "Times a failed unit is sent to another worker")
+	unitTimeout := fs.Duration("unit-timeout", 5*time.Minute, "Longest a worker may take for one unit")
+	outputPath := fs.String("o", "", "Output file path (default: stdout)")
+	format := fs.String("format", writer.FormatJSON, "Output format")
+	fs.Parse(args)
+
+	if err := writer.CheckFormat(*format); err != nil {
+		log.Printf("Invalid -format: %v", err)
+		return 1
+	}
+	reg, err := coordinator.NewRegistry(splitList(*workers)...)
+	if err != nil {
+		log.Printf("Invalid -workers: %v", err)
+		return 1
+	}
+	if *workers == "" && *addr == "" {
+		log.Printf("No workers: set -workers, -addr or both")
+		return 1
+	}
+	client, err := netclient.New(netclient.Options{Timeout: *unitTimeout})
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+
+	var in io.Reader = os.Stdin
+	source := "stdin"
+	if *inputPath != "" {
+		f, err := os.Open(*inputPath)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer f.Close()
+		in, source = f, *inputPath
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *addr != "" {
+		ln, err := net.Listen("tcp", *addr)
+		if err != nil {
+			log.Printf("Listen: %v", err)
+			return 1
+		}
+		srv := &http.Server{Handler: reg.Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go srv.Serve(ln)
+		defer srv.Close()
+		log.Printf("Accepting workers on %s", ln.Addr())
+	}
+
+	results := make(chan types.Result)
+	written := make(chan error, 1)
+	go func() {
+		written <- writer.WriteStream(ctx, results, *outputPath, *format, false, 0)
+	}()
+	stats, err := coordinator.Run(ctx, in, source, reg, coordinator.Options{
+		Unit:    *unit,
+		Retries: *retries,
+		Client:  client,
+		Logger:  slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}, results)
+	close(results)
+	if werr := <-written; werr != nil && err == nil {
+		err = werr
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Scanned %d URLs in %d units on %d workers: %d findings (rules %s)\n",
+		stats.URLs, stats.Units, len(stats.ByWorker), stats.Findings, stats.RulesHash)
+	names := make([]string, 0, len(stats.ByWorker))
+	for w := range stats.ByWorker {
+		names = append(names, w)
+	}
+	sort.Strings(names)
+	for _, w := range names {
+		fmt.Fprintf(os.Stderr, "  %s: %d units\n", w, stats.ByWorker[w])
+	}
+	if stats.Retried > 0 {
+		fmt.Fprintf(os.Stderr, "Retried %d units after worker failures\n", stats.Retried)
+	}
+	return 0
+}
+
+// register announces a serve http worker to its coordinator, and again
+// every heartbeat so it rejoins after the coordinator dropped or restarted
+func register(ctx context.Context, coordinatorURL, advertise string) {
+	body, _ := json.Marshal(map[string]string{"url": advertise})
+	client := &http.Client{Timeout: 10 * time.Second}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		err := post(ctx, client, coordinatorURL+"/register", body)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Register with %s: %v", coordinatorURL, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func post(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return errors.New(resp.Status + ": " + e.Error)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"juicyurls/internal/baseline"
+)
+
+// runDiff implements `juicyurls diff old.json new.json`
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", baseline.FormatText, "Output format: text, markdown or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: juicyurls diff [-format text|markdown|json] old.json new.json")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+	if len(paths) != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	old, err := baseline.Read(paths[0])
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+	cur, err := baseline.Read(paths[1])
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+	if err := baseline.Diff(old, cur).Write(os.Stdout, *format); err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// setUnset applies values to flags that were not given on the command line,
+// so explicit flags always win over config sources
+func setUnset(fs *flag.FlagSet, values map[string][]string, source string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, vals := range values {
+		if explicit[name] {
+			continue
+		}
+		for _, v := range vals {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("%s: invalid value %q for -%s: %w", source, v, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseInterspersed parses args allowing flags after positional arguments,
+// as in `juicyurls merge a.json b.json -o merged.json`, and returns the
+// positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"juicyurls/internal/fingerprint"
+	"juicyurls/internal/triage"
+)
+
+// fpUsage lists the fp commands
+const fpUsage = `Usage:
+  juicyurls fp add [-store fp.json] [-reason text] [-by name] <fingerprint>...
+  juicyurls fp add [-store fp.json] -url <url> -rule <id>
+  juicyurls fp add [-store fp.json] [-host <host>] [-rule <id>]
+  juicyurls fp list [-store fp.json]
+  juicyurls fp export [-store fp.json] -key team.key team-fp.json
+  juicyurls fp import [-store fp.json] -key team.key team-fp.json`
+
+// runFP implements `juicyurls fp <command>`
+func runFP(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, fpUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("fp "+args[0], flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), fpUsage) }
+	storePath := fs.String("store", "fp.json", "False-positive store")
+	switch args[0] {
+	case "add":
+		return runFPAdd(fs, storePath, args[1:])
+	case "list":
+		parseInterspersed(fs, args[1:])
+		s, err := triage.Load(*storePath)
+		if err != nil {
+			log.Printf("Invalid -store: %v", err)
+			return 1
+		}
+		for _, e := range s.Entries {
+			what := e.Fingerprint
+			switch {
+			case e.Fingerprint != "" && e.URL != "":
+				what += " " + e.URL
+			case e.Fingerprint == "" && e.Host != "" && e.RuleID != "":
+				what = "host " + e.Host + " rule " + e.RuleID
+			case e.Fingerprint == "" && e.Host != "":
+				what = "host " + e.Host
+			case e.Fingerprint == "":
+				what = "rule " + e.RuleID
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.Added.Format(time.DateOnly), e.By, what, e.Reason)
+		}
+	case "export", "import":
+		keyPath := fs.String("key", "", "File holding the team's signing key")
+		files := parseInterspersed(fs, args[1:])
+		if len(files) != 1 || *keyPath == "" {
+			fs.Usage()
+			return 2
+		}
+		key, err := os.ReadFile(*keyPath)
+		if err != nil {
+			log.Printf("Invalid -key: %v", err)
+			return 1
+		}
+		key = bytes.TrimSpace(key)
+		s, err := triage.Load(*storePath)
+		if err != nil {
+			log.Printf("Invalid -store: %v", err)
+			return 1
+		}
+		if args[0] == "export" {
+			f, err := os.Create(files[0])
+			if err != nil {
+				log.Printf("Error: %v", err)
+				return 1
+			}
+			if err := s.Export(f, key); err != nil {
+				f.Close()
+				log.Printf("Error: %v", err)
+				return 1
+			}
+			if err := f.Close(); err != nil {
+				log.Printf("Error: %v", err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Exported %d decisions to %s\n", len(s.Entries), files[0])
+			return 0
+		}
+		f, err := os.Open(files[0])
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		entries, err := triage.Import(f, key)
+		f.Close()
+		if err != nil {
+			log.Printf("Invalid export %s: %v", files[0], err)
+			return 1
+		}
+		added := 0
+		for _, e := range entries {
+			if s.Add(e) {
+				added++
+			}
+		}
+		if err := s.Save(); err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Imported %d decisions from %s (%d already known)\n", added, files[0], len(entries)-added)
+	default:
+		log.Printf("Unknown fp command: %s", args[0])
+		return 1
+	}
+	return 0
+}
+
+// runFPAdd records false-positive decisions in the store
+func runFPAdd(fs *flag.FlagSet, storePath *string, args []string) int {
+	reason := fs.String("reason", "", "Why the findings are false positives")
+	by := fs.String("by", os.Getenv("USER"), "Analyst taking the decision")
+	rawURL := fs.String("url", "", "URL of the finding, with -rule")
+	host := fs.String("host", "", "Allow every finding on this host and its subdomains")
+	rule := fs.String("rule", "", "Rule ID of the finding with -url; otherwise allow this rule, on -host if given")
+	fps := parseInterspersed(fs, args)
+
+	base := triage.Entry{Reason: *reason, By: *by, Added: time.Now().UTC()}
+	var entries []triage.Entry
+	for _, fp := range fps {
+		e := base
+		e.Fingerprint = fp
+		entries = append(entries, e)
+	}
+	switch {
+	case *rawURL != "":
+		if *rule == "" || *host != "" {
+			log.Printf("-url takes the finding's -rule and no -host")
+			return 1
+		}
+		e := base
+		e.Fingerprint, e.URL, e.RuleID = fingerprint.Compute(*rawURL, *rule), *rawURL, *rule
+		entries = append(entries, e)
+	case *host != "" || *rule != "":
+		e := base
+		e.Host, e.RuleID = *host, *rule
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	s, err := triage.Load(*storePath)
+	if err != nil {
+		log.Printf("Invalid -store: %v", err)
+		return 1
+	}
+	added := 0
+	for _, e := range entries {
+		if s.Add(e) {
+			added++
+		}
+	}
+	if err := s.Save(); err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Added %d decisions to %s (%d already known)\n", added, *storePath, len(entries)-added)
+	return 0
+}
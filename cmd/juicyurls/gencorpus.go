@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"juicyurls/internal/corpus"
+)
+
+// runGenCorpus implements `juicyurls gen-corpus`
+func runGenCorpus(args []string) int {
+	fs := flag.NewFlagSet("gen-corpus", flag.ExitOnError)
+	countStr := fs.String("n", "100K", "Number of URLs to generate (supports K/M/G suffixes)")
+	ratio := fs.Float64("suspicious-ratio", 0.01, "Fraction of suspicious URLs (0-1)")
+	seed := fs.Int64("seed", 1, "Random seed for reproducible corpora")
+	outputPath := fs.String("o", "", "Output file path (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: juicyurls gen-corpus [-n 1M] [-suspicious-ratio 0.01] [-seed 1] [-o corpus.txt]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	count, err := parseCount(*countStr)
+	if err != nil {
+		log.Printf("Invalid count: %v", err)
+		return 1
+	}
+	if *ratio < 0 || *ratio > 1 {
+		log.Printf("Invalid suspicious ratio: %v (want 0-1)", *ratio)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *outputPath != "" {
+		f, err := os.Create(*outputPath)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	start := time.Now()
+	stats, err := corpus.Generate(out, corpus.Options{
+		Count:           count,
+		SuspiciousRatio: *ratio,
+		Seed:            *seed,
+	})
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated %d URLs (%d suspicious) in %v\n",
+		stats.Total, stats.Suspicious, time.Since(start).Round(time.Millisecond))
+	return 0
+}
+
+// parseCount parses counts like "500", "10K" or "1M"
+func parseCount(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	mult := 1
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'k', 'K':
+			mult = 1_000
+		case 'm', 'M':
+			mult = 1_000_000
+		case 'g', 'G':
+			mult = 1_000_000_000
+		}
+		if mult > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("count must not be negative")
+	}
+	return n * mult, nil
+}
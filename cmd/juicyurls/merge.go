@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"juicyurls/internal/baseline"
+)
+
+// runMerge implements `juicyurls merge a.json b.json ... -o merged.json`
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	outputPath := fs.String("o", "", "Output file path (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: juicyurls merge [-o merged.json] a.json b.json ...")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+	if len(paths) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var runs []baseline.Run
+	total := 0
+	for _, path := range paths {
+		results, err := baseline.Read(path)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		runs = append(runs, baseline.Run{Name: path, Results: results})
+		total += len(results)
+	}
+	merged := baseline.Merge(runs)
+
+	var out io.Writer = os.Stdout
+	if *outputPath != "" {
+		f, err := os.Create(*outputPath)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	for _, r := range merged {
+		if err := enc.Encode(r); err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Printf("Error: %v", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Merged %d findings from %d runs into %d (%d duplicates)\n",
+		total, len(runs), len(merged), total-len(merged))
+	return 0
+}
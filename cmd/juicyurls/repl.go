@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"juicyurls/internal/checker"
+	"juicyurls/pkg/juicyurls"
+	"juicyurls/pkg/writer"
+	"juicyurls/suspicious"
+)
+
+// runRepl implements `juicyurls repl`
+func runRepl(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	scanner := scannerFlags(fs, nil)
+	fs.Parse(args)
+	s := scanner()
+
+	interactive := false
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		interactive = true
+		fmt.Printf("Rules %s. Paste URLs; Ctrl-D or exit to quit.\n", s.RulesHash())
+	}
+	repl(s, os.Stdin, os.Stdout, interactive, writer.IsTerminal(os.Stdout))
+	return 0
+}
+
+// repl reads lines from in and prints a verdict for every URL on them.
+// Pasted lines may hold several URLs, or a URL among other words, as in a
+// request line copied from a proxy.
+func repl(s *juicyurls.Scanner, in io.Reader, out io.Writer, prompt, color bool) {
+	lines := bufio.NewScanner(in)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		if prompt {
+			fmt.Fprint(out, "> ")
+		}
+		if !lines.Scan() {
+			break
+		}
+		line := strings.TrimSpace(lines.Text())
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			return
+		}
+
+		urls := lineURLs(line)
+		if len(urls) == 0 {
+			fmt.Fprintf(out, "no URL in %q\n", line)
+		}
+		for _, u := range urls {
+			verdict(out, u, s.Explain(u), color)
+		}
+	}
+	if prompt {
+		fmt.Fprintln(out)
+	}
+}
+
+// lineURLs picks the URLs out of a line: the words with a scheme, or else
+// the whole line when it is a single schemeless URL such as example.com/x.
+// Words like HTTP/1.1 would pass for schemeless URLs, hence the fallback.
+func lineURLs(line string) []string {
+	var urls []string
+	words := strings.Fields(line)
+	for _, word := range words {
+		if strings.Contains(word, "://") && checker.IsValidURL(word) {
+			urls = append(urls, word)
+		}
+	}
+	if len(urls) == 0 && len(words) == 1 && checker.IsValidURL(line) {
+		urls = words
+	}
+	return urls
+}
+
+// verdict prints a URL's verdict and one line per matched rule
+func verdict(out io.Writer, url string, matches []juicyurls.Result, color bool) {
+	paint := func(severity, s string) string {
+		if color {
+			return writer.Colorize(severity, s)
+		}
+		return s
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(out, "%s  %s\n", paint("info", "clean"), url)
+		return
+	}
+
+	worst := matches[0].Severity
+	for _, m := range matches[1:] {
+		if suspicious.SeverityRank(m.Severity) > suspicious.SeverityRank(worst) {
+			worst = m.Severity
+		}
+	}
+	fmt.Fprintf(out, "%s  %s\n", paint(worst, strings.ToUpper(worst)), url)
+	for _, m := range matches {
+		// Pad before painting, as escape codes would throw off the width
+		fmt.Fprintf(out, "  %s %-9s %s  %s", paint(m.Severity, fmt.Sprintf("%-8s", m.Severity)), m.Confidence, m.RuleID, m.Reason)
+		if m.Pattern != "" {
+			fmt.Fprintf(out, " [%s %q at %d]", m.Component, m.Pattern, m.Offset)
+		}
+		fmt.Fprintln(out)
+	}
+}
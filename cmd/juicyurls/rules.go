@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"juicyurls/internal/checker"
+	"juicyurls/internal/rules"
+)
+
+// rulesUsage lists the rules commands
+const rulesUsage = `Usage:
+  juicyurls rules test [-rules file]...
+  juicyurls rules taxonomy [-rules file]... [-format text|json]`
+
+// runRules implements `juicyurls rules <command>`
+func runRules(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, rulesUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "test":
+		return runRulesTest(args[1:])
+	case "taxonomy":
+		return runRulesTaxonomy(args[1:])
+	}
+	log.Printf("Unknown rules command: %s", args[0])
+	return 1
+}
+
+// runRulesTest validates built-in and user rules against their examples
+func runRulesTest(args []string) int {
+	fs := flag.NewFlagSet("rules test", flag.ExitOnError)
+	var files stringList
+	fs.Var(&files, "rules", "Rules file to test (repeatable)")
+	fs.Parse(args)
+
+	user, err := rules.LoadFiles(files)
+	if err != nil {
+		log.Printf("Invalid rules file: %v", err)
+		return 1
+	}
+
+	sum := rules.RunTests(append(rules.Builtin(), user...))
+	for _, f := range sum.Failures {
+		fmt.Printf("FAIL  %s\n", f)
+	}
+	fmt.Printf("%d rules, %d with tests, %d examples, %d failures\n",
+		sum.Rules, sum.Tested, sum.Examples, len(sum.Failures))
+	if len(sum.Failures) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRulesTaxonomy prints the categories, severities and rules that a
+// scan with the given rules files would match
+func runRulesTaxonomy(args []string) int {
+	fs := flag.NewFlagSet("rules taxonomy", flag.ExitOnError)
+	var files stringList
+	fs.Var(&files, "rules", "Rules file to include (repeatable)")
+	format := fs.String("format", "text", "Output format: text or json")
+	fs.Parse(args)
+
+	user, err := rules.LoadFiles(files)
+	if err != nil {
+		log.Printf("Invalid rules file: %v", err)
+		return 1
+	}
+	tax := checker.NewURLChecker("", "", user...).Taxonomy()
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tax); err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+	case "text":
+		for _, cat := range tax.Categories {
+			fmt.Printf("%s (%d rules): %s\n", cat.Name, cat.Count, cat.Reason)
+			for _, sev := range cat.Severities {
+				fmt.Printf("  %s (%d)\n", sev.Severity, sev.Count)
+				for _, r := range sev.Rules {
+					fmt.Printf("    %s\n", r.ID)
+				}
+			}
+		}
+		fmt.Printf("%d categories, %d rules\n", len(tax.Categories), tax.Count)
+	default:
+		log.Printf("Invalid -format: %s (use text or json)", *format)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/config"
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestMain runs the command instead of the tests when asked to, so exit
+// statuses can be checked on a real process
+func TestMain(m *testing.M) {
+	if args := os.Getenv("JUICYURLS_TEST_MAIN"); args != "" {
+		os.Args = append(os.Args[:1], strings.Split(args, "\n")...)
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// command runs juicyurls with args and env, returning its exit status
+// and standard error
+func command(t *testing.T, env []string, args ...string) (int, string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), "JUICYURLS_TEST_MAIN="+strings.Join(args, "\n"))
+	cmd.Env = append(cmd.Env, env...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	return cmd.ProcessState.ExitCode(), stderr.String()
+}
+
+// TestSubcommandStatus passes each subcommand's status through to the exit
+// status
+func TestSubcommandStatus(t *testing.T) {
+	dir := t.TempDir()
+	failing := filepath.Join(dir, "failing.yaml")
+	os.WriteFile(failing, []byte(`rules:
+  - id: zz:broken
+    category: zz
+    pattern: /zzq
+    tests:
+      match: ["https://example.com/nothing"]
+`), 0o644)
+	results := filepath.Join(dir, "results.json")
+	os.WriteFile(results, []byte(`{"url":"https://example.com/.env","rule_id":"hidden:dotenv"}`+"\n"), 0o644)
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"rules", "test"}, 0},
+		{[]string{"rules", "test", "-rules", failing}, 1},
+		{[]string{"rules", "nope"}, 1},
+		{[]string{"rules"}, 2},
+		{[]string{"diff", results, results}, 0},
+		{[]string{"diff", results, missing}, 1},
+		{[]string{"diff", results}, 2},
+		{[]string{"merge", results, "-o", filepath.Join(dir, "merged.json")}, 0},
+		{[]string{"merge", missing}, 1},
+		{[]string{"merge"}, 2},
+		{[]string{"fp"}, 2},
+		{[]string{"serve", "nope"}, 2},
+		{[]string{"gen-corpus", "-n", "x"}, 1},
+	}
+	for _, tt := range tests {
+		if got, stderr := command(t, nil, tt.args...); got != tt.want {
+			t.Errorf("juicyurls %s exited %d; want %d\n%s", strings.Join(tt.args, " "), got, tt.want, stderr)
+		}
+	}
+}
+
+// TestScanStatus exits with the -exit-code status, and with 1 for options
+// that fail once cleanup is deferred
+func TestScanStatus(t *testing.T) {
+	tests := []struct {
+		env  []string
+		args []string
+		want int
+	}{
+		{nil, []string{"-exit-code", "-u", "https://example.com/.git/config"}, 30},
+		{nil, []string{"-exit-code", "-u", "https://example.com/"}, 0},
+		{nil, []string{"-u", "https://example.com/.git/config"}, 0},
+		// The environment applies when the flag is not given, and the flag
+		// wins when it is
+		{[]string{"JUICYURLS_MIN_SEVERITY=critical"}, []string{"-exit-code", "-u", "https://example.com/.git/config"}, 0},
+		{[]string{"JUICYURLS_MIN_SEVERITY=critical"}, []string{"-exit-code", "-min-severity", "high", "-u", "https://example.com/.git/config"}, 30},
+		{nil, []string{"-metrics-addr", "256.0.0.1:x", "-u", "https://example.com/"}, 1},
+		{nil, []string{"-min-severity", "severe", "-u", "https://example.com/"}, 1},
+		{nil, []string{"-m", "nope", "-u", "https://example.com/"}, 1},
+	}
+	for _, tt := range tests {
+		if got, stderr := command(t, tt.env, tt.args...); got != tt.want {
+			t.Errorf("%s juicyurls %s exited %d; want %d\n%s", strings.Join(tt.env, " "), strings.Join(tt.args, " "), got, tt.want, stderr)
+		}
+	}
+}
+
+// TestSeverityStatus encodes the highest severity reported, ignoring info
+func TestSeverityStatus(t *testing.T) {
+	tests := []struct {
+		severities map[string]uint64
+		want       int
+	}{
+		{nil, 0},
+		{map[string]uint64{"info": 3}, 0},
+		{map[string]uint64{"low": 1, "info": 2}, 10},
+		{map[string]uint64{"medium": 1}, 20},
+		{map[string]uint64{"low": 5, "high": 1}, 30},
+		{map[string]uint64{"critical": 1, "medium": 9}, 40},
+	}
+	for _, tt := range tests {
+		if got := severityStatus(types.Stats{Severities: tt.severities}); got != tt.want {
+			t.Errorf("severityStatus(%v) = %d; want %d", tt.severities, got, tt.want)
+		}
+	}
+}
+
+// TestSetUnset layers sources: the command line, then each source applied
+// in turn, so an earlier source wins over a later one
+func TestSetUnset(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	workers := fs.Int("w", 0, "")
+	format := fs.String("format", "text", "")
+	output := fs.String("o", "", "")
+	var rulesFiles stringList
+	fs.Var(&rulesFiles, "rules", "")
+	if err := fs.Parse([]string{"-w", "4"}); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string][]string{"w": {"8"}, "format": {"json"}, "rules": {"a.yaml", "b.yaml"}}
+	if err := setUnset(fs, env, "environment"); err != nil {
+		t.Fatal(err)
+	}
+	file := map[string][]string{"w": {"16"}, "format": {"cef"}, "o": {"out.json"}, "rules": {"c.yaml"}}
+	if err := setUnset(fs, file, "config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 4 || *format != "json" || *output != "out.json" || rulesFiles.String() != "a.yaml,b.yaml" {
+		t.Errorf("-w %d -format %s -o %s -rules %s; want 4 from the command line, json and a.yaml,b.yaml from the environment, out.json from the file",
+			*workers, *format, *output, rulesFiles.String())
+	}
+
+	err := setUnset(fs, map[string][]string{"o": {"x"}, "nope": {"1"}}, "config.yaml")
+	if err == nil || !strings.Contains(err.Error(), "config.yaml") {
+		t.Errorf("err = %v; want an error naming config.yaml", err)
+	}
+}
+
+// notFileKeys are the scan flags a config file cannot set, because they
+// pick the config itself or only print help
+var notFileKeys = map[string]bool{"h": true, "config": true, "profile": true, "project": true}
+
+// TestFileKeys checks that every scan flag can be set from a config file
+// and the environment, unless it is excluded on purpose
+func TestFileKeys(t *testing.T) {
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	os.Args = []string{"juicyurls", "-h"}
+	run()
+	os.Stdout = stdout
+
+	inFile := make(map[string]bool)
+	for _, name := range config.FileKeys {
+		if flag.Lookup(name) == nil {
+			t.Errorf("config key for -%s, which is not a flag", name)
+		}
+		inFile[name] = true
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		// The testing package's own flags share the command line
+		if !inFile[f.Name] && !notFileKeys[f.Name] && !strings.HasPrefix(f.Name, "test.") {
+			t.Errorf("-%s has no config file key", f.Name)
+		}
+	})
+}
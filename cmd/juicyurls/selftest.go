@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"juicyurls/internal/checker"
+	"juicyurls/internal/selftest"
+)
+
+// runSelftest implements `juicyurls selftest`
+func runSelftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	workers := fs.Int("w", 0, "Number of worker goroutines (default: CPU cores)")
+	fs.Parse(args)
+
+	report, err := selftest.Run(context.Background(), checker.NewURLChecker("", ""), *workers)
+	if err != nil {
+		log.Printf("Self-test error: %v", err)
+		return 1
+	}
+
+	for _, line := range report.Missing {
+		fmt.Printf("MISSING     %s\n", line)
+	}
+	for _, line := range report.Unexpected {
+		fmt.Printf("UNEXPECTED  %s\n", line)
+	}
+	if !report.OK() {
+		fmt.Printf("Self-test FAILED: %d expected, %d found, %d missing, %d unexpected\n",
+			report.Expected, report.Found, len(report.Missing), len(report.Unexpected))
+		return 1
+	}
+	fmt.Printf("Self-test passed: %d findings matched\n", report.Found)
+	return 0
+}
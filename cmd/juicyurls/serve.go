@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"juicyurls/internal/checker"
+	"juicyurls/internal/rules"
+	"juicyurls/internal/server"
+	"juicyurls/pkg/juicyurls"
+)
+
+// runServe implements `juicyurls serve [protocol]`; the protocol defaults
+// to http
+func runServe(args []string) int {
+	protocol := "http"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		protocol, args = args[0], args[1:]
+	}
+
+	// Both servers count their scans for GET /metrics
+	m := juicyurls.NewMetrics()
+	switch protocol {
+	case "http":
+		fs := flag.NewFlagSet("serve http", flag.ExitOnError)
+		addr := fs.String("addr", "localhost:8080", "Address to listen on")
+		maxUpload := fs.Int64("max-upload", 100<<20, "Largest accepted /scan-file upload in bytes")
+		coordinatorURL := fs.String("coordinator", "", "Register as a worker with the juicyurls coordinate at this URL")
+		advertise := fs.String("advertise", "", "URL the coordinator reaches this worker at (default: http://<addr>)")
+		scanner := scannerFlags(fs, m)
+		fs.Parse(args)
+		s := scanner()
+		if *coordinatorURL != "" {
+			if *advertise == "" {
+				*advertise = "http://" + *addr
+			}
+			go register(context.Background(), strings.TrimSuffix(*coordinatorURL, "/"), *advertise)
+		}
+		return listen(*addr, withMetrics(server.HTTP(s, *maxUpload), m), "HTTP", s)
+	case "grpc":
+		fs := flag.NewFlagSet("serve grpc", flag.ExitOnError)
+		addr := fs.String("addr", "localhost:50051", "Address to listen on")
+		scanner := scannerFlags(fs, m)
+		fs.Parse(args)
+		s := scanner()
+		return listen(*addr, h2c.NewHandler(withMetrics(server.GRPC(s), m), &http2.Server{}), "gRPC", s)
+	}
+	fmt.Fprintf(os.Stderr, "Unknown serve protocol: %s\nUsage: juicyurls serve [http|grpc] [-addr host:port] [options]\n", protocol)
+	return 2
+}
+
+// scannerFlags registers the detection options of the serve commands and
+// returns a function building the scanner, counting into m if set, once flags
+// are parsed. Categories passed to it replace those selected with -m and -M.
+func scannerFlags(fs *flag.FlagSet, m *juicyurls.Metrics) func(only ...string) *juicyurls.Scanner {
+	var rulesFiles stringList
+	fs.Var(&rulesFiles, "rules", "YAML rules file (repeatable)")
+	categories := fs.String("m", "", "Categories to check")
+	skip := fs.String("M", "", "Categories to skip")
+	excludes := fs.String("e", "", "Exclude patterns")
+	minSeverity := fs.String("min-severity", "", "Only report findings at or above this severity")
+	minConfidence := fs.String("min-confidence", "", "Only report findings at or above this confidence")
+
+	return func(only ...string) *juicyurls.Scanner {
+		extra, err := rules.LoadFiles(rulesFiles)
+		if err != nil {
+			log.Fatalf("Invalid rules file: %v", err)
+		}
+		cats, err := checker.ResolveCategories(*categories, *skip, extra)
+		if err != nil {
+			log.Fatalf("Invalid %v", err)
+		}
+		selected := splitList(cats)
+		if len(only) > 0 {
+			selected = only
+		}
+		s, err := juicyurls.New(juicyurls.Options{
+			Categories:    selected,
+			Excludes:      splitList(*excludes),
+			Rules:         extra,
+			MinSeverity:   *minSeverity,
+			MinConfidence: *minConfidence,
+			Metrics:       m,
+		})
+		if err != nil {
+			log.Fatalf("Invalid options: %v", err)
+		}
+		return s
+	}
+}
+
+// splitList splits a comma-separated flag value
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// withMetrics adds GET /metrics to h, for Prometheus
+func withMetrics(h http.Handler, m *juicyurls.Metrics) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	mux.Handle("GET /metrics", m.Handler())
+	return mux
+}
+
+// serveMetrics serves m at GET /metrics on addr until ctx ends
+func serveMetrics(ctx context.Context, addr string, m *juicyurls.Metrics) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	return serveAlongside(ctx, addr, mux)
+}
+
+// servePprof serves the runtime profiles of net/http/pprof under
+// /debug/pprof/ on addr until ctx ends
+func servePprof(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return serveAlongside(ctx, addr, mux)
+}
+
+// serveAlongside serves h on addr while a scan runs, until ctx ends. The
+// listener is opened before it returns, so a bad address fails the scan.
+func serveAlongside(ctx context.Context, addr string, h http.Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go srv.Serve(ln)
+	return nil
+}
+
+// listen serves h on addr until interrupted, then drains open calls, and
+// returns the exit status
+func listen(addr string, h http.Handler, protocol string, s *juicyurls.Scanner) int {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("Listen: %v", err)
+		return 1
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("Serving %s on %s (rules %s)", protocol, ln.Addr(), s.RulesHash())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Serve: %v", err)
+		return 1
+	}
+	return 0
+}
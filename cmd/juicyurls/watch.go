@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"juicyurls/config"
+	"juicyurls/internal/processor"
+	"juicyurls/internal/watch"
+	"juicyurls/pkg/writer"
+)
+
+// resultsExt is the extension of -watch results files in a format
+func resultsExt(format string) string {
+	switch format {
+	case writer.FormatJSON:
+		return ".json"
+	case writer.FormatMarkdown:
+		return ".md"
+	case writer.FormatCEF:
+		return ".cef"
+	case writer.FormatLEEF:
+		return ".leef"
+	}
+	return ".txt"
+}
+
+// scanDropped scans a list found by -watch. Results are written under a
+// hidden name and renamed once complete, so pipelines waiting for the
+// results file never read a partial one.
+func scanDropped(ctx context.Context, base *config.Config, path string, timeout time.Duration) error {
+	final := watch.ResultsPath(path, resultsExt(base.Format))
+	tmp := filepath.Join(filepath.Dir(final), "."+filepath.Base(final)+".tmp")
+
+	cfg := *base
+	cfg.FilePath, cfg.OutputPath = path, tmp
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := processor.ProcessFile(ctx, &cfg); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return err
+	}
+	cfg.Logger.Info("scanned dropped list", "file", path, "results", final)
+	return nil
+}
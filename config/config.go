@@ -1,29 +1,110 @@
 package config
 
 import (
+	"io"
+	"log/slog"
 	"time"
 
+	"juicyurls/internal/anomaly"
+	"juicyurls/internal/baseline"
 	"juicyurls/internal/checker"
+	"juicyurls/internal/checkpoint"
+	"juicyurls/internal/dedup"
+	"juicyurls/internal/input"
+	"juicyurls/internal/metrics"
+	"juicyurls/internal/netclient"
+	"juicyurls/internal/otlp"
+	"juicyurls/internal/route"
+	"juicyurls/internal/scope"
+	"juicyurls/internal/shard"
+	"juicyurls/internal/triage"
+	"juicyurls/internal/types"
+	"juicyurls/internal/verify"
 )
 
 const (
 	MaxURLLength     = 2048
 	MaxFileSize      = 500 * 1024 * 1024 // 500MB limit (increased)
 	BufferSize       = 1024 * 1024       // 1MB buffer (increased)
+	MapThreshold     = 64 * 1024 * 1024  // Input files this large are memory-mapped
 	DefaultTimeout   = 300 * time.Second // 5 minutes default (increased)
 	MaxWorkers       = 500               // Increased max workers
 	ProgressInterval = 10000             // Report progress every 10k URLs
+	DefaultProgress  = time.Second       // Default Progress callback interval
 )
 
 // Config holds application configuration
 type Config struct {
-	FilePath     string
-	OutputPath   string
-	Categories   string
-	Excludes     string
-	Workers      int
-	Timeout      time.Duration
-	Verbose      bool
-	ValidateURLs bool
-	URLChecker   *checker.URLChecker // Use pointer for URLChecker
+	FilePath        string
+	InputFiles      []string               // Further URL lists read after FilePath, in order
+	Reader          io.Reader              // URL list read after the files, reported as source "-"
+	Streams         []input.Stream         // Live log sources read after the files until the scan ends
+	Follow          bool                   // Keep reading the files as they grow, until the scan ends
+	StatePath       string                 // File storing per-input read offsets; empty reads inputs whole
+	Checkpoint      *checkpoint.Checkpoint // Progress saved while scanning, for resuming; ProcessFile only
+	InputFormat     string                 // Input line format: urls (default) or hostport
+	Extract         bool                   // Input files are free text; URLs are taken from anywhere in them
+	URLs            []string               // Inline URLs from -u and positional arguments
+	Unique          io.Writer              // Receives each distinct input URL once; only those are checked. Nil checks them all
+	OutputPath      string
+	PostgresDSN     string // Findings are upserted into PostgreSQL instead of written to OutputPath
+	Elasticsearch   string // Findings are indexed into this Elasticsearch URL instead of written to OutputPath
+	Webhook         string // Findings are POSTed in batches to this URL instead of written to OutputPath
+	SyslogOut       string // Findings are sent as RFC 5424 messages to this udp:// or tcp:// collector instead of written to OutputPath
+	ErrorsPath      string // Input lines that cannot be checked are written here with the reason; empty writes none
+	Notify          string // Slack or Discord webhook URL told the scan's summary; ProcessFile only
+	NotifySeverity  string // Lowest severity also posted to Notify per finding; empty posts the summary only
+	Email           string // SMTP URL a digest of the scan is emailed through; ProcessFile only
+	StatsPath       string // Scan statistics are written here as JSON; empty writes none
+	Format          string // Output format, one of writer.Formats: text (default), json, summary, markdown, grep, cef or leef
+	Categories      string
+	SkipCategories  string // Categories removed from the selection (-M)
+	Excludes        string
+	ExcludeFile     string // Exclude patterns, one per line
+	Workers         int
+	Readers         int // Goroutines reading each memory-mapped input file in ranges; 0 uses one per four workers
+	Timeout         time.Duration
+	Verbose         bool
+	ValidateURLs    bool
+	MinSeverity     string             // Lowest severity reported; empty reports all
+	MinConfidence   string             // Lowest confidence reported; empty reports all
+	Scoring         bool               // Evaluate all rules and sum their weights
+	MinScore        int                // Lowest score reported; implies Scoring
+	MaxPerRule      int                // Findings reported per rule; more are counted, not written. Zero is no limit
+	Sort            bool               // Findings are written in extsort.Compare order once the scan ends; file output only
+	SortMemory      int64              // Bytes of findings Sort holds before spilling runs to temporary files; 0 is extsort.DefaultLimit
+	MaxFieldLength  int                // Bytes URLs, patterns and contexts are cut to in structured formats and sinks; 0 writes them whole
+	MaxMemory       int64              // Bytes of memory the scan aims to stay under, reading less ahead and spilling sort runs early near it; 0 is no limit
+	TechStack       bool               // Infer each host's technologies from its URLs, for the stats
+	BaselinePath    string             // Earlier JSON results (-baseline)
+	Baseline        *baseline.Baseline // Findings in it are counted as known, not written; nil reports all
+	FalsePositives  *triage.Store      // Findings its decisions cover are counted as false positives, not written; nil reports all
+	Dedup           dedup.Filter       // Findings whose fingerprint it has seen are counted as repeated, not written; nil writes all
+	Verify          verify.Verifier    // Findings whose target it finds dead are dropped; nil keeps all
+	KeywordsFile    string             // "+path" appends to built-in keywords, "path" replaces them
+	ExtensionsFile  string             // Same semantics as KeywordsFile
+	PathsFile       string             // Same semantics as KeywordsFile
+	HiddenFile      string             // Same semantics as KeywordsFile
+	SharesFile      string             // Same semantics as KeywordsFile
+	ExtraKeywords   string             // Extra pattern files always extend the built-ins,
+	ExtraExtensions string             // unless their category is listed in ReplaceBuiltin
+	ExtraPaths      string
+	ExtraHidden     string
+	ExtraShares     string
+	ReplaceBuiltin  string
+	RulesFiles      []string             // YAML rules files with extra patterns
+	Heartbeat       time.Duration        // Interval between heartbeat log lines (0 = off)
+	Metrics         *metrics.Metrics     // Counts for /metrics, shared across scans; nil counts nothing
+	Anomaly         *anomaly.Detector    // Watches the rate of findings per category, shared across scans; nil watches none
+	Telemetry       *otlp.Exporter       // Receives a trace and metrics of each scan; nil sends none
+	Routes          []route.Route        // Categories written to destinations of their own instead of the output
+	Progress        func(types.Progress) // Called every ProgressEvery and once when done; never concurrently
+	ProgressEvery   time.Duration        // Progress callback interval (default: 1s)
+	Finished        func(types.Stats)    // Called with the final statistics once a scan has ended; ProcessFile only
+	Logger          *slog.Logger         // Structured logger for operational messages
+	Net             netclient.Options    // Network settings for every feature that makes requests
+	URLChecker      *checker.URLChecker  // Use pointer for URLChecker
+	ScopePath       string               // Domain allowlist file (-scope)
+	Scope           *scope.Scope         // URLs outside it are counted and skipped; nil scans all
+	Shard           *shard.Shard         // URLs of other shards are counted and skipped; nil scans all
 }
@@ -0,0 +1,44 @@
+package config
+
+import "strings"
+
+// EnvPrefix prefixes environment variables that set flags
+const EnvPrefix = "JUICYURLS_"
+
+// repeatableFlags accept several values; from the environment they are comma-separated
+var repeatableFlags = map[string]bool{"u": true, "rules": true, "route": true}
+
+// EnvName returns the environment variable for a config file key,
+// e.g. "keywords-file" -> "JUICYURLS_KEYWORDS_FILE"
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// EnvValues returns the flag values set through JUICYURLS_* environment
+// variables, keyed by flag name. JUICYURLS_CONFIG, JUICYURLS_PROFILE and
+// JUICYURLS_PROJECT set -config, -profile and -project.
+func EnvValues(lookup func(string) (string, bool)) map[string][]string {
+	values := make(map[string][]string)
+	for _, name := range []string{"config", "profile", "project"} {
+		if v, ok := lookup(EnvName(name)); ok && v != "" {
+			values[name] = []string{v}
+		}
+	}
+
+	for key, name := range FileKeys {
+		v, ok := lookup(EnvName(key))
+		if !ok || v == "" {
+			continue
+		}
+		if repeatableFlags[name] {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					values[name] = append(values[name], item)
+				}
+			}
+			continue
+		}
+		values[name] = []string{v}
+	}
+	return values
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileKeys maps config file keys to the command-line flags they set
+var FileKeys = map[string]string{
+	"input":            "l",
+	"preset":           "preset",
+	"input-format":     "input-format",
+	"extract":          "extract",
+	"unique":           "unique",
+	"syslog":           "syslog",
+	"journal":          "journal",
+	"watch":            "watch",
+	"urls":             "u",
+	"output":           "o",
+	"stats":            "stats",
+	"errors-out":       "errors-out",
+	"postgres":         "postgres",
+	"elasticsearch":    "elasticsearch",
+	"webhook":          "webhook",
+	"syslog-out":       "syslog-out",
+	"notify":           "notify",
+	"notify-severity":  "notify-severity",
+	"email":            "email",
+	"format":           "format",
+	"siem-fields":      "siem-fields",
+	"max-field-length": "max-field-length",
+	"categories":       "m",
+	"skip-categories":  "M",
+	"excludes":         "e",
+	"exclude-file":     "exclude-file",
+	"scope":            "scope",
+	"shard":            "shard",
+	"workers":          "w",
+	"readers":          "readers",
+	"timeout":          "t",
+	"verbose":          "v",
+	"validate":         "validate",
+	"min-severity":     "min-severity",
+	"min-confidence":   "min-confidence",
+	"score":            "score",
+	"min-score":        "min-score",
+	"max-per-rule":     "max-per-rule",
+	"sort":             "sort",
+	"sort-memory":      "sort-memory",
+	"max-memory":       "max-memory",
+	"baseline":         "baseline",
+	"fp":               "fp",
+	"dedup":            "dedup",
+	"dedup-fp":         "dedup-fp",
+	"verify":           "verify",
+	"exit-code":        "exit-code",
+	"tech":             "tech",
+	"user-agent":       "user-agent",
+	"http-timeout":     "http-timeout",
+	"ip-version":       "ip-version",
+	"insecure":         "insecure",
+	"ca-file":          "ca-file",
+	"offline":          "offline",
+	"heartbeat":        "heartbeat",
+	"metrics-addr":     "metrics-addr",
+	"alert":            "alert",
+	"alert-window":     "alert-window",
+	"alert-factor":     "alert-factor",
+	"otlp":             "otlp",
+	"pprof":            "pprof",
+	"state":            "state",
+	"checkpoint":       "checkpoint",
+	"resume":           "resume",
+	"follow":           "follow",
+	"rules":            "rules",
+	"plugin":           "plugin",
+	"route":            "route",
+	"keywords-file":    "keywords-file",
+	"extensions-file":  "extensions-file",
+	"paths-file":       "paths-file",
+	"hidden-file":      "hidden-file",
+	"shares-file":      "shares-file",
+	"extra-keywords":   "extra-keywords",
+	"extra-extensions": "extra-extensions",
+	"extra-paths":      "extra-paths",
+	"extra-hidden":     "extra-hidden",
+	"extra-shares":     "extra-shares",
+	"replace-builtin":  "replace-builtin",
+}
+
+// commaFlags take a single comma-separated value; a YAML list is joined
+var commaFlags = map[string]bool{"m": true, "M": true, "e": true, "replace-builtin": true}
+
+// profilesKey holds named profiles in a config file
+const profilesKey = "profiles"
+
+// LoadFile reads a YAML config file and returns the flag values it sets,
+// keyed by flag name. Repeatable flags may carry several values. When
+// profile is not empty, the values of that named profile override the
+// top-level ones.
+func LoadFile(path, profile string) (map[string][]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	profiles, _ := doc[profilesKey].(map[string]any)
+	if _, ok := doc[profilesKey]; ok && profiles == nil {
+		return nil, fmt.Errorf("%s: %q must be a mapping of profile names", path, profilesKey)
+	}
+	delete(doc, profilesKey)
+
+	values, err := flagValues(path, doc)
+	if err != nil || profile == "" {
+		return values, err
+	}
+
+	p, ok := profiles[profile].(map[string]any)
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%s: unknown profile %q (available: %s)", path, profile, strings.Join(names, ", "))
+	}
+	overrides, err := flagValues(path+": profile "+profile, p)
+	if err != nil {
+		return nil, err
+	}
+	for name, v := range overrides {
+		values[name] = v
+	}
+	return values, nil
+}
+
+// flagValues converts decoded config keys into flag values
+func flagValues(path string, doc map[string]any) (map[string][]string, error) {
+	values := make(map[string][]string, len(doc))
+	for key, v := range doc {
+		name, ok := FileKeys[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown key %q (valid keys: %s)", path, key, validKeys())
+		}
+
+		switch v := v.(type) {
+		case nil:
+			continue
+		case []any:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			if commaFlags[name] {
+				items = []string{strings.Join(items, ",")}
+			}
+			values[name] = items
+		case map[string]any:
+			return nil, fmt.Errorf("%s: key %q must be a scalar or list", path, key)
+		default:
+			values[name] = []string{fmt.Sprint(v)}
+		}
+	}
+	return values, nil
+}
+
+func validKeys() string {
+	keys := make([]string, 0, len(FileKeys))
+	for k := range FileKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+)
+
+// TestLoadFile maps config keys to flag values
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+input: urls.txt
+categories: [keywords, paths]
+urls: [https://a.example, https://b.example]
+workers: 8
+verbose: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	want := map[string][]string{
+		"l": {"urls.txt"},
+		"m": {"keywords,paths"},
+		"u": {"https://a.example", "https://b.example"},
+		"w": {"8"},
+		"v": {"true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile = %v; want %v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("wrokers: 8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path, ""); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+// TestLoadFileProfile lets a named profile override top-level values
+func TestLoadFileProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+workers: 8
+categories: [keywords, paths]
+profiles:
+  secrets-only:
+    categories: [hidden]
+    keywords-file: secrets.txt
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadFile(path, "secrets-only")
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	want := map[string][]string{
+		"w":             {"8"},
+		"m":             {"hidden"},
+		"keywords-file": {"secrets.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile = %v; want %v", got, want)
+	}
+
+	if _, err := LoadFile(path, "missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+// TestEnvValues maps JUICYURLS_* variables to flag values
+func TestEnvValues(t *testing.T) {
+	env := map[string]string{
+		"JUICYURLS_WORKERS":       "4",
+		"JUICYURLS_KEYWORDS_FILE": "+kw.txt",
+		"JUICYURLS_RULES":         "a.yaml, b.yaml",
+		"JUICYURLS_CONFIG":        "scan.yaml",
+		"JUICYURLS_TIMEOUT":       "",
+	}
+	got := EnvValues(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	})
+	want := map[string][]string{
+		"w":             {"4"},
+		"keywords-file": {"+kw.txt"},
+		"rules":         {"a.yaml", "b.yaml"},
+		"config":        {"scan.yaml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvValues = %v; want %v", got, want)
+	}
+}
+
+// TestLoadProject resolves project paths against the project's directory
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "acme.yaml")
+	content := `
+name: acme
+seeds: [https://acme.example/]
+inputs:
+  - file: a.txt
+  - file: /abs/b.txt
+rules:
+  files: [rules.yaml]
+  categories: [keywords, hidden]
+  excludes: [cdn.acme.example, "re:x{1,2}"]
+output:
+  path: out.json
+  format: json
+settings:
+  workers: 4
+  keywords-file: +kw.txt
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadProject(path)
+	if err != nil {
+		t.Fatalf("LoadProject error: %v", err)
+	}
+	if got, want := p.Files(), []string{filepath.Join(dir, "a.txt"), "/abs/b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Files = %v; want %v", got, want)
+	}
+	got, err := p.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"u":             {"https://acme.example/"},
+		"rules":         {filepath.Join(dir, "rules.yaml")},
+		"m":             {"keywords,hidden"},
+		"e":             {"cdn.acme.example,re:x{1,2}"},
+		"o":             {filepath.Join(dir, "out.json")},
+		"format":        {"json"},
+		"w":             {"4"},
+		"keywords-file": {"+" + filepath.Join(dir, "kw.txt")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %v; want %v", got, want)
+	}
+
+	for _, bad := range []string{"inputs: [{wayback: acme.example}]\n", "inputs: [{}]\n", "settings: {input: a.txt}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := LoadProject(path)
+		if err == nil {
+			_, err = p.Values()
+		}
+		if err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// TestPresets loads every preset and checks its suppressions
+func TestPresets(t *testing.T) {
+	for _, name := range PresetNames() {
+		p, err := LoadPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Values(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := LoadPreset("nope"); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+
+	tests := []struct {
+		preset string
+		url    string
+		want   bool
+	}{
+		{"secrets", "https://example.com/.env", true},
+		{"secrets", "https://example.com/.env.example", false},
+		{"secrets", "https://example.com/app/.env.dist", false},
+		{"secrets", "https://example.com/admin", false},
+		{"exposure", "https://example.com/admin/", true},
+		{"exposure", "https://example.com/wp-content/uploads/a.png", false},
+		{"exposure", "https://example.com/wp-content/uploads/backup.zip", true},
+		{"exposure", "https://example.com/wp-admin/admin-ajax.php", false},
+		{"exposure", "https://example.com/index.php", false},
+		{"phishing", "https://xn--pypal-4ve.com/", true},
+		{"phishing", "https://example.com/.env", false},
+	}
+	for _, tt := range tests {
+		p, err := LoadPreset(tt.preset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, _ := p.Values()
+		uc := checker.NewURLChecker(strings.Join(values["m"], ","), "", p.Rules...)
+		if len(values["min-severity"]) > 0 {
+			uc.SetMinSeverity(values["min-severity"][0])
+		}
+		if len(values["min-confidence"]) > 0 {
+			uc.SetMinConfidence(values["min-confidence"][0])
+		}
+		if f, got := uc.Check(tt.url); got != tt.want {
+			t.Errorf("%s: Check(%s) = %+v, %v; want %v", tt.preset, tt.url, f, got, tt.want)
+		}
+	}
+}
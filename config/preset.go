@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"juicyurls/internal/rules"
+)
+
+//go:embed presets/*.yaml
+var presetFS embed.FS
+
+// Preset is a built-in bundle of settings and suppress rules tuned for a
+// common use case, selected with -preset. Its settings rank below every
+// other source, so flags, config and project files adjust it.
+type Preset struct {
+	Name        string         `yaml:"-"`
+	Description string         `yaml:"description"`
+	Settings    map[string]any `yaml:"settings"` // Config file keys
+	Rules       []rules.Rule   `yaml:"rules"`    // Noise suppressions, checked like a rules file's
+}
+
+// PresetNames lists the built-in presets
+func PresetNames() []string {
+	files, _ := fs.Glob(presetFS, "presets/*.yaml")
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = strings.TrimSuffix(strings.TrimPrefix(f, "presets/"), ".yaml")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPreset returns a built-in preset by name
+func LoadPreset(name string) (*Preset, error) {
+	raw, err := presetFS.ReadFile("presets/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset %q (want one of %s)", name, strings.Join(PresetNames(), ", "))
+	}
+
+	p := &Preset{Name: name}
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	if err := rules.Prepare(p.Rules); err != nil {
+		return nil, fmt.Errorf("preset %s: %w", name, err)
+	}
+	for i := range p.Rules {
+		p.Rules[i].Source = "preset " + name
+	}
+	return p, nil
+}
+
+// Values returns the flag values the preset sets, keyed by flag name
+func (p *Preset) Values() (map[string][]string, error) {
+	values, err := flagValues("preset "+p.Name, p.Settings)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"l", "preset"} {
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("preset %s: cannot set -%s", p.Name, name)
+		}
+	}
+	return values, nil
+}
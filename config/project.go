@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Project describes a whole scan in one file: the URLs to read, the rules
+// to apply and where findings go. Relative paths are resolved against the
+// project file's directory, so a project reproduces the same run from
+// anywhere.
+type Project struct {
+	Name     string         `yaml:"name"`
+	Scope    []string       `yaml:"scope"`    // In-scope domains; -scope overrides
+	Seeds    []string       `yaml:"seeds"`    // URLs checked alongside the inputs
+	Inputs   []ProjectInput `yaml:"inputs"`   // URL sources, read in order
+	Rules    ProjectRules   `yaml:"rules"`    // Rules files and overrides
+	Output   ProjectOutput  `yaml:"output"`   // Where findings are written
+	Settings map[string]any `yaml:"settings"` // Any other config file key
+
+	dir string // Directory relative paths are resolved against
+}
+
+// ProjectInput is one source of URLs
+type ProjectInput struct {
+	File string `yaml:"file"` // URL list, one per line
+}
+
+// ProjectRules selects the rules a project applies
+type ProjectRules struct {
+	Files          []string `yaml:"files"` // YAML rules files
+	Categories     []string `yaml:"categories"`
+	SkipCategories []string `yaml:"skip-categories"`
+	MinSeverity    string   `yaml:"min-severity"`
+	MinConfidence  string   `yaml:"min-confidence"`
+	Excludes       []string `yaml:"excludes"`
+	ExcludeFile    string   `yaml:"exclude-file"`
+}
+
+// ProjectOutput is a project's findings destination
+type ProjectOutput struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+}
+
+// pathFlags take file paths; a leading "+" (append mode) is kept
+var pathFlags = map[string]bool{
+	"l": true, "o": true, "scope": true, "rules": true, "exclude-file": true,
+	"keywords-file": true, "extensions-file": true, "paths-file": true, "hidden-file": true, "shares-file": true,
+	"extra-keywords": true, "extra-extensions": true, "extra-paths": true, "extra-hidden": true, "extra-shares": true,
+}
+
+// LoadProject reads a project file. Unknown keys are errors so a typo
+// cannot silently drop part of a run.
+func LoadProject(path string) (*Project, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Project{}
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, in := range p.Inputs {
+		if in.File == "" {
+			return nil, fmt.Errorf("%s: input %d has no file", path, i+1)
+		}
+	}
+	p.dir = filepath.Dir(path)
+	return p, nil
+}
+
+// Files returns the project's input files
+func (p *Project) Files() []string {
+	files := make([]string, len(p.Inputs))
+	for i, in := range p.Inputs {
+		files[i] = resolve(p.dir, in.File)
+	}
+	return files
+}
+
+// Values returns the flag values the project sets, keyed by flag name.
+// Input files are not flags; see Files.
+func (p *Project) Values() (map[string][]string, error) {
+	values, err := flagValues("settings", p.Settings)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := values["l"]; ok {
+		return nil, fmt.Errorf("settings: use inputs instead of %q", "input")
+	}
+
+	set := func(name string, v ...string) {
+		if len(v) > 0 && v[0] != "" {
+			values[name] = v
+		}
+	}
+	set("u", p.Seeds...)
+	set("rules", p.Rules.Files...)
+	set("m", strings.Join(p.Rules.Categories, ","))
+	set("M", strings.Join(p.Rules.SkipCategories, ","))
+	set("min-severity", p.Rules.MinSeverity)
+	set("min-confidence", p.Rules.MinConfidence)
+	set("e", strings.Join(p.Rules.Excludes, ","))
+	set("exclude-file", p.Rules.ExcludeFile)
+	set("o", p.Output.Path)
+	set("format", p.Output.Format)
+
+	for name, vals := range values {
+		if pathFlags[name] {
+			for i, v := range vals {
+				vals[i] = resolve(p.dir, v)
+			}
+		}
+	}
+	return values, nil
+}
+
+// resolve makes a relative path relative to dir, keeping a "+" prefix
+func resolve(dir, path string) string {
+	rest, appendMode := strings.CutPrefix(path, "+")
+	if rest == "" || filepath.IsAbs(rest) {
+		return path
+	}
+	rest = filepath.Join(dir, rest)
+	if appendMode {
+		return "+" + rest
+	}
+	return rest
+}
@@ -0,0 +1,104 @@
+package ahocorasick
+
+// Matcher finds which of a set of patterns occur in a text in a single
+// pass over it, however many patterns there are. ASCII letters match
+// either case. It is safe for concurrent use once built.
+type Matcher struct {
+	classes [256]int32 // Byte to alphabet class; 0 is every byte no pattern has
+	width   int32      // Number of classes
+	next    []int32    // Transitions by state*width+class, failures folded in
+	out     [][]int    // Patterns ending at each state, including by failure
+}
+
+// New builds a matcher for patterns. Empty patterns never match.
+func New(patterns []string) *Matcher {
+	m := &Matcher{width: 1}
+	for _, p := range patterns {
+		for i := 0; i < len(p); i++ {
+			if b := lower(p[i]); m.classes[b] == 0 {
+				m.classes[b] = m.width
+				m.width++
+			}
+		}
+	}
+	for b := 'A'; b <= 'Z'; b++ {
+		m.classes[b] = m.classes[b+'a'-'A']
+	}
+
+	// The trie, with -1 for missing transitions
+	m.addState()
+	for i, p := range patterns {
+		if p == "" {
+			continue
+		}
+		s := int32(0)
+		for j := 0; j < len(p); j++ {
+			c := m.classes[p[j]]
+			if m.next[s*m.width+c] < 0 {
+				m.next[s*m.width+c] = m.addState()
+			}
+			s = m.next[s*m.width+c]
+		}
+		m.out[s] = append(m.out[s], i)
+	}
+
+	// Breadth first, point each missing transition where the longest
+	// proper suffix of the state would go, and give each state the
+	// patterns its suffixes end
+	fail := make([]int32, len(m.out))
+	queue := []int32{0}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for c := int32(0); c < m.width; c++ {
+			u := m.next[s*m.width+c]
+			switch {
+			case u >= 0 && s == 0:
+				queue = append(queue, u)
+			case u >= 0:
+				fail[u] = m.next[fail[s]*m.width+c]
+				if inherited := m.out[fail[u]]; len(inherited) > 0 {
+					m.out[u] = append(append([]int(nil), m.out[u]...), inherited...)
+				}
+				queue = append(queue, u)
+			case s == 0:
+				m.next[c] = 0
+			default:
+				m.next[s*m.width+c] = m.next[fail[s]*m.width+c]
+			}
+		}
+	}
+	return m
+}
+
+func (m *Matcher) addState() int32 {
+	s := int32(len(m.out))
+	for c := int32(0); c < m.width; c++ {
+		m.next = append(m.next, -1)
+	}
+	m.out = append(m.out, nil)
+	return s
+}
+
+// Each calls fn with the index of each pattern occurring in text, once per
+// occurrence, in the order the occurrences end. It stops when fn returns
+// false.
+func (m *Matcher) Each(text string, fn func(pattern int) bool) {
+	s := int32(0)
+	for i := 0; i < len(text); i++ {
+		s = m.next[s*m.width+m.classes[text[i]]]
+		for _, p := range m.out[s] {
+			if !fn(p) {
+				return
+			}
+		}
+	}
+}
+
+// lower folds an ASCII upper-case letter to lower case
+func lower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
@@ -0,0 +1,39 @@
+package ahocorasick
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEach(t *testing.T) {
+	m := New([]string{"he", "she", "his", "hers", "", ".ENV", "e"})
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"ushers", []int{1, 0, 6, 3}},
+		{"HIS", []int{2}},
+		{"x/.env", []int{6, 5}},
+		{"xyz", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		m.Each(tt.text, func(p int) bool {
+			got = append(got, p)
+			return true
+		})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Each(%q) = %v; want %v", tt.text, got, tt.want)
+		}
+	}
+
+	n := 0
+	m.Each("she sells", func(int) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Each went on after fn returned false: %d calls", n)
+	}
+}
@@ -0,0 +1,146 @@
+// Package anomaly watches the rate of findings per category in long-running
+// scans and raises an alert when a category's findings spike far above their
+// usual rate, such as a sudden flood of .env hits in proxy logs.
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWindow is how long findings are counted before the rate is
+	// compared with the baseline
+	DefaultWindow = time.Minute
+	// DefaultFactor is how many times its baseline a window's findings must
+	// reach to raise an alert
+	DefaultFactor = 5.0
+	// MinCount is the fewest findings a window needs to raise an alert, so a
+	// quiet category going from one finding to six is not a spike
+	MinCount = 10
+	// Warmup is the number of windows counted before any alert, while the
+	// baselines settle
+	Warmup = 5
+	// smoothing is the weight of the latest window in a baseline, making it
+	// a moving average over about ten windows
+	smoothing = 0.1
+)
+
+// Alert is a category whose findings spiked in one window
+type Alert struct {
+	Category string
+	Count    uint64        // Findings in the window
+	Baseline float64       // Usual findings per window before it
+	Window   time.Duration // Length of the window
+}
+
+// String describes the spike for a chat message or log line
+func (a Alert) String() string {
+	return fmt.Sprintf("juicyurls: %d %s findings in the last %s, %.1fx the usual %.1f",
+		a.Count, a.Category, a.Window, float64(a.Count)/max(a.Baseline, 1), a.Baseline)
+}
+
+// Detector counts findings per category in fixed windows and keeps a moving
+// average of each as its baseline. It is safe for concurrent use, shared
+// across scans, and a nil *Detector counts nothing.
+type Detector struct {
+	window time.Duration
+	factor float64
+
+	mu     sync.Mutex
+	counts map[string]uint64 // Findings in the current window, by category
+
+	windows   int                // Windows closed so far
+	baselines map[string]float64 // Findings per window, moving average
+	alerting  map[string]bool    // Categories whose last window spiked
+}
+
+// New returns a detector comparing windows of the given length with their
+// baseline; zero values take DefaultWindow and DefaultFactor
+func New(window time.Duration, factor float64) *Detector {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if factor <= 0 {
+		factor = DefaultFactor
+	}
+	return &Detector{
+		window:    window,
+		factor:    factor,
+		counts:    make(map[string]uint64),
+		baselines: make(map[string]float64),
+		alerting:  make(map[string]bool),
+	}
+}
+
+// Finding counts a finding in category
+func (d *Detector) Finding(category string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.counts[category]++
+	d.mu.Unlock()
+}
+
+// Run closes a window every interval until ctx is done, logging each spike
+// and passing it to post. A failed post is logged and counting carries on.
+func (d *Detector) Run(ctx context.Context, logger *slog.Logger, post func(context.Context, Alert) error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, a := range d.tick() {
+				logger.Warn("findings spiked", "category", a.Category, "count", a.Count,
+					"baseline", fmt.Sprintf("%.1f", a.Baseline), "window", a.Window.String())
+				if err := post(ctx, a); err != nil && ctx.Err() == nil {
+					logger.Warn("alert failed", "category", a.Category, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// tick closes the current window and returns the categories that spiked in
+// it, by name. A category alerts once per spike, not again until a window
+// falls back under the threshold.
+func (d *Detector) tick() []Alert {
+	d.mu.Lock()
+	counts := d.counts
+	d.counts = make(map[string]uint64, len(counts))
+	d.mu.Unlock()
+
+	d.windows++
+	for category := range counts {
+		if _, ok := d.baselines[category]; !ok {
+			d.baselines[category] = 0
+		}
+	}
+	var alerts []Alert
+	for category, base := range d.baselines {
+		n := counts[category]
+		spiked := d.windows > Warmup && n >= MinCount && float64(n) > d.factor*max(base, 1)
+		if spiked && !d.alerting[category] {
+			alerts = append(alerts, Alert{Category: category, Count: n, Baseline: base, Window: d.window})
+		}
+		if spiked {
+			d.alerting[category] = true
+		} else {
+			delete(d.alerting, category)
+		}
+		d.baselines[category] = base + smoothing*(float64(n)-base)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Category < alerts[j].Category })
+	return alerts
+}
@@ -0,0 +1,90 @@
+package anomaly
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// window counts n findings in each category, then closes the window
+func window(d *Detector, counts map[string]int) []Alert {
+	for category, n := range counts {
+		for range n {
+			d.Finding(category)
+		}
+	}
+	return d.tick()
+}
+
+// TestTick alerts once when a category's findings spike past its baseline,
+// after the warmup and not for small counts
+func TestTick(t *testing.T) {
+	d := New(time.Minute, 5)
+	for i := range Warmup {
+		// Even a burst in the warmup is not compared with anything yet
+		n := 4
+		if i == 0 {
+			n = 100
+		}
+		if alerts := window(d, map[string]int{"keywords": n}); len(alerts) != 0 {
+			t.Fatalf("warmup window %d alerted: %v", i, alerts)
+		}
+	}
+	for range 20 {
+		window(d, map[string]int{"keywords": 4})
+	}
+
+	// Under the factor, and a new category under MinCount, are quiet
+	if alerts := window(d, map[string]int{"keywords": 12, "hidden": MinCount - 1}); len(alerts) != 0 {
+		t.Fatalf("alerts = %v; want none", alerts)
+	}
+
+	alerts := window(d, map[string]int{"keywords": 200, "hidden": 50})
+	if len(alerts) != 2 || alerts[0].Category != "hidden" || alerts[1].Category != "keywords" {
+		t.Fatalf("alerts = %v; want hidden and keywords", alerts)
+	}
+	if a := alerts[1]; a.Count != 200 || a.Baseline < 4 || a.Baseline > 6 || a.Window != time.Minute {
+		t.Errorf("keywords alert = %+v; want 200 findings over a baseline near 4", a)
+	}
+
+	// A spike that goes on alerts once, and again after it has settled
+	if alerts := window(d, map[string]int{"keywords": 200}); len(alerts) != 0 {
+		t.Errorf("ongoing spike alerted again: %v", alerts)
+	}
+	window(d, map[string]int{"keywords": 4})
+	if alerts := window(d, map[string]int{"keywords": 500}); len(alerts) != 1 {
+		t.Errorf("alerts = %v; want keywords to alert for a new spike", alerts)
+	}
+}
+
+// TestRun posts spikes as windows close
+func TestRun(t *testing.T) {
+	var nilDetector *Detector
+	nilDetector.Finding("keywords")
+
+	d := New(10*time.Millisecond, 5)
+	d.windows = Warmup
+	for range MinCount {
+		d.Finding("extensions")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	posted := make(chan Alert, 1)
+	go d.Run(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), func(_ context.Context, a Alert) error {
+		posted <- a
+		return nil
+	})
+	select {
+	case a := <-posted:
+		if a.Category != "extensions" || a.Count != MinCount {
+			t.Errorf("alert = %+v; want %d extensions findings", a, MinCount)
+		}
+		if want := "juicyurls: 10 extensions findings in the last 10ms, 10.0x the usual 0.0"; a.String() != want {
+			t.Errorf("message = %q; want %q", a.String(), want)
+		}
+	case <-ctx.Done():
+		t.Fatal("no alert posted")
+	}
+}
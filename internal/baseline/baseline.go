@@ -0,0 +1,71 @@
+package baseline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"juicyurls/internal/fingerprint"
+	"juicyurls/internal/types"
+)
+
+// Baseline is the set of findings an earlier scan reported, keyed by
+// fingerprint, so a new scan can report only what has appeared since
+type Baseline struct {
+	fingerprints map[string]bool
+}
+
+// Load reads the findings of an earlier scan written with -format json
+func Load(path string) (*Baseline, error) {
+	results, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{fingerprints: make(map[string]bool, len(results))}
+	for _, r := range results {
+		b.fingerprints[r.Fingerprint] = true
+	}
+	return b, nil
+}
+
+// Read returns the findings in a scan's -format json output, one object
+// per line. Findings without a fingerprint, as written by older versions,
+// are fingerprinted from their URL and rule ID.
+func Read(path string) ([]types.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []types.Result
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r types.Result
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: not a JSON finding: %w", path, n, err)
+		}
+		if r.Fingerprint == "" {
+			if r.URL == "" || r.RuleID == "" {
+				return nil, fmt.Errorf("%s:%d: finding has no fingerprint, url or rule_id", path, n)
+			}
+			r.Fingerprint = fingerprint.Compute(r.URL, r.RuleID)
+		}
+		results = append(results, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return results, nil
+}
+
+// Contains reports whether a finding with this fingerprint is in the baseline
+func (b *Baseline) Contains(fp string) bool {
+	return b.fingerprints[fp]
+}
@@ -0,0 +1,99 @@
+package baseline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/fingerprint"
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestLoad reads fingerprints from JSON output, computing missing ones
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last.json")
+	os.WriteFile(path, []byte(`{"url":"https://example.com/.env","rule_id":"hidden:.env","fingerprint":"0123456789abcdef"}
+
+{"url":"https://example.com/db.sql","rule_id":"extensions:.sql"}
+`), 0o644)
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fp, want := range map[string]bool{
+		"0123456789abcdef": true,
+		fingerprint.Compute("https://EXAMPLE.com:443/db.sql", "extensions:.sql"): true,
+		fingerprint.Compute("https://example.com/db.sql", "keywords:db"):         false,
+	} {
+		if got := b.Contains(fp); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", fp, got, want)
+		}
+	}
+
+	os.WriteFile(path, []byte("https://example.com/.env\n"), 0o644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("Load(text output) error = %v, want one naming line 1", err)
+	}
+}
+
+// TestDiff groups added and removed findings by category
+func TestDiff(t *testing.T) {
+	r := func(url, category string) types.Result {
+		return types.Result{URL: url, Category: category, Severity: "high", Reason: "r", Fingerprint: url}
+	}
+	old := []types.Result{r("https://a/old.zip", "extensions"), r("https://a/.git", "hidden")}
+	cur := []types.Result{r("https://a/.git", "hidden"), r("https://a/db.sql", "extensions"),
+		r("https://a/db.sql", "extensions"), r("https://a/admin", "paths")}
+
+	var out bytes.Buffer
+	if err := Diff(old, cur).Write(&out, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	want := `extensions: 1 added, 1 removed
+  + https://a/db.sql [high] r
+  - https://a/old.zip [high] r
+paths: 1 added, 0 removed
+  + https://a/admin [high] r
+Total: 2 added, 1 removed
+`
+	if out.String() != want {
+		t.Errorf("Write(text) =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	Diff(old, cur).Write(&out, FormatJSON)
+	if got := strings.Count(out.String(), `"change":"added"`); got != 2 {
+		t.Errorf("json has %d added findings, want 2:\n%s", got, out.String())
+	}
+}
+
+// TestMerge keeps each finding once and records the runs reporting it
+func TestMerge(t *testing.T) {
+	r := func(fp string, runs ...string) types.Result {
+		return types.Result{URL: "https://a/" + fp, Fingerprint: fp, Runs: runs}
+	}
+	merged := Merge([]Run{
+		{Name: "a.json", Results: []types.Result{r("1"), r("2"), r("1")}},
+		{Name: "b.json", Results: []types.Result{r("2"), r("3")}},
+		{Name: "ab.json", Results: []types.Result{r("3", "c.json"), r("4", "a.json", "d.json")}},
+	})
+
+	want := map[string][]string{
+		"1": {"a.json"},
+		"2": {"a.json", "b.json"},
+		"3": {"b.json", "c.json"},
+		"4": {"a.json", "d.json"},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("merged %d findings, want %d: %+v", len(merged), len(want), merged)
+	}
+	for i, m := range merged {
+		if m.Fingerprint != strconv.Itoa(i+1) || !slices.Equal(m.Runs, want[m.Fingerprint]) {
+			t.Errorf("merged[%d] = %s from %v, want %d from %v", i, m.Fingerprint, m.Runs, i+1, want[m.Fingerprint])
+		}
+	}
+}
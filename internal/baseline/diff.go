@@ -0,0 +1,142 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"juicyurls/internal/types"
+)
+
+// Diff formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Changes are the findings that differ between two scans, matched by
+// fingerprint
+type Changes struct {
+	Added   []types.Result // In the new scan only
+	Removed []types.Result // In the old scan only
+}
+
+// Diff compares the findings of an old and a new scan. A finding reported
+// more than once in a scan counts once. Each side is sorted by category,
+// then URL.
+func Diff(old, cur []types.Result) Changes {
+	return Changes{Added: subtract(cur, old), Removed: subtract(old, cur)}
+}
+
+// subtract returns the findings of a whose fingerprint is not in b
+func subtract(a, b []types.Result) []types.Result {
+	skip := make(map[string]bool, len(b))
+	for _, r := range b {
+		skip[r.Fingerprint] = true
+	}
+	var out []types.Result
+	for _, r := range a {
+		if !skip[r.Fingerprint] {
+			skip[r.Fingerprint] = true
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+// group is one category's share of the changes
+type group struct {
+	category       string
+	added, removed []types.Result
+}
+
+func (c Changes) groups() []group {
+	byCategory := make(map[string]*group)
+	var groups []*group
+	get := func(category string) *group {
+		g := byCategory[category]
+		if g == nil {
+			g = &group{category: category}
+			byCategory[category] = g
+			groups = append(groups, g)
+		}
+		return g
+	}
+	for _, r := range c.Added {
+		g := get(r.Category)
+		g.added = append(g.added, r)
+	}
+	for _, r := range c.Removed {
+		g := get(r.Category)
+		g.removed = append(g.removed, r)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].category < groups[j].category })
+
+	out := make([]group, len(groups))
+	for i, g := range groups {
+		out[i] = *g
+	}
+	return out
+}
+
+// Write prints the changes grouped by category in a format: text,
+// markdown for changelogs, or json with one finding per line and its
+// "change", added or removed
+func (c Changes) Write(out io.Writer, format string) error {
+	var b strings.Builder
+	switch format {
+	case FormatText, "":
+		for _, g := range c.groups() {
+			fmt.Fprintf(&b, "%s: %s\n", g.category, counts(len(g.added), len(g.removed)))
+			for _, r := range g.added {
+				fmt.Fprintf(&b, "  + %s [%s] %s\n", r.URL, r.Severity, r.Reason)
+			}
+			for _, r := range g.removed {
+				fmt.Fprintf(&b, "  - %s [%s] %s\n", r.URL, r.Severity, r.Reason)
+			}
+		}
+		fmt.Fprintf(&b, "Total: %s\n", counts(len(c.Added), len(c.Removed)))
+	case FormatMarkdown:
+		fmt.Fprintf(&b, "## Changes: %s\n", counts(len(c.Added), len(c.Removed)))
+		for _, g := range c.groups() {
+			fmt.Fprintf(&b, "\n### %s (%s)\n\n", g.category, counts(len(g.added), len(g.removed)))
+			for _, r := range g.added {
+				fmt.Fprintf(&b, "- Added `%s`: %s (%s)\n", r.URL, r.Reason, r.Severity)
+			}
+			for _, r := range g.removed {
+				fmt.Fprintf(&b, "- Removed `%s`: %s (%s)\n", r.URL, r.Reason, r.Severity)
+			}
+		}
+	case FormatJSON:
+		enc := json.NewEncoder(&b)
+		for _, change := range []struct {
+			name    string
+			results []types.Result
+		}{{"added", c.Added}, {"removed", c.Removed}} {
+			for _, r := range change.results {
+				enc.Encode(struct {
+					Change string `json:"change"`
+					types.Result
+				}{change.name, r})
+			}
+		}
+	default:
+		return fmt.Errorf("unknown diff format %q (want text, markdown or json)", format)
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// counts describes how many findings were added and removed
+func counts(added, removed int) string {
+	return fmt.Sprintf("%d added, %d removed", added, removed)
+}
@@ -0,0 +1,48 @@
+package baseline
+
+import "juicyurls/internal/types"
+
+// Run is the findings of one scan, named for provenance
+type Run struct {
+	Name    string
+	Results []types.Result
+}
+
+// Merge unions the findings of several runs, keeping each fingerprint
+// once in the order first seen. A finding's Runs lists the runs that
+// reported it; findings already merged keep the runs they list, so merged
+// files can be merged again.
+func Merge(runs []Run) []types.Result {
+	var merged []types.Result
+	at := make(map[string]int) // Index in merged by fingerprint
+	for _, run := range runs {
+		for _, r := range run.Results {
+			from := r.Runs
+			if len(from) == 0 {
+				from = []string{run.Name}
+			}
+			i, ok := at[r.Fingerprint]
+			if !ok {
+				i = len(merged)
+				at[r.Fingerprint] = i
+				r.Runs = nil
+				merged = append(merged, r)
+			}
+			for _, name := range from {
+				if !contains(merged[i].Runs, name) {
+					merged[i].Runs = append(merged[i].Runs, name)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
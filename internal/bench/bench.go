@@ -0,0 +1,222 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"juicyurls/internal/corpus"
+	"juicyurls/pkg/juicyurls"
+)
+
+// Options controls a benchmark run
+type Options struct {
+	Corpus corpus.Options // Synthetic input, generated once and scanned every round
+	Rounds int            // Timed scans; the median is reported
+
+	// Only returns a scanner checking one category, configured as the
+	// benchmarked one otherwise; nil leaves out the per-category costs
+	Only func(category string) *juicyurls.Scanner
+}
+
+// Report is the outcome of a run, saved as JSON for later comparison
+type Report struct {
+	Time       time.Time `json:"time"`
+	GoVersion  string    `json:"go_version"`
+	CPUs       int       `json:"cpus"`
+	RulesHash  string    `json:"rules_hash"`
+	URLs       int       `json:"urls"`
+	Seed       int64     `json:"seed"`
+	Findings   int       `json:"findings"`
+	Rounds     int       `json:"rounds"`
+	URLsPerSec float64   `json:"urls_per_sec"` // Median over the rounds
+
+	AllocsPerURL float64        `json:"allocs_per_url"` // Heap allocations per URL scanned, over all rounds
+	BytesPerURL  float64        `json:"bytes_per_url"`  // Heap bytes allocated per URL scanned
+	Categories   []CategoryCost `json:"categories,omitempty"`
+}
+
+// CategoryCost is what checking one category alone costs, measured by
+// checking every URL of the corpus in turn on one goroutine
+type CategoryCost struct {
+	Name     string  `json:"name"`
+	NsPerURL float64 `json:"ns_per_url"`
+	Findings int     `json:"findings"`
+}
+
+// Run scans a synthetic corpus opts.Rounds times with s
+func Run(ctx context.Context, s *juicyurls.Scanner, opts Options) (Report, error) {
+	if opts.Rounds < 1 {
+		opts.Rounds = 1
+	}
+	var input bytes.Buffer
+	if _, err := corpus.Generate(&input, opts.Corpus); err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Time:      time.Now().UTC(),
+		GoVersion: runtime.Version(),
+		CPUs:      runtime.NumCPU(),
+		RulesHash: s.RulesHash(),
+		URLs:      opts.Corpus.Count,
+		Seed:      opts.Corpus.Seed,
+		Rounds:    opts.Rounds,
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	rates := make([]float64, opts.Rounds)
+	for i := range rates {
+		start := time.Now()
+		found, err := s.Scan(ctx, bytes.NewReader(input.Bytes()))
+		if err != nil {
+			return Report{}, err
+		}
+		report.Findings = 0
+		for range found {
+			report.Findings++
+		}
+		if err := ctx.Err(); err != nil {
+			return Report{}, err
+		}
+		rates[i] = float64(report.URLs) / time.Since(start).Seconds()
+	}
+	runtime.ReadMemStats(&after)
+	scanned := float64(report.URLs * opts.Rounds)
+	report.AllocsPerURL = float64(after.Mallocs-before.Mallocs) / scanned
+	report.BytesPerURL = float64(after.TotalAlloc-before.TotalAlloc) / scanned
+	slices.Sort(rates)
+	report.URLsPerSec = rates[len(rates)/2]
+
+	if opts.Only != nil {
+		urls := strings.Split(strings.TrimSuffix(input.String(), "\n"), "\n")
+		for _, name := range s.Categories() {
+			if err := ctx.Err(); err != nil {
+				return Report{}, err
+			}
+			report.Categories = append(report.Categories, measure(opts.Only(name), name, urls, opts.Rounds))
+		}
+	}
+	return report, nil
+}
+
+// measure times checking urls with a scanner of one category, rounds
+// times, and reports the median
+func measure(s *juicyurls.Scanner, name string, urls []string, rounds int) CategoryCost {
+	cost := CategoryCost{Name: name}
+	times := make([]float64, rounds)
+	for i := range times {
+		cost.Findings = 0
+		start := time.Now()
+		for _, u := range urls {
+			if _, ok := s.Check(u); ok {
+				cost.Findings++
+			}
+		}
+		times[i] = float64(time.Since(start).Nanoseconds()) / float64(len(urls))
+	}
+	slices.Sort(times)
+	cost.NsPerURL = times[len(times)/2]
+	return cost
+}
+
+// Save writes a report to path
+func Save(path string, r Report) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Load reads a report written by Save
+func Load(path string) (Report, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Report{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.URLsPerSec <= 0 {
+		return Report{}, fmt.Errorf("%s: no throughput recorded", path)
+	}
+	return r, nil
+}
+
+// Change is the throughput difference from base to cur in percent;
+// positive is faster
+func Change(base, cur Report) float64 {
+	return (cur.URLsPerSec - base.URLsPerSec) / base.URLsPerSec * 100
+}
+
+// Write prints a report, and its comparison with base when base is set.
+// Differences that make the comparison unfair are called out.
+func Write(out io.Writer, cur Report, base *Report) {
+	fmt.Fprintf(out, "Scanned %d URLs x %d rounds: %.0f URLs/s, %d findings (rules %s, %s, %d CPUs)\n",
+		cur.URLs, cur.Rounds, cur.URLsPerSec, cur.Findings, cur.RulesHash, cur.GoVersion, cur.CPUs)
+	fmt.Fprintf(out, "Allocations: %.1f per URL, %.0f bytes per URL\n", cur.AllocsPerURL, cur.BytesPerURL)
+	if base != nil {
+		compare(out, cur, *base)
+	}
+	if len(cur.Categories) > 0 {
+		writeCategories(out, cur, base)
+	}
+}
+
+// compare prints how cur differs from base
+func compare(out io.Writer, cur, base Report) {
+	fmt.Fprintf(out, "Baseline %s: %.0f URLs/s (rules %s, %s, %d CPUs)\n",
+		base.Time.Format(time.DateOnly), base.URLsPerSec, base.RulesHash, base.GoVersion, base.CPUs)
+	change := Change(base, cur)
+	verdict := "improvement"
+	if change < 0 {
+		verdict = "regression"
+	}
+	fmt.Fprintf(out, "Change: %+.1f%% (%s)\n", change, verdict)
+	if base.AllocsPerURL > 0 {
+		fmt.Fprintf(out, "Allocations: %.1f per URL in the baseline (%+.1f)\n", base.AllocsPerURL, math.Round((cur.AllocsPerURL-base.AllocsPerURL)*10)/10+0)
+	}
+
+	if base.URLs != cur.URLs || base.Seed != cur.Seed {
+		fmt.Fprintf(out, "Note: corpus differs from the baseline (%d URLs, seed %d)\n", base.URLs, base.Seed)
+	}
+	if base.RulesHash != cur.RulesHash {
+		fmt.Fprintln(out, "Note: rule set differs from the baseline")
+	}
+	if base.CPUs != cur.CPUs {
+		fmt.Fprintln(out, "Note: CPU count differs from the baseline")
+	}
+}
+
+// writeCategories prints what each category costs, and how that changed
+// from base for categories it measured too
+func writeCategories(out io.Writer, cur Report, base *Report) {
+	before := map[string]float64{}
+	if base != nil {
+		for _, c := range base.Categories {
+			before[c.Name] = c.NsPerURL
+		}
+	}
+	width := len("category")
+	for _, c := range cur.Categories {
+		width = max(width, len(c.Name))
+	}
+	fmt.Fprintf(out, "%-*s  %10s  %8s\n", width, "category", "ns/URL", "findings")
+	for _, c := range cur.Categories {
+		fmt.Fprintf(out, "%-*s  %10.0f  %8d", width, c.Name, c.NsPerURL, c.Findings)
+		if ns, ok := before[c.Name]; ok && ns > 0 {
+			fmt.Fprintf(out, "  %+.1f%%", (c.NsPerURL-ns)/ns*100)
+		}
+		fmt.Fprintln(out)
+	}
+}
@@ -0,0 +1,74 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/corpus"
+	"github.com/alwalxed/juicyurls/v2/pkg/juicyurls"
+)
+
+// TestRunCompare saves a run and compares a second one against it
+func TestRunCompare(t *testing.T) {
+	s, err := juicyurls.New(juicyurls.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Corpus: corpus.Options{Count: 2000, SuspiciousRatio: 0.1, Seed: 7}, Rounds: 2}
+	opts.Only = func(category string) *juicyurls.Scanner {
+		only, err := juicyurls.New(juicyurls.Options{Categories: []string{category}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return only
+	}
+	first, err := Run(context.Background(), s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.URLs != 2000 || first.Findings == 0 || first.URLsPerSec <= 0 || first.RulesHash != s.RulesHash() || first.AllocsPerURL <= 0 {
+		t.Fatalf("report = %+v", first)
+	}
+	if len(first.Categories) != len(s.Categories()) {
+		t.Fatalf("measured %d categories; want %d", len(first.Categories), len(s.Categories()))
+	}
+	found := 0
+	for _, c := range first.Categories {
+		if c.NsPerURL <= 0 {
+			t.Errorf("%s costs %.0f ns/URL", c.Name, c.NsPerURL)
+		}
+		found += c.Findings
+	}
+	if found < first.Findings {
+		t.Errorf("categories found %d alone; want at least the %d found together", found, first.Findings)
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := Save(path, first); err != nil {
+		t.Fatal(err)
+	}
+	base, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.URLsPerSec != first.URLsPerSec || !base.Time.Equal(first.Time) {
+		t.Errorf("loaded %+v; want %+v", base, first)
+	}
+
+	cur := first
+	cur.URLsPerSec = base.URLsPerSec * 0.8
+	cur.RulesHash = "other"
+	if got := Change(base, cur); got > -19.9 || got < -20.1 {
+		t.Errorf("Change = %.2f; want -20", got)
+	}
+	var out bytes.Buffer
+	Write(&out, cur, &base)
+	for _, want := range []string{"Change: -20.0% (regression)", "rule set differs", "Allocations:", first.Categories[0].Name} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
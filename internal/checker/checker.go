@@ -1,46 +1,139 @@
 package checker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"unicode/utf8"
+	"unsafe"
 
+	"github.com/alwalxed/juicyurls/v2/internal/rules"
+	"github.com/alwalxed/juicyurls/v2/internal/urlnorm"
 	"github.com/alwalxed/juicyurls/v2/suspicious"
 )
 
 // URLChecker handles URL validation and suspicious pattern detection
 type URLChecker struct {
-	checkKeywords    bool
-	checkExtensions  bool
-	checkPaths       bool
-	checkHidden      bool
-	excludePatterns  []string
-	excludeRegexes   []*regexp.Regexp
-	keywordRegexes   []*regexp.Regexp
-	extensionRegexes []*regexp.Regexp
-	pathRegexes      []*regexp.Regexp
-	hiddenRegexes    []*regexp.Regexp
-	compiledOnce     sync.Once
-}
-
-// NewURLChecker creates and initializes a new URLChecker
-func NewURLChecker(categories, excludes string) *URLChecker {
-	uc := &URLChecker{}
+	checkKeywords   bool
+	checkExtensions bool
+	checkPaths      bool
+	checkHidden     bool
+	checkShares     bool
+	checkObfuscated bool
+	checkHomograph  bool
+	excludePatterns []string
+	excludeRegexes  []*regexp.Regexp
+	extraRules      []rules.Rule
+	customSelected  map[string]bool   // User categories chosen with -m; nil selects all
+	matchers        []Matcher         // Categories in match order, then registered matchers
+	overrides       []*rules.Compiled // Suppress and downgrade rules
+	minSeverity     int               // Findings ranked below this are dropped
+	minConfidence   int               // Findings less confident than this are dropped
+	rulesHash       string            // Identifies the compiled rule set
+	compiledOnce    sync.Once
+}
 
-	// Parse exclude patterns
-	if excludes != "" {
-		uc.excludePatterns = strings.Split(excludes, ",")
-		for i, pattern := range uc.excludePatterns {
-			uc.excludePatterns[i] = strings.TrimSpace(pattern)
+// Finding describes why a URL was flagged
+type Finding struct {
+	Category   string
+	Reason     string
+	Severity   string
+	Confidence string
+	RuleID     string
+	RuleSource string         // rules.SourceBuiltin, a rules file, a preset or a plugin
+	Weight     int            // Contribution to the score in scoring mode
+	Match      rules.Location // Where the rule matched
+}
+
+// ParsedURL is a URL prepared for matching, with accessors for its
+// components
+type ParsedURL = rules.Target
+
+// Matcher finds suspicious patterns in a URL. Every category, built-in or
+// from a rules file, is a Matcher; Register adds others. Match returns
+// findings in priority order: Check reports the most severe one that
+// survives suppress rules and thresholds, Score sums all of them. The target is
+// reused once Match returns, so a Matcher must not keep it.
+type Matcher interface {
+	Match(u *ParsedURL) []Finding
+}
+
+// category holds the compiled rules of one category in match order
+type category struct {
+	name      string
+	reason    string
+	rules     []*rules.Compiled
+	prefilter *rules.Prefilter // Rules out literal rules; nil when there are none
+}
+
+// Match returns a finding for every rule of the category that matches
+func (cat *category) Match(t *ParsedURL) []Finding {
+	var out []Finding
+	candidates := cat.prefilter.Candidates(t)
+	for i, rule := range cat.rules {
+		if (candidates == nil || candidates[i]) && rule.Match(t) {
+			out = append(out, newFinding(cat, rule, t))
+		}
+	}
+	return out
+}
+
+// best replaces *best with each matching rule's finding that c accepts and
+// that outranks it, and reports whether *best holds a finding. Rules that
+// could not outrank it are not evaluated: overrides only lower a finding.
+func (cat *category) best(c *URLChecker, t *ParsedURL, best *Finding, found bool) bool {
+	candidates := cat.prefilter.Candidates(t)
+	for i, rule := range cat.rules {
+		if candidates != nil && !candidates[i] || found && !outranks(rule.Rule.Severity, rule.Rule.Confidence, *best) {
+			continue
+		}
+		if rule.Match(t) {
+			f := newFinding(cat, rule, t)
+			ok, more := c.accept(t, &f)
+			if ok && (!found || outranks(f.Severity, f.Confidence, *best)) {
+				*best, found = f, true
+			}
+			if !more {
+				break
+			}
 		}
 	}
+	return found
+}
+
+// builtinReasons are the default reasons reported for built-in categories
+var builtinReasons = map[string]string{
+	"keywords":    "Contains suspicious keyword",
+	"extensions":  "Suspicious file extension",
+	"paths":       "Suspicious path pattern",
+	"hidden":      "Hidden file or directory",
+	"shares":      "Exposed file share or transfer service",
+	"obfuscation": "Obfuscated encoding",
+	"homograph":   "Internationalized host name lookalike",
+}
+
+// NewURLChecker creates and initializes a new URLChecker. Extra rules from
+// rules files are checked alongside the built-in lists of their category.
+func NewURLChecker(categories, excludes string, extra ...rules.Rule) *URLChecker {
+	uc := &URLChecker{extraRules: extra}
+
+	// Parse exclude patterns
+	uc.excludePatterns = splitExcludes(excludes)
 
 	// Parse categories if specified, otherwise enable all
 	if categories != "" {
+		uc.customSelected = make(map[string]bool)
 		cats := strings.Split(categories, ",")
 		for _, category := range cats {
-			switch strings.TrimSpace(strings.ToLower(category)) {
+			name := strings.TrimSpace(strings.ToLower(category))
+			switch name {
 			case "keywords":
 				uc.checkKeywords = true
 			case "extensions":
@@ -49,6 +142,14 @@ func NewURLChecker(categories, excludes string) *URLChecker {
 				uc.checkPaths = true
 			case "hidden":
 				uc.checkHidden = true
+			case "shares":
+				uc.checkShares = true
+			case "obfuscation":
+				uc.checkObfuscated = true
+			case "homograph":
+				uc.checkHomograph = true
+			default:
+				uc.customSelected[name] = true
 			}
 		}
 	} else {
@@ -56,115 +157,558 @@ func NewURLChecker(categories, excludes string) *URLChecker {
 		uc.checkExtensions = true
 		uc.checkPaths = true
 		uc.checkHidden = true
+		uc.checkShares = true
+		uc.checkObfuscated = true
+		uc.checkHomograph = true
 	}
 
-	uc.compileRegexes() // Compile regexes upon creation
+	uc.compileRules() // Compile rules upon creation
 
 	return uc
 }
 
-// compileRegexes compiles all regex patterns once for better performance
-func (c *URLChecker) compileRegexes() {
+// ResolveCategories checks the -m selection (all categories when empty)
+// and the -M exclusions against the built-in and user categories, and
+// returns the selection less the exclusions as a -m style list. Errors
+// start with the flag at fault.
+func ResolveCategories(include, exclude string, extra []rules.Rule) (string, error) {
+	known := rules.CategoryNames(extra)
+	selected := known
+	if strings.TrimSpace(include) != "" {
+		selected = splitCategories(include)
+		if err := knownCategories("-m", selected, known); err != nil {
+			return "", err
+		}
+	}
+	if strings.TrimSpace(exclude) == "" {
+		return include, nil
+	}
+
+	skipped := splitCategories(exclude)
+	if err := knownCategories("-M", skipped, known); err != nil {
+		return "", err
+	}
+	skip := make(map[string]bool)
+	for _, name := range skipped {
+		skip[name] = true
+	}
+
+	var out []string
+	for _, name := range selected {
+		if !skip[name] {
+			out = append(out, name)
+		}
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("-M: no categories left to check")
+	}
+	return strings.Join(out, ","), nil
+}
+
+// knownCategories checks the categories given to a flag
+func knownCategories(flag string, names, known []string) error {
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("%s: unknown category %q (want one of %s)", flag, name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// AddExcludes adds exclude patterns as given, without splitting on commas,
+// such as the lines of an exclude file
+func (c *URLChecker) AddExcludes(patterns ...string) error {
+	for _, pattern := range patterns {
+		regex, err := compileExclude(pattern)
+		if err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+		c.excludePatterns = append(c.excludePatterns, pattern)
+		c.excludeRegexes = append(c.excludeRegexes, regex)
+	}
+	return nil
+}
+
+// RegexPrefix marks an exclude pattern as a regular expression
+const RegexPrefix = "re:"
+
+// compileExclude matches a pattern as a case-insensitive literal, or as a
+// regular expression when it starts with RegexPrefix
+func compileExclude(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, RegexPrefix); ok {
+		return regexp.Compile("(?i)" + expr)
+	}
+	return regexp.Compile("(?i)" + regexp.QuoteMeta(pattern))
+}
+
+// CheckExcludes returns an error for the first exclude pattern that does
+// not compile
+func CheckExcludes(excludes string) error {
+	for _, pattern := range splitExcludes(excludes) {
+		if _, err := compileExclude(pattern); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// splitExcludes splits a comma-separated exclude list. Commas inside a
+// regex's brackets, braces or parentheses, or escaped with a backslash,
+// stay part of the regex, so "re:a{1,3}" is one pattern.
+func splitExcludes(s string) []string {
+	var out []string
+	add := func(p string) {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	start, depth := 0, 0
+	regex := strings.HasPrefix(strings.TrimSpace(s), RegexPrefix)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && regex:
+			i++
+		case (c == '[' || c == '{' || c == '(') && regex:
+			depth++
+		case (c == ']' || c == '}' || c == ')') && regex && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			add(s[start:i])
+			start = i + 1
+			regex = strings.HasPrefix(strings.TrimSpace(s[start:]), RegexPrefix)
+		}
+	}
+	add(s[start:])
+	return out
+}
+
+func splitCategories(s string) []string {
+	var out []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(strings.ToLower(name)); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// compileRules compiles all patterns once for better performance
+func (c *URLChecker) compileRules() {
 	c.compiledOnce.Do(func() {
 		// Compile exclude patterns
 		for _, pattern := range c.excludePatterns {
-			if regex, err := regexp.Compile("(?i)" + regexp.QuoteMeta(pattern)); err == nil {
+			if regex, err := compileExclude(pattern); err == nil {
 				c.excludeRegexes = append(c.excludeRegexes, regex)
 			}
 		}
 
-		// Compile suspicious patterns
-		if c.checkKeywords {
-			for _, keyword := range suspicious.Keywords {
-				if regex, err := regexp.Compile("(?i)" + regexp.QuoteMeta(keyword)); err == nil {
-					c.keywordRegexes = append(c.keywordRegexes, regex)
-				}
+		// Built-in categories come first, in their fixed order; user
+		// categories follow in the order they first appear
+		byName := make(map[string]*category)
+		for _, name := range rules.Categories {
+			if c.enabled(name) {
+				cat := &category{name: name, reason: builtinReasons[name]}
+				byName[name] = cat
+				c.matchers = append(c.matchers, cat)
 			}
 		}
 
-		if c.checkExtensions {
-			for _, ext := range suspicious.Extensions {
-				pattern := regexp.QuoteMeta(ext) + "$"
-				if regex, err := regexp.Compile("(?i)" + pattern); err == nil {
-					c.extensionRegexes = append(c.extensionRegexes, regex)
-				}
+		all := append(rules.Builtin(), c.extraRules...)
+		for i := range all {
+			rule := &all[i]
+			if rule.IsDetect() && !c.enabled(rule.Category) {
+				continue
 			}
-		}
-
-		if c.checkPaths {
-			for _, path := range suspicious.Paths {
-				if regex, err := regexp.Compile("(?i)" + regexp.QuoteMeta(path)); err == nil {
-					c.pathRegexes = append(c.pathRegexes, regex)
-				}
+			compiled, err := rule.Compile()
+			if err != nil {
+				continue
 			}
-		}
-
-		if c.checkHidden {
-			for _, hidden := range suspicious.Hidden {
-				if regex, err := regexp.Compile("(?i)" + regexp.QuoteMeta(hidden)); err == nil {
-					c.hiddenRegexes = append(c.hiddenRegexes, regex)
+			if !rule.IsDetect() {
+				c.overrides = append(c.overrides, compiled)
+				continue
+			}
+			if rule.Severity == "" {
+				rule.Severity = suspicious.DefaultSeverity(rule.Category)
+			}
+			if rule.Confidence == "" {
+				rule.Confidence = suspicious.DefaultConfidence(rule.Category)
+			}
+			if rule.Weight == 0 {
+				rule.Weight = suspicious.SeverityWeight(rule.Severity)
+			}
+			cat, ok := byName[rule.Category]
+			if !ok {
+				cat = &category{name: rule.Category, reason: rule.Reason}
+				if cat.reason == "" {
+					cat.reason = "Matches " + rule.Category + " pattern"
 				}
+				byName[rule.Category] = cat
+				c.matchers = append(c.matchers, cat)
 			}
+			cat.rules = append(cat.rules, compiled)
+		}
+		for _, cat := range byName {
+			cat.prefilter = rules.NewPrefilter(cat.rules)
 		}
+		c.rulesHash = hashRules(c.matchers, c.overrides)
 	})
 }
 
+// hashRules hashes the definitions of the compiled rules in match order,
+// leaving out where they were loaded from
+func hashRules(matchers []Matcher, overrides []*rules.Compiled) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	add := func(rule *rules.Compiled) {
+		r := *rule.Rule
+		r.Source = ""
+		enc.Encode(r)
+	}
+	for _, m := range matchers {
+		for _, rule := range m.(*category).rules {
+			add(rule)
+		}
+	}
+	for _, rule := range overrides {
+		add(rule)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// RulesHash identifies the active rule set. Runs with the same hash apply
+// the same rules in the same order, wherever they were loaded from;
+// registered matchers are not covered.
+func (c *URLChecker) RulesHash() string {
+	return c.rulesHash
+}
+
+// Register adds a matcher after the categories, so its findings are
+// reported when no category matches with a higher severity. Registered matchers run
+// whatever categories are selected, and suppress and downgrade rules apply
+// to their findings by category and rule ID. Findings without a severity,
+// confidence or weight get the defaults of their category. Register must
+// be called before the checker is shared between goroutines.
+func (c *URLChecker) Register(m Matcher) {
+	c.matchers = append(c.matchers, m)
+}
+
+// SetMinSeverity drops findings below level, so Check reports the most
+// severe match at or above it. It must be called before
+// the checker is shared between goroutines.
+func (c *URLChecker) SetMinSeverity(level string) error {
+	if level == "" {
+		c.minSeverity = 0
+		return nil
+	}
+	rank := suspicious.SeverityRank(level)
+	if rank < 0 {
+		return fmt.Errorf("unknown severity %q (want one of %s)", level, strings.Join(suspicious.Severities, ", "))
+	}
+	c.minSeverity = rank
+	return nil
+}
+
+// SetMinConfidence drops findings less confident than level like
+// SetMinSeverity drops less severe ones. It must be called before the checker is shared between goroutines.
+func (c *URLChecker) SetMinConfidence(level string) error {
+	if level == "" {
+		c.minConfidence = 0
+		return nil
+	}
+	rank := suspicious.ConfidenceRank(level)
+	if rank < 0 {
+		return fmt.Errorf("unknown confidence %q (want one of %s)", level, strings.Join(suspicious.Confidences, ", "))
+	}
+	c.minConfidence = rank
+	return nil
+}
+
+// enabled reports whether a category was selected
+func (c *URLChecker) enabled(name string) bool {
+	switch name {
+	case "keywords":
+		return c.checkKeywords
+	case "extensions":
+		return c.checkExtensions
+	case "paths":
+		return c.checkPaths
+	case "hidden":
+		return c.checkHidden
+	case "shares":
+		return c.checkShares
+	case "obfuscation":
+		return c.checkObfuscated
+	case "homograph":
+		return c.checkHomograph
+	}
+	return c.customEnabled(name)
+}
+
+// customEnabled reports whether a user category was selected
+func (c *URLChecker) customEnabled(name string) bool {
+	return c.customSelected == nil || c.customSelected[name]
+}
+
 // IsSuspicious checks if a URL matches suspicious patterns
 func (c *URLChecker) IsSuspicious(rawURL string) (bool, string, string) {
-	if rawURL == "" {
-		return false, "", ""
+	f, ok := c.Check(rawURL)
+	return ok, f.Category, f.Reason
+}
+
+// Check returns the most severe finding for a URL across the categories
+// and registered matchers, the most confident one on equal severity and
+// the first one in match order on ties, like the finding Score reports. Matches below the thresholds or suppressed are
+// passed over.
+func (c *URLChecker) Check(rawURL string) (Finding, bool) {
+	if rawURL == "" || c.excluded(rawURL) {
+		return Finding{}, false
 	}
 
-	// Check exclude patterns first
-	for _, regex := range c.excludeRegexes {
-		if regex.MatchString(rawURL) {
-			return false, "", ""
-		}
+	// Check suspicious patterns, matcher by matcher
+	t := getTarget(rawURL)
+	defer putTarget(t)
+	var best Finding
+	found := false
+	for _, m := range c.matchers {
+		found = c.best(m, t, &best, found)
 	}
+	return best, found
+}
 
-	// Check suspicious patterns
-	for _, regex := range c.keywordRegexes {
-		if regex.MatchString(rawURL) {
-			return true, "keywords", "Contains suspicious keyword"
+// Score evaluates every rule against a URL and sums the weights of all
+// surviving matches. The returned finding is the most severe match, the
+// most confident one on equal severity and the first one on ties.
+func (c *URLChecker) Score(rawURL string) (Finding, int, bool) {
+	var best Finding
+	score := 0
+	found := c.Matches(rawURL)
+	for i, f := range found {
+		score += f.Weight
+		if i == 0 || outranks(f.Severity, f.Confidence, best) {
+			best = f
 		}
 	}
+	return best, score, len(found) > 0
+}
 
-	for _, regex := range c.extensionRegexes {
-		if regex.MatchString(rawURL) {
-			return true, "extensions", "Suspicious file extension"
+// Matches evaluates every rule against a URL and returns all surviving
+// matches in rule order
+func (c *URLChecker) Matches(rawURL string) []Finding {
+	if rawURL == "" || c.excluded(rawURL) {
+		return nil
+	}
+
+	var out []Finding
+	t := getTarget(rawURL)
+	defer putTarget(t)
+	for _, m := range c.matchers {
+		for _, f := range m.Match(t) {
+			f = withDefaults(f)
+			ok, more := c.accept(t, &f)
+			if ok {
+				out = append(out, f)
+			}
+			if !more {
+				break
+			}
 		}
 	}
+	return out
+}
+
+// CheckBytes is Check for a URL held in a buffer the caller may reuse
+// once it returns. The URL is matched in place, without copying it.
+func (c *URLChecker) CheckBytes(raw []byte) (Finding, bool) {
+	f, ok := c.Check(unsafe.String(unsafe.SliceData(raw), len(raw)))
+	if ok && aliases(f.Reason, raw) {
+		f.Reason = strings.Clone(f.Reason) // A registered matcher quoted the URL
+	}
+	return f, ok
+}
+
+// aliases reports whether s points into b
+func aliases(s string, b []byte) bool {
+	if len(s) == 0 || len(b) == 0 {
+		return false
+	}
+	p, start := uintptr(unsafe.Pointer(unsafe.StringData(s))), uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+	return p >= start && p < start+uintptr(len(b))
+}
+
+// targets holds Targets for reuse, so matching a URL does not allocate one
+var targets = sync.Pool{New: func() any { return new(rules.Target) }}
+
+func getTarget(rawURL string) *rules.Target {
+	t := targets.Get().(*rules.Target)
+	t.Reset(rawURL)
+	return t
+}
+
+func putTarget(t *rules.Target) {
+	t.Reset("") // Drop the URL, which may be a caller's buffer
+	targets.Put(t)
+}
 
-	for _, regex := range c.pathRegexes {
+// excluded reports whether a URL matches an exclude pattern
+func (c *URLChecker) excluded(rawURL string) bool {
+	for _, regex := range c.excludeRegexes {
 		if regex.MatchString(rawURL) {
-			return true, "paths", "Suspicious path pattern"
+			return true
 		}
 	}
+	return false
+}
 
-	for _, regex := range c.hiddenRegexes {
-		if regex.MatchString(rawURL) {
-			return true, "hidden", "Hidden file or directory"
+// best replaces *best with each finding of a matcher that accept takes and
+// that outranks it, and reports whether *best holds a finding
+func (c *URLChecker) best(m Matcher, t *ParsedURL, best *Finding, found bool) bool {
+	if cat, ok := m.(*category); ok {
+		return cat.best(c, t, best, found)
+	}
+	for _, f := range m.Match(t) {
+		f = withDefaults(f)
+		ok, more := c.accept(t, &f)
+		if ok && (!found || outranks(f.Severity, f.Confidence, *best)) {
+			*best, found = f, true
 		}
+		if !more {
+			break
+		}
+	}
+	return found
+}
+
+// outranks reports whether a finding of the given severity and confidence
+// ranks above best, so that it is reported in its place: a more severe one
+// does, and on equal severity a more confident one
+func outranks(severity, confidence string, best Finding) bool {
+	if s, b := suspicious.SeverityRank(severity), suspicious.SeverityRank(best.Severity); s != b {
+		return s > b
+	}
+	return suspicious.ConfidenceRank(confidence) > suspicious.ConfidenceRank(best.Confidence)
+}
+
+// accept reviews a finding and reports whether it is reported, and whether
+// the matcher's later findings are still considered. One below the
+// thresholds or suppressed for its rule gives way to the next; one
+// suppressed for its category ends its matcher.
+func (c *URLChecker) accept(t *rules.Target, f *Finding) (ok, more bool) {
+	switch c.review(t, f) {
+	case suppressed:
+		return false, true
+	case silenced:
+		return false, false
+	}
+	return c.passes(*f), true
+}
+
+// withDefaults fills in the severity, confidence and weight a registered
+// matcher left out
+func withDefaults(f Finding) Finding {
+	if f.Severity == "" {
+		f.Severity = suspicious.DefaultSeverity(f.Category)
+	}
+	if f.Confidence == "" {
+		f.Confidence = suspicious.DefaultConfidence(f.Category)
+	}
+	if f.Weight == 0 {
+		f.Weight = suspicious.SeverityWeight(f.Severity)
+	}
+	return f
+}
+
+// passes reports whether a finding meets the severity and confidence
+// thresholds
+func (c *URLChecker) passes(f Finding) bool {
+	return suspicious.SeverityRank(f.Severity) >= c.minSeverity &&
+		suspicious.ConfidenceRank(f.Confidence) >= c.minConfidence
+}
+
+// newFinding describes a rule match
+func newFinding(cat *category, rule *rules.Compiled, t *rules.Target) Finding {
+	f := Finding{
+		Category:   cat.name,
+		Reason:     cat.reason,
+		Severity:   rule.Rule.Severity,
+		Confidence: rule.Rule.Confidence,
+		RuleID:     rule.Rule.ID,
+		RuleSource: rule.Rule.Source,
+		Weight:     rule.Rule.Weight,
+	}
+	if rule.Rule.Reason != "" {
+		f.Reason = rule.Rule.Reason
 	}
+	if captured := rule.Captures(t); captured != "" {
+		f.Reason += " (" + captured + ")"
+	}
+	f.Match, _ = rule.Locate(t)
+	return f
+}
 
-	return false, "", ""
+// verdict is what suppress rules made of a finding
+type verdict int
+
+const (
+	kept       verdict = iota
+	suppressed         // By a rule naming rule IDs, for the finding's rule only
+	silenced           // For its whole category or matcher
+)
+
+// review applies suppress and downgrade rules to a finding and reports
+// whether it survives
+func (c *URLChecker) review(t *rules.Target, f *Finding) verdict {
+	for _, rule := range c.overrides {
+		if !rule.Rule.AppliesToCategory(f.Category) || !rule.Rule.AppliesToRule(f.RuleID) || !rule.Match(t) {
+			continue
+		}
+		switch rule.Rule.Type {
+		case rules.TypeSuppress:
+			if len(rule.Rule.AppliesToRules) > 0 {
+				return suppressed
+			}
+			return silenced
+		case rules.TypeDowngrade:
+			if rule.Rule.Severity != "" && suspicious.SeverityRank(rule.Rule.Severity) < suspicious.SeverityRank(f.Severity) {
+				f.Severity = rule.Rule.Severity
+				f.Weight = min(f.Weight, suspicious.SeverityWeight(f.Severity))
+				f.Reason += ", downgraded to " + f.Severity + " by " + rule.Rule.ID
+			}
+			if rule.Rule.Confidence != "" && suspicious.ConfidenceRank(rule.Rule.Confidence) < suspicious.ConfidenceRank(f.Confidence) {
+				f.Confidence = rule.Rule.Confidence
+				f.Reason += ", downgraded to " + f.Confidence + " by " + rule.Rule.ID
+			}
+		}
+	}
+	return kept
 }
 
 // IsValidURL performs basic URL validation
 func IsValidURL(rawURL string) bool {
+	return ValidateURL(rawURL) == nil
+}
+
+// ValidateURL performs basic URL validation, returning why a URL fails
+func ValidateURL(rawURL string) error {
 	if len(rawURL) == 0 {
-		return false
+		return errors.New("empty URL")
+	}
+	if !utf8.ValidString(rawURL) {
+		return errors.New("invalid UTF-8")
 	}
+	rawURL, _ = urlnorm.FromWindows(rawURL)
 
 	// Basic URL parsing validation
-	_, err := url.Parse(rawURL)
-	if err != nil {
-		return false
+	if _, err := url.Parse(rawURL); err != nil {
+		return errors.Unwrap(err) // The url.Error repeats the URL
 	}
 
 	// Check for common URL patterns
-	return strings.HasPrefix(rawURL, "http://") ||
+	if strings.HasPrefix(rawURL, "http://") ||
 		strings.HasPrefix(rawURL, "https://") ||
 		strings.HasPrefix(rawURL, "ftp://") ||
-		strings.Contains(rawURL, ".")
+		strings.HasPrefix(rawURL, "file://") ||
+		strings.Contains(rawURL, ".") {
+		return nil
+	}
+	return errors.New("no known scheme or domain")
 }
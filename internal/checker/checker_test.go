@@ -0,0 +1,470 @@
+package checker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/rules"
+)
+
+// TestCustomCategories verifies user categories are reported and selectable
+func TestCustomCategories(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "tools:/jenkinsx", Category: "internal-tools", Condition: rules.Condition{Pattern: "/jenkinsx"}, Reason: "Internal tooling"},
+	}
+
+	tests := []struct {
+		categories string
+		url        string
+		wantFlag   bool
+		wantCat    string
+	}{
+		{"", "https://example.com/jenkinsx", true, "internal-tools"},
+		{"internal-tools", "https://example.com/jenkinsx", true, "internal-tools"},
+		{"paths", "https://example.com/jenkinsx", false, ""},
+		{"internal-tools", "https://example.com/.git/config", false, ""},
+	}
+
+	for _, tc := range tests {
+		uc := NewURLChecker(tc.categories, "", extra...)
+		flag, cat, _ := uc.IsSuspicious(tc.url)
+		if flag != tc.wantFlag || cat != tc.wantCat {
+			t.Errorf("-m %q IsSuspicious(%q) = %v, %q; want %v, %q",
+				tc.categories, tc.url, flag, cat, tc.wantFlag, tc.wantCat)
+		}
+	}
+}
+
+// TestResolveCategories covers -M exclusions with and without -m, and
+// unknown names in either
+func TestResolveCategories(t *testing.T) {
+	extra := []rules.Rule{{ID: "t", Category: "internal-tools", Condition: rules.Condition{Pattern: "/x"}}}
+	tests := []struct {
+		include, exclude string
+		want             string
+		wantErr          string // Flag the error names, if any
+	}{
+		{"", "", "", ""},
+		{"Hidden,internal-tools", "", "Hidden,internal-tools", ""},
+		{"", "extensions,hidden", "keywords,paths,shares,obfuscation,homograph,internal-tools", ""},
+		{"keywords,paths", "paths", "keywords", ""},
+		{"", "nope", "", "-M"},
+		{"nope", "", "", "-m"},
+		{"keywords,nope", "paths", "", "-m"},
+		{"hidden", "hidden", "", "-M"},
+	}
+	for _, tc := range tests {
+		got, err := ResolveCategories(tc.include, tc.exclude, extra)
+		if got != tc.want || (err == nil) != (tc.wantErr == "") || err != nil && !strings.HasPrefix(err.Error(), tc.wantErr+":") {
+			t.Errorf("ResolveCategories(%q, %q) = %q, %v; want %q, error from %q",
+				tc.include, tc.exclude, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// TestOverrides covers suppress and downgrade rules
+func TestOverrides(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "docs", Type: rules.TypeDowngrade, Severity: "info", AppliesTo: []string{"keywords"},
+			Condition: rules.Condition{Path: "/docs/"}},
+		{ID: "health", Type: rules.TypeSuppress, Condition: rules.Condition{Pattern: "/health.json"}},
+		{ID: "hc", Type: rules.TypeSuppress, AppliesToRules: []string{"extensions:.js*"},
+			Condition: rules.Condition{Path: "/hc."}},
+	}
+	uc := NewURLChecker("", "", extra...)
+
+	f, ok := uc.Check("https://example.com/docs/token")
+	if !ok || f.Category != "keywords" || f.Severity != "info" {
+		t.Errorf("downgrade: got %+v, %v; want keywords finding at info", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/token"); !ok || f.Severity == "info" {
+		t.Errorf("no downgrade outside /docs/: got %+v, %v", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/health.json"); ok {
+		t.Errorf("suppress: got %+v; want no finding", f)
+	}
+	if f, ok := uc.Check("https://example.com/hc.json"); ok {
+		t.Errorf("rule-level suppress: got %+v; want no finding", f)
+	}
+	if f, ok := uc.Check("https://example.com/hc.zip"); !ok || f.RuleID != "extensions:.zip" {
+		t.Errorf("rule-level suppress of other rules: got %+v, %v; want extensions:.zip", f, ok)
+	}
+
+	// A suppress naming rule IDs skips only their matches, so the rest of
+	// the category is still tried
+	uc = NewURLChecker("hidden", "", rules.Rule{ID: "git-ok", Type: rules.TypeSuppress,
+		AppliesToRules: []string{"hidden:git*"}, Condition: rules.Condition{Pattern: "/.git"}})
+	if f, ok := uc.Check("https://example.com/.git/.ssh/id_rsa"); !ok || f.RuleID != "hidden:ssh-dir" {
+		t.Errorf("got %+v, %v; want the .ssh finding after the suppressed .git one", f, ok)
+	}
+	if f, score, ok := uc.Score("https://example.com/.git/.ssh/id_rsa"); !ok || f.RuleID != "hidden:ssh-dir" || score != 20 {
+		t.Errorf("got %+v scoring %d, %v; want the .ssh finding alone", f, score, ok)
+	}
+	if f, ok := uc.Check("https://example.com/.git/config"); ok {
+		t.Errorf("got %+v; want suppressed", f)
+	}
+}
+
+// TestCheck reports the most severe match across categories, not the first
+// category to match
+func TestCheck(t *testing.T) {
+	uc := NewURLChecker("", "")
+	f, ok := uc.Check("https://a.com/.git/config")
+	if !ok || f.RuleID != "hidden:git-config" || f.Severity != "high" {
+		t.Errorf("got %+v, %v; want the high hidden:git-config finding", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/export.sql"); !ok || f.Category != "extensions" || f.Severity != "high" {
+		t.Errorf("got %+v, %v; want the high .sql finding over the sql keyword", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/token"); !ok || f.Category != "keywords" {
+		t.Errorf("got %+v, %v; want a keyword finding when nothing else matches", f, ok)
+	}
+}
+
+// TestMinSeverity lets a low-severity category fall through to a higher one
+func TestMinSeverity(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "low", Category: "keywords", Severity: "low", Condition: rules.Condition{Pattern: "zzlow"}},
+		{ID: "crit", Category: "hidden", Severity: "critical", Condition: rules.Condition{Pattern: "/.zzcrit"}},
+	}
+	uc := NewURLChecker("", "", extra...)
+	if err := uc.SetMinSeverity("high"); err != nil {
+		t.Fatal(err)
+	}
+
+	if f, ok := uc.Check("https://example.com/zzlow/.zzcrit"); !ok || f.RuleID != "crit" {
+		t.Errorf("got %+v, %v; want finding from rule crit", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/zzlow"); ok {
+		t.Errorf("got %+v; want low finding dropped", f)
+	}
+	if err := uc.SetMinSeverity("severe"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+
+	// A match below the threshold gives way to a later one of its own
+	// category: .git (high) comes before .ssh (critical) in hidden, and
+	// /admin (medium) before /phpmyadmin (high) in paths
+	if err := uc.SetMinSeverity("critical"); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := uc.Check("https://example.com/.git/.ssh/id_rsa"); !ok || f.RuleID != "hidden:ssh-dir" {
+		t.Errorf("got %+v, %v; want the critical .ssh finding", f, ok)
+	}
+	uc = NewURLChecker("paths", "")
+	if err := uc.SetMinSeverity("high"); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := uc.Check("https://example.com/admin/phpmyadmin/"); !ok || f.Severity != "high" {
+		t.Errorf("got %+v, %v; want a high paths finding past /admin", f, ok)
+	}
+}
+
+// TestMinConfidence drops tentative matches, including ones lowered by a
+// downgrade rule, and defaults confidence from the category
+func TestMinConfidence(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "kw", Category: "keywords", Condition: rules.Condition{Pattern: "zzkw"}},
+		{ID: "sure", Category: "hidden", Confidence: "certain", Condition: rules.Condition{Pattern: "/.zzsure"}},
+		{ID: "unsure", Type: rules.TypeDowngrade, Confidence: "tentative", Condition: rules.Condition{Path: "/docs/"}},
+	}
+	uc := NewURLChecker("", "", extra...)
+	if f, ok := uc.Check("https://example.com/zzkw"); !ok || f.Confidence != "tentative" {
+		t.Errorf("got %+v, %v; want tentative keyword finding", f, ok)
+	}
+	if err := uc.SetMinConfidence("likely"); err != nil {
+		t.Fatal(err)
+	}
+
+	if f, ok := uc.Check("https://example.com/zzkw/.zzsure"); !ok || f.RuleID != "sure" || f.Confidence != "certain" {
+		t.Errorf("got %+v, %v; want certain finding from rule sure", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/zzkw"); ok {
+		t.Errorf("got %+v; want tentative finding dropped", f)
+	}
+	if f, ok := uc.Check("https://example.com/docs/.zzsure"); ok {
+		t.Errorf("got %+v; want downgraded finding dropped", f)
+	}
+	if err := uc.SetMinConfidence("sure"); err == nil {
+		t.Error("expected error for unknown confidence")
+	}
+
+	// A tentative match gives way to a more confident one later in the
+	// same category
+	uc = NewURLChecker("", "",
+		rules.Rule{ID: "guess", Category: "zz", Confidence: "tentative", Condition: rules.Condition{Pattern: "zzguess"}},
+		rules.Rule{ID: "known", Category: "zz", Confidence: "certain", Condition: rules.Condition{Pattern: "zzknown"}},
+	)
+	if err := uc.SetMinConfidence("likely"); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := uc.Check("https://example.com/zzguess/zzknown"); !ok || f.RuleID != "known" {
+		t.Errorf("got %+v, %v; want the certain finding from rule known", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/zzguess"); ok {
+		t.Errorf("got %+v; want the tentative finding dropped", f)
+	}
+
+	// With default options a certain match is reported over a tentative
+	// one of the same severity, and .git/config is certain
+	uc = NewURLChecker("", "",
+		rules.Rule{ID: "guess", Category: "zz", Severity: "high", Confidence: "tentative", Condition: rules.Condition{Pattern: "zzguess"}},
+		rules.Rule{ID: "known", Category: "zz", Severity: "high", Confidence: "certain", Condition: rules.Condition{Pattern: "zzknown"}},
+	)
+	if f, ok := uc.Check("https://example.com/zzguess/zzknown"); !ok || f.RuleID != "known" {
+		t.Errorf("got %+v, %v; want the certain finding from rule known", f, ok)
+	}
+	if f, ok := uc.Check("https://a.com/.git/config"); !ok || f.RuleID != "hidden:git-config" || f.Confidence != "certain" {
+		t.Errorf("got %+v, %v; want hidden:git-config with certain confidence", f, ok)
+	}
+}
+
+// hostMatcher flags one host, leaving severity and confidence to defaults
+type hostMatcher string
+
+func (h hostMatcher) Match(u *ParsedURL) []Finding {
+	if u.Host() != string(h) {
+		return nil
+	}
+	return []Finding{{Category: "inventory", Reason: "Known staging host", RuleID: "inventory:" + string(h)}}
+}
+
+// TestRegister runs registered matchers after the categories, with
+// defaults filled in and suppress rules applied, reporting them when they
+// outrank the categories' matches
+func TestRegister(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "ok", Type: rules.TypeSuppress, AppliesToRules: []string{"inventory:*"}, Condition: rules.Condition{Path: "/ok"}},
+	}
+	uc := NewURLChecker("", "", extra...)
+	uc.Register(hostMatcher("zz.example"))
+
+	f, ok := uc.Check("https://zz.example/")
+	if !ok || f.RuleID != "inventory:zz.example" || f.Severity != "medium" || f.Confidence != "likely" {
+		t.Errorf("got %+v, %v; want inventory finding with default severity and confidence", f, ok)
+	}
+	if f, ok := uc.Check("https://zz.example/.git/HEAD"); !ok || f.Category != "hidden" {
+		t.Errorf("got %+v, %v; want the high hidden finding over the medium inventory one", f, ok)
+	}
+	if f, ok := uc.Check("https://zz.example/token"); !ok || f.Category != "inventory" {
+		t.Errorf("got %+v, %v; want the medium inventory finding over the low keyword", f, ok)
+	}
+	if f, ok := uc.Check("https://zz.example/ok"); ok {
+		t.Errorf("got %+v; want suppressed", f)
+	}
+	if _, score, ok := uc.Score("https://zz.example/"); !ok || score != 5 {
+		t.Errorf("score = %d, %v; want 5", score, ok)
+	}
+}
+
+// quoteMatcher flags every URL, quoting it in the reason
+type quoteMatcher struct{}
+
+func (quoteMatcher) Match(u *ParsedURL) []Finding {
+	return []Finding{{Category: "quote", Reason: u.Raw[len("https://"):], RuleID: "quote"}}
+}
+
+// TestCheckBytes matches a reused buffer in place, keeping findings that
+// quote it intact once it is overwritten
+func TestCheckBytes(t *testing.T) {
+	uc := NewURLChecker("paths", "")
+	uc.Register(quoteMatcher{})
+	buf := []byte("https://example.com/.git/config")
+	f, ok := uc.CheckBytes(buf)
+	if !ok || f.Category != "paths" {
+		t.Fatalf("got %+v, %v; want a paths finding", f, ok)
+	}
+	buf = append(buf[:0], "https://example.com/index"...)
+	f, ok = uc.CheckBytes(buf)
+	copy(buf, "XXXXXXXXXXXXXXXXXXXXXXXXX")
+	if !ok || f.Reason != "example.com/index" {
+		t.Errorf("got %+v, %v; want the quoted URL to survive reuse of the buffer", f, ok)
+	}
+}
+
+// TestRulesHash ignores where rules came from but not what they say
+func TestRulesHash(t *testing.T) {
+	rule := rules.Rule{ID: "zz", Category: "zz", Condition: rules.Condition{Pattern: "zzq"}, Source: "a.yaml"}
+	a := NewURLChecker("", "", rule)
+	rule.Source = "b.yaml"
+	b := NewURLChecker("", "", rule)
+	rule.Pattern = "zzr"
+	c := NewURLChecker("", "", rule)
+
+	if a.RulesHash() == "" || a.RulesHash() != b.RulesHash() {
+		t.Errorf("hashes %q and %q differ for the same rules", a.RulesHash(), b.RulesHash())
+	}
+	if a.RulesHash() == c.RulesHash() {
+		t.Error("hash unchanged after editing a rule")
+	}
+	if a.RulesHash() == NewURLChecker("keywords", "", rule).RulesHash() {
+		t.Error("hash unchanged after selecting fewer categories")
+	}
+	if f, ok := a.Check("https://example.com/zzq"); !ok || f.RuleSource != "a.yaml" {
+		t.Errorf("got %+v, %v; want rule source a.yaml", f, ok)
+	}
+	if f, ok := a.Check("https://example.com/.env"); !ok || f.RuleSource != rules.SourceBuiltin {
+		t.Errorf("got %+v, %v; want builtin rule source", f, ok)
+	}
+}
+
+// TestTaxonomy groups each category's rules by severity, most severe
+// first, and counts them
+func TestTaxonomy(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "ci:jenkins", Category: "ci", Reason: "Build server", Severity: "high", Condition: rules.Condition{Pattern: "/jenkins"}},
+		{ID: "ci:grafana", Category: "ci", Severity: "low", Condition: rules.Condition{Pattern: "/grafana"}},
+		{ID: "ci:kibana", Category: "ci", Condition: rules.Condition{Pattern: "/kibana"}},
+		{ID: "quiet", Type: rules.TypeSuppress, Condition: rules.Condition{Path: "/ok"}},
+	}
+	uc := NewURLChecker("paths,ci", "", extra...)
+	uc.Register(hostMatcher("zz.example"))
+	tax := uc.Taxonomy()
+
+	var names []string
+	total := 0
+	for _, cat := range tax.Categories {
+		names = append(names, cat.Name)
+		total += cat.Count
+	}
+	if want := []string{"paths", "ci"}; !slices.Equal(names, want) {
+		t.Fatalf("categories = %v; want %v", names, want)
+	}
+	if total != tax.Count {
+		t.Errorf("count = %d; want the categories' sum %d", tax.Count, total)
+	}
+	ci := tax.Categories[1]
+	if ci.Builtin || !tax.Categories[0].Builtin || ci.Count != 3 || ci.Reason != "Build server" {
+		t.Errorf("ci = %+v", ci)
+	}
+	var got []string
+	for _, sev := range ci.Severities {
+		if sev.Count != len(sev.Rules) {
+			t.Errorf("%s count = %d; want %d", sev.Severity, sev.Count, len(sev.Rules))
+		}
+		for _, r := range sev.Rules {
+			got = append(got, sev.Severity+" "+r.ID)
+		}
+	}
+	want := []string{"high ci:jenkins", "medium ci:kibana", "low ci:grafana"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ci rules = %v; want %v", got, want)
+	}
+}
+
+// TestScore sums the weights of every surviving match
+func TestScore(t *testing.T) {
+	extra := []rules.Rule{
+		{ID: "a", Category: "zz", Severity: "high", Condition: rules.Condition{Pattern: "zza"}},
+		{ID: "b", Category: "zz", Severity: "low", Weight: 3, Condition: rules.Condition{Pattern: "zzb"}},
+		{ID: "c", Category: "zz", Severity: "medium", Condition: rules.Condition{Pattern: "zzc"}},
+		{ID: "down", Type: rules.TypeDowngrade, Severity: "info", Condition: rules.Condition{Path: "/docs/"}},
+	}
+	uc := NewURLChecker("zz", "", extra...)
+
+	tests := []struct {
+		url   string
+		score int
+		rule  string
+	}{
+		{"https://example.com/zzb/zza", 13, "a"},
+		{"https://example.com/zzb/zzc", 8, "c"},
+		{"https://example.com/docs/zza/zzc", 2, "a"},
+		{"https://example.com/nothing", 0, ""},
+	}
+	for _, tt := range tests {
+		f, score, _ := uc.Score(tt.url)
+		if score != tt.score || f.RuleID != tt.rule {
+			t.Errorf("Score(%q) = %d, %q; want %d, %q", tt.url, score, f.RuleID, tt.score, tt.rule)
+		}
+	}
+}
+
+// TestRegexExcludes mixes literal and re: exclude patterns
+func TestRegexExcludes(t *testing.T) {
+	uc := NewURLChecker("", `cdn.example.com, re:^https://cdn\d+\., re:/v\d{1,3}/static/`)
+
+	for url, want := range map[string]bool{
+		"https://CDN.example.com/.env":              false,
+		"https://cdn42.example.org/.env":            false,
+		"https://www.example.org/v12/static/.env":   false,
+		"https://www.example.org/cdn42.x/.env":      true,
+		"https://www.example.org/v1234/static/.env": true,
+	} {
+		if _, ok := uc.Check(url); ok != want {
+			t.Errorf("Check(%q) = %v, want %v", url, ok, want)
+		}
+	}
+
+	if err := CheckExcludes("a.com, re:[z-a]"); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if err := CheckExcludes(`re:(a|b)+,[literal`); err != nil {
+		t.Errorf("CheckExcludes: %v", err)
+	}
+
+	got := splitExcludes(`.js, re:a{1,3}, re:x\,y,[a,b`)
+	want := []string{".js", "re:a{1,3}", `re:x\,y`, "[a", "b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitExcludes = %q, want %q", got, want)
+	}
+}
+
+// TestAddExcludes keeps file patterns whole, commas included
+func TestAddExcludes(t *testing.T) {
+	uc := NewURLChecker("", "")
+	if err := uc.AddExcludes("re:^https://(a|b),c\\.example/", "static.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	for url, want := range map[string]bool{
+		"https://b,c.example/.env":        false,
+		"https://static.example.com/.env": false,
+		"https://www.example.com/.env":    true,
+	} {
+		if _, ok := uc.Check(url); ok != want {
+			t.Errorf("Check(%q) = %v, want %v", url, ok, want)
+		}
+	}
+	if err := uc.AddExcludes("re:(unclosed"); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+// benchURLs are typical crawl URLs, most of them clean
+var benchURLs = []string{
+	"https://www.example.com/products/item?id=42&ref=home",
+	"https://static.example.com/assets/app.3f9c2b.css",
+	"https://example.com/blog/2024/05/hello-world/",
+	"https://api.example.com/v2/users/1234/orders?page=3#top",
+	"https://example.com/backup/db.sql",
+	"https://cdn.example.net/img/logo%20large.png",
+	"https://example.com/.git/config",
+	"https://shop.example.org/category/shoes?sort=price&dir=asc",
+}
+
+// BenchmarkCheck measures Check on a mix of clean and suspicious URLs,
+// with allocations per URL
+func BenchmarkCheck(b *testing.B) {
+	uc := NewURLChecker("", "")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		uc.Check(benchURLs[i%len(benchURLs)])
+	}
+}
+
+// BenchmarkCheckBytes measures CheckBytes reading URLs from one reused
+// buffer, as a line reader would
+func BenchmarkCheckBytes(b *testing.B) {
+	uc := NewURLChecker("", "")
+	lines := make([][]byte, len(benchURLs))
+	for i, u := range benchURLs {
+		lines[i] = []byte(u)
+	}
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = append(buf[:0], lines[i%len(lines)]...)
+		uc.CheckBytes(buf)
+	}
+}
@@ -0,0 +1,79 @@
+package checker
+
+import (
+	"github.com/alwalxed/juicyurls/v2/internal/rules"
+	"github.com/alwalxed/juicyurls/v2/suspicious"
+)
+
+// Taxonomy is the tree of categories, severities and rules a checker
+// matches, with counts at each level, for front-ends that list them
+type Taxonomy struct {
+	Count      int                `json:"count"`
+	Categories []TaxonomyCategory `json:"categories"`
+}
+
+// TaxonomyCategory is one category with its rules grouped by severity,
+// most severe first
+type TaxonomyCategory struct {
+	Name       string             `json:"name"`
+	Reason     string             `json:"reason"`
+	Builtin    bool               `json:"builtin"`
+	Count      int                `json:"count"`
+	Severities []TaxonomySeverity `json:"severities"`
+}
+
+// TaxonomySeverity holds the rules of a category at one severity
+type TaxonomySeverity struct {
+	Severity string         `json:"severity"`
+	Count    int            `json:"count"`
+	Rules    []TaxonomyRule `json:"rules"`
+}
+
+// TaxonomyRule describes one detect rule
+type TaxonomyRule struct {
+	ID         string `json:"id"`
+	Reason     string `json:"reason,omitempty"`
+	Confidence string `json:"confidence"`
+	Weight     int    `json:"weight"`
+	Source     string `json:"source"`
+}
+
+// Taxonomy returns the enabled categories in match order with their rules.
+// Severities without rules are left out; registered matchers, whose
+// findings are not known in advance, are not listed.
+func (c *URLChecker) Taxonomy() Taxonomy {
+	c.compileRules()
+	var tax Taxonomy
+	for _, m := range c.matchers {
+		cat, ok := m.(*category)
+		if !ok {
+			continue
+		}
+		tc := TaxonomyCategory{
+			Name:    cat.name,
+			Reason:  cat.reason,
+			Builtin: rules.IsBuiltinCategory(cat.name),
+			Count:   len(cat.rules),
+		}
+		for i := len(suspicious.Severities) - 1; i >= 0; i-- {
+			ts := TaxonomySeverity{Severity: suspicious.Severities[i]}
+			for _, r := range cat.rules {
+				if r.Rule.Severity == ts.Severity {
+					ts.Rules = append(ts.Rules, TaxonomyRule{
+						ID:         r.Rule.ID,
+						Reason:     r.Rule.Reason,
+						Confidence: r.Rule.Confidence,
+						Weight:     r.Rule.Weight,
+						Source:     r.Rule.Source,
+					})
+				}
+			}
+			if ts.Count = len(ts.Rules); ts.Count > 0 {
+				tc.Severities = append(tc.Severities, ts)
+			}
+		}
+		tax.Count += tc.Count
+		tax.Categories = append(tax.Categories, tc)
+	}
+	return tax
+}
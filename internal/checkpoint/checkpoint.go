@@ -0,0 +1,271 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"juicyurls/internal/offsets"
+	"juicyurls/internal/types"
+)
+
+// Interval is how often a running scan saves its checkpoint
+const Interval = 5 * time.Second
+
+// ErrExists is returned by New when a checkpoint is already saved at the
+// path, so an interrupted scan is not overwritten by accident
+var ErrExists = errors.New("checkpoint already exists")
+
+// Outcome is what became of a checked line
+type Outcome int
+
+const (
+	Clean      Outcome = iota // Checked, no finding
+	Found                     // Checked, and its finding written
+	OutOfScope                // Skipped as out of scope
+	Known                     // Checked, and its finding already in the baseline
+	OtherShard                // Skipped as another shard's
+)
+
+// Checkpoint is how far a scan got, saved so an interrupted scan can resume
+// instead of starting over. Workers finish lines out of order, so each
+// source is recorded up to the last line below which every line has been
+// checked and its finding written. Findings already written past that
+// point are listed, and are not written again on resume.
+type Checkpoint struct {
+	Files      map[string]offsets.Position `json:"files"`       // Per source name, as in findings
+	Written    map[string][]int            `json:"written"`     // Lines past Files already reported, per source
+	Stats      types.Stats                 `json:"stats"`       // Totals up to Files
+	OutputSize int64                       `json:"output_size"` // Output bytes covering Files and Written
+
+	path    string
+	resumed map[line]bool // Written, as loaded
+
+	mu       sync.Mutex
+	next     uint64 // Sequence number of the next tracked line
+	low      uint64 // Every line below is done and folded into Files
+	pending  map[uint64]*tracked
+	seqs     map[line]uint64
+	sourceAt map[string]int // Index in Stats.Sources
+	output   int64
+}
+
+// line identifies an input line
+type line struct {
+	source string
+	n      int
+}
+
+// tracked is a line read but not yet folded into Files
+type tracked struct {
+	line
+	pos     offsets.Position
+	done    bool
+	outcome Outcome
+}
+
+// New starts a checkpoint at path, refusing to replace a saved one
+func New(path string) (*Checkpoint, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrExists)
+	}
+	return start(&Checkpoint{path: path}), nil
+}
+
+// Load reads the checkpoint an interrupted scan saved at path
+func Load(path string) (*Checkpoint, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: no checkpoint to resume", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{path: path}
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.resumed = make(map[line]bool)
+	for source, lines := range c.Written {
+		for _, n := range lines {
+			c.resumed[line{source, n}] = true
+		}
+	}
+	return start(c), nil
+}
+
+func start(c *Checkpoint) *Checkpoint {
+	if c.Files == nil {
+		c.Files = make(map[string]offsets.Position)
+	}
+	c.pending = make(map[uint64]*tracked)
+	c.seqs = make(map[line]uint64)
+	c.sourceAt = make(map[string]int)
+	for i, s := range c.Stats.Sources {
+		c.sourceAt[s.Source] = i
+	}
+	c.output = c.OutputSize
+	return c
+}
+
+// Resume seeks f, opened from source, past the lines the checkpoint covers
+// and returns the position reached. A file now shorter than that was
+// changed since, and resuming it is refused.
+func (c *Checkpoint) Resume(source string, f *os.File) (offsets.Position, error) {
+	p := c.Files[source]
+	if p.Offset == 0 {
+		return p, nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return offsets.Position{}, err
+	}
+	if fi.Size() < p.Offset {
+		return offsets.Position{}, fmt.Errorf("%s changed since the checkpoint", source)
+	}
+	_, err = f.Seek(p.Offset, io.SeekStart)
+	return p, err
+}
+
+// Position returns where source was recorded, for sources that are not
+// files, such as the command-line URLs
+func (c *Checkpoint) Position(source string) offsets.Position {
+	return c.Files[source]
+}
+
+// Reported reports whether the finding on a line was written before the
+// scan was resumed
+func (c *Checkpoint) Reported(source string, n int) bool {
+	return c.resumed[line{source, n}]
+}
+
+// Track records a line as read, at pos just past it. Lines must be tracked
+// in reading order.
+func (c *Checkpoint) Track(source string, pos offsets.Position) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := line{source, pos.Line}
+	c.pending[c.next] = &tracked{line: l, pos: pos}
+	c.seqs[l] = c.next
+	c.next++
+}
+
+// Done records what became of a tracked line
+func (c *Checkpoint) Done(source string, n int, o Outcome) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seq, ok := c.seqs[line{source, n}]
+	if !ok {
+		return
+	}
+	t := c.pending[seq]
+	t.done, t.outcome = true, o
+
+	// Fold in every line up to the first one still in flight
+	for {
+		t, ok := c.pending[c.low]
+		if !ok || !t.done {
+			return
+		}
+		delete(c.pending, c.low)
+		delete(c.seqs, t.line)
+		c.low++
+		c.Files[t.source] = t.pos
+		c.count(t)
+	}
+}
+
+// count adds a folded line to the totals
+func (c *Checkpoint) count(t *tracked) {
+	i, ok := c.sourceAt[t.source]
+	if !ok {
+		i = len(c.Stats.Sources)
+		c.sourceAt[t.source] = i
+		c.Stats.Sources = append(c.Stats.Sources, types.SourceStats{Source: t.source})
+	}
+	s := &c.Stats.Sources[i]
+	c.Stats.Total++
+	s.Total++
+	switch t.outcome {
+	case OtherShard:
+		c.Stats.OtherShards++
+	case OutOfScope:
+		c.Stats.OutOfScope++
+		s.OutOfScope++
+	case Known:
+		c.Stats.Known++
+		fallthrough
+	case Found:
+		c.Stats.Suspicious++
+		s.Suspicious++
+		fallthrough
+	case Clean:
+		c.Stats.Processed++
+		s.Processed++
+	}
+}
+
+// Output wraps the scan's output to count the bytes written to it
+func (c *Checkpoint) Output(w io.Writer) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		n, err := w.Write(p)
+		c.mu.Lock()
+		c.output += int64(n)
+		c.mu.Unlock()
+		return n, err
+	})
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// Save writes the checkpoint to its file, replacing it atomically. elapsed
+// is the scan's running time, including earlier runs.
+func (c *Checkpoint) Save(elapsed time.Duration) error {
+	c.mu.Lock()
+	c.OutputSize = c.output
+	c.Stats.Seconds = elapsed.Seconds()
+	if c.Stats.Seconds > 0 {
+		c.Stats.Rate = float64(c.Stats.Processed) / c.Stats.Seconds
+	}
+	c.Written = make(map[string][]int)
+	for l := range c.resumed {
+		if _, ok := c.seqs[l]; ok || c.Files[l.source].Line < l.n {
+			c.Written[l.source] = append(c.Written[l.source], l.n)
+		}
+	}
+	for _, t := range c.pending {
+		if t.done && t.outcome == Found && !c.resumed[t.line] {
+			c.Written[t.source] = append(c.Written[t.source], t.n)
+		}
+	}
+	for _, lines := range c.Written {
+		slices.Sort(lines)
+	}
+	raw, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Remove deletes the checkpoint once the scan completes
+func (c *Checkpoint) Remove() error {
+	err := os.Remove(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package checkpoint
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alwalxed/juicyurls/v2/internal/offsets"
+)
+
+// TestCheckpoint records a source only up to its first unfinished line,
+// and keeps the findings written past it across a resume
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.checkpoint")
+	c, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= 4; n++ {
+		c.Track("urls.txt", offsets.Position{Offset: int64(10 * n), Line: n})
+	}
+	c.Done("urls.txt", 1, Found)
+	c.Done("urls.txt", 3, Found) // Line 2 is still being checked
+	c.Done("urls.txt", 4, Clean)
+	if err := c.Save(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path); !errors.Is(err, ErrExists) {
+		t.Errorf("New over a saved checkpoint = %v; want ErrExists", err)
+	}
+
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Position("urls.txt"); got.Line != 1 || got.Offset != 10 {
+		t.Errorf("position = %+v; want line 1 at offset 10", got)
+	}
+	if r.Stats.Total != 1 || r.Stats.Suspicious != 1 {
+		t.Errorf("stats = %+v; want the first line only", r.Stats)
+	}
+	if !r.Reported("urls.txt", 3) || r.Reported("urls.txt", 2) {
+		t.Errorf("written = %v; want line 3 only", r.Written)
+	}
+
+	// Resumed lines are folded in again; line 3 stays reported until then
+	r.Track("urls.txt", offsets.Position{Offset: 20, Line: 2})
+	r.Track("urls.txt", offsets.Position{Offset: 30, Line: 3})
+	r.Done("urls.txt", 3, Found)
+	if err := r.Save(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(r.Written["urls.txt"], []int{3}) {
+		t.Errorf("written after resume = %v; want [3]", r.Written)
+	}
+	r.Done("urls.txt", 2, Clean)
+	if got := r.Position("urls.txt"); got.Line != 3 || r.Stats.Total != 3 || r.Stats.Suspicious != 2 {
+		t.Errorf("position %+v, stats %+v; want line 3 with 3 lines and 2 findings", got, r.Stats)
+	}
+}
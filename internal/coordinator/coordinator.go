@@ -0,0 +1,327 @@
+package coordinator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+
+	"juicyurls/internal/types"
+	"juicyurls/pkg/juicyurls"
+)
+
+// DefaultUnit is how many URLs a work unit holds unless set
+const DefaultUnit = 10_000
+
+// ErrNoWorkers is returned when every worker failed and none can register
+var ErrNoWorkers = errors.New("no workers left")
+
+// Options control a distributed scan
+type Options struct {
+	Unit    int          // URLs per work unit; zero uses DefaultUnit
+	Retries int          // Times a failed unit is sent to another worker
+	Client  *http.Client // Client for the workers; nil uses http.DefaultClient
+	Logger  *slog.Logger
+}
+
+// Stats summarize a distributed scan
+type Stats struct {
+	URLs      int            // URLs the workers scanned
+	Units     int            // Work units completed
+	Findings  int            // Findings received
+	Retried   int            // Units sent again after a worker failed
+	RulesHash string         // Rule set of the workers
+	ByWorker  map[string]int // Units completed per worker
+}
+
+// unit is a slice of the input sent to one worker
+type unit struct {
+	first    int // Input line of the first line in body
+	body     []byte
+	attempts int
+}
+
+// scanResponse is the body of a worker's POST /scan-file
+type scanResponse struct {
+	Scanned   int                `json:"scanned"`
+	RulesHash string             `json:"rules_hash"`
+	Findings  []juicyurls.Result `json:"findings"`
+	Error     string             `json:"error"`
+}
+
+// Run splits in into units of opts.Unit URLs, scans each on a worker of reg
+// through its serve http API and sends the findings to out, with Source set
+// to source and Line to the line in the whole input. Findings arrive as
+// units complete, not in input order. A worker that fails, or runs a rule
+// set other than the first worker's, is dropped and its unit sent to
+// another, up to opts.Retries times. Run returns once every unit is done,
+// when no worker is left and none can register, or when ctx ends.
+func Run(ctx context.Context, in io.Reader, source string, reg *Registry,
+	opts Options, out chan<- types.Result) (Stats, error) {
+
+	if opts.Unit <= 0 {
+		opts.Unit = DefaultUnit
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	r := &run{reg: reg, opts: opts, source: source, out: out, cancel: cancel,
+		units: make(chan *unit), stats: Stats{ByWorker: make(map[string]int)}}
+	if !r.workersLeft() {
+		return r.stats, ErrNoWorkers
+	}
+	if reg.Live() == 0 {
+		opts.Logger.Info("waiting for workers to register")
+	}
+
+	// The reader counts as pending until it is done, so the wait below
+	// cannot end before every unit is queued
+	r.pending.Add(1)
+	go func() {
+		defer r.pending.Done()
+		if err := r.split(ctx, in); err != nil {
+			cancel(err)
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case worker := <-reg.added:
+				go r.serve(ctx, worker)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ctx.Err() != nil {
+		return r.stats, context.Cause(ctx)
+	}
+	return r.stats, nil
+}
+
+// run is the state of one Run
+type run struct {
+	reg     *Registry
+	opts    Options
+	source  string
+	out     chan<- types.Result
+	cancel  context.CancelCauseFunc
+	units   chan *unit
+	pending sync.WaitGroup // Units not yet completed, and the reader
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// split reads the input into units and queues them
+func (r *run) split(ctx context.Context, in io.Reader) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	u := &unit{first: 1}
+	line, urls := 0, 0
+	for sc.Scan() {
+		line++
+		u.body = append(append(u.body, sc.Bytes()...), '\n')
+		if strings.TrimSpace(sc.Text()) != "" {
+			urls++
+		}
+		if urls == r.opts.Unit {
+			if !r.queue(ctx, u) {
+				return nil
+			}
+			u, urls = &unit{first: line + 1}, 0
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if urls > 0 {
+		r.queue(ctx, u)
+	}
+	return nil
+}
+
+// queue hands a unit to the next free worker. It reports false once ctx
+// has ended.
+func (r *run) queue(ctx context.Context, u *unit) bool {
+	r.pending.Add(1)
+	select {
+	case r.units <- u:
+		return true
+	case <-ctx.Done():
+		r.pending.Done()
+		return false
+	}
+}
+
+// serve sends units to worker until it fails or ctx ends
+func (r *run) serve(ctx context.Context, worker string) {
+	for {
+		var u *unit
+		select {
+		case u = <-r.units:
+		case <-ctx.Done():
+			return
+		}
+		resp, err := r.post(ctx, worker, u.body)
+		if err == nil {
+			err = r.checkRules(resp.RulesHash)
+		}
+		if err != nil {
+			r.fail(ctx, worker, u, err)
+			return
+		}
+		for _, f := range resp.Findings {
+			select {
+			case r.out <- r.result(f, u):
+			case <-ctx.Done():
+				r.pending.Done()
+				return
+			}
+		}
+		r.mu.Lock()
+		r.stats.URLs += resp.Scanned
+		r.stats.Units++
+		r.stats.Findings += len(resp.Findings)
+		r.stats.ByWorker[worker]++
+		r.mu.Unlock()
+		r.pending.Done()
+	}
+}
+
+// result converts a worker's finding on a line of u
+func (r *run) result(f juicyurls.Result, u *unit) types.Result {
+	return types.Result{
+		URL:         f.URL,
+		Category:    f.Category,
+		Reason:      f.Reason,
+		Severity:    f.Severity,
+		Confidence:  f.Confidence,
+		RuleID:      f.RuleID,
+		RuleSource:  f.RuleSource,
+		RulesHash:   f.RulesHash,
+		Fingerprint: f.Fingerprint,
+		Score:       f.Score,
+		Pattern:     f.Pattern,
+		Component:   f.Component,
+		Offset:      f.Offset,
+		Source:      r.source,
+		Line:        u.first - 1 + f.Line,
+	}
+}
+
+// fail drops worker and requeues its unit, or ends the run once the unit
+// is out of retries or no worker is left
+func (r *run) fail(ctx context.Context, worker string, u *unit, err error) {
+	if ctx.Err() != nil {
+		r.pending.Done()
+		return
+	}
+	r.opts.Logger.Warn("worker failed, dropping it", "worker", worker, "line", u.first, "err", err)
+	r.reg.drop(worker)
+	u.attempts++
+	if u.attempts > r.opts.Retries {
+		r.pending.Done()
+		r.cancel(fmt.Errorf("unit at line %d failed %d times: %w", u.first, u.attempts, err))
+		return
+	}
+	if !r.workersLeft() {
+		r.pending.Done()
+		r.cancel(ErrNoWorkers)
+		return
+	}
+	r.mu.Lock()
+	r.stats.Retried++
+	r.mu.Unlock()
+	// The unit stays pending; it is sent from a new goroutine as the other
+	// workers may all be busy
+	go func() {
+		select {
+		case r.units <- u:
+		case <-ctx.Done():
+			r.pending.Done()
+		}
+	}()
+}
+
+// workersLeft reports whether a worker is live or can still register
+func (r *run) workersLeft() bool {
+	r.reg.mu.Lock()
+	defer r.reg.mu.Unlock()
+	return len(r.reg.live) > 0 || r.reg.dynamic
+}
+
+// checkRules makes sure every worker runs the rule set of the first one
+// to answer, so the merged findings are consistent
+func (r *run) checkRules(hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stats.RulesHash == "" {
+		r.stats.RulesHash = hash
+	}
+	if hash != r.stats.RulesHash {
+		return fmt.Errorf("worker runs rules %s, not %s", hash, r.stats.RulesHash)
+	}
+	return nil
+}
+
+// post scans a unit on worker
+func (r *run) post(ctx context.Context, worker string, body []byte) (scanResponse, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", "unit.txt")
+	if err != nil {
+		return scanResponse{}, err
+	}
+	part.Write(body)
+	if err := mw.Close(); err != nil {
+		return scanResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, worker+"/scan-file", &buf)
+	if err != nil {
+		return scanResponse{}, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	res, err := r.opts.Client.Do(req)
+	if err != nil {
+		return scanResponse{}, err
+	}
+	defer res.Body.Close()
+
+	var resp scanResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return scanResponse{}, fmt.Errorf("%s: %w", res.Status, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return scanResponse{}, fmt.Errorf("%s: %s", res.Status, resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,127 @@
+package coordinator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/server"
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+	"github.com/alwalxed/juicyurls/v2/pkg/juicyurls"
+)
+
+// collect gathers the findings of a Run
+func collect(t *testing.T, input string, reg *Registry, opts Options) ([]types.Result, Stats, error) {
+	t.Helper()
+	out := make(chan types.Result)
+	var found []types.Result
+	done := make(chan struct{})
+	go func() {
+		for r := range out {
+			found = append(found, r)
+		}
+		close(done)
+	}()
+	stats, err := Run(context.Background(), strings.NewReader(input), "corpus.txt", reg, opts, out)
+	close(out)
+	<-done
+	slices.SortFunc(found, func(a, b types.Result) int { return a.Line - b.Line })
+	return found, stats, err
+}
+
+// TestRun spreads units over two workers, one of which fails once, and
+// checks the findings keep their lines in the whole input
+func TestRun(t *testing.T) {
+	s, err := juicyurls.New(juicyurls.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := httptest.NewServer(server.HTTP(s, 1<<20))
+	defer good.Close()
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+	}))
+	defer flaky.Close()
+
+	input := "https://example.com/\n\nhttps://example.com/.env\nhttps://example.com/a\n" +
+		"https://example.com/b\nhttps://example.com/backup.sql\nhttps://example.com/c\n"
+	reg, err := NewRegistry(good.URL, flaky.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, stats, err := collect(t, input, reg, Options{Unit: 2, Retries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, r := range found {
+		if r.Source != "corpus.txt" {
+			t.Errorf("source = %q", r.Source)
+		}
+		lines = append(lines, r.Line)
+	}
+	if !slices.Equal(lines, []int{3, 6}) {
+		t.Errorf("lines = %v; want [3 6]", lines)
+	}
+	if stats.URLs != 6 || stats.Units != 3 || stats.RulesHash != s.RulesHash() || stats.ByWorker[good.URL] != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if calls.Load() > 1 || stats.Retried != int(calls.Load()) {
+		t.Errorf("flaky worker called %d times, %d retries; want at most once", calls.Load(), stats.Retried)
+	}
+	if reg.Live() != 1 {
+		t.Errorf("live workers = %d; want the flaky one dropped", reg.Live())
+	}
+}
+
+// TestRunNoWorkers fails once every static worker is gone
+func TestRunNoWorkers(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	reg, err := NewRegistry(down.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := collect(t, "https://example.com/\n", reg, Options{Retries: 3}); err != ErrNoWorkers {
+		t.Errorf("err = %v; want ErrNoWorkers", err)
+	}
+	if _, err := NewRegistry("ftp://example.com"); err == nil {
+		t.Error("ftp worker accepted")
+	}
+}
+
+// TestRegister adds a worker over HTTP while a run waits for one
+func TestRegister(t *testing.T) {
+	s, err := juicyurls.New(juicyurls.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker := httptest.NewServer(server.HTTP(s, 1<<20))
+	defer worker.Close()
+	reg, _ := NewRegistry()
+	coord := httptest.NewServer(reg.Handler())
+	defer coord.Close()
+
+	go func() {
+		resp, err := http.Post(coord.URL+"/register", "application/json",
+			strings.NewReader(`{"url":"`+worker.URL+`"}`))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}()
+	found, _, err := collect(t, "https://example.com/.env\n", reg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Line != 1 {
+		t.Errorf("found = %+v", found)
+	}
+}
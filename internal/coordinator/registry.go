@@ -0,0 +1,119 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Registry holds the worker instances a coordinator dispatches to. Workers
+// are listed up front or register themselves over HTTP; one that fails is
+// dropped until it registers again.
+type Registry struct {
+	mu      sync.Mutex
+	live    map[string]bool
+	added   chan string // Workers to start dispatching to
+	dynamic bool        // Workers may register later
+}
+
+// NewRegistry starts a registry with static workers, given by base URL
+func NewRegistry(workers ...string) (*Registry, error) {
+	r := &Registry{live: make(map[string]bool), added: make(chan string, 64)}
+	for _, w := range workers {
+		if _, err := r.Add(w); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add registers a worker by base URL, such as http://10.0.0.5:8080. It
+// reports whether the worker is new; registering a live worker again is a
+// no-op, so workers can re-register as a heartbeat.
+func (r *Registry) Add(worker string) (bool, error) {
+	u, err := url.Parse(worker)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false, fmt.Errorf("invalid worker URL %q (want http://host:port)", worker)
+	}
+	worker = strings.TrimSuffix(u.String(), "/")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.live[worker] {
+		return false, nil
+	}
+	r.live[worker] = true
+	select {
+	case r.added <- worker:
+	default:
+		// The coordinator is behind; the worker is picked up on its next
+		// registration
+		delete(r.live, worker)
+		return false, fmt.Errorf("too many workers registering at once")
+	}
+	return true, nil
+}
+
+// drop removes a failed worker
+func (r *Registry) drop(worker string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.live, worker)
+}
+
+// Live returns how many workers are registered and not failed
+func (r *Registry) Live() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.live)
+}
+
+// registerBody is the JSON body of POST /register
+type registerBody struct {
+	URL string `json:"url"`
+}
+
+// Handler serves worker registration:
+//
+//	POST /register  {"url": "http://host:port"}, the worker's serve http address
+//	GET  /workers   the live workers
+//
+// Serving it lets workers join a running coordinator.
+func (r *Registry) Handler() http.Handler {
+	r.mu.Lock()
+	r.dynamic = true
+	r.mu.Unlock()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /register", func(w http.ResponseWriter, req *http.Request) {
+		var body registerBody
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1<<10)).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if _, err := r.Add(body.URL); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "registered"})
+	})
+	mux.HandleFunc("GET /workers", func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		workers := make([]string, 0, len(r.live))
+		for worker := range r.live {
+			workers = append(workers, worker)
+		}
+		r.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string][]string{"workers": workers})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
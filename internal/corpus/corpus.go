@@ -0,0 +1,110 @@
+package corpus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+
+	"juicyurls/suspicious"
+)
+
+// Options controls corpus generation
+type Options struct {
+	Count           int     // Number of URLs to generate
+	SuspiciousRatio float64 // Fraction of URLs built to be suspicious (0-1)
+	Seed            int64   // Seed for reproducible output
+}
+
+// Stats reports what was generated
+type Stats struct {
+	Total      int
+	Suspicious int
+}
+
+var (
+	tlds      = []string{"com", "net", "org", "io", "co.uk", "de", "fr", "app"}
+	hostWords = []string{
+		"acme", "globex", "umbrella", "hooli", "wonka", "stark", "wayne",
+		"vandelay", "pied", "soylent", "tyrell", "cyberdyne", "gringotts", "oceanic",
+	}
+	subdomains = []string{"www", "www", "www", "news", "media", "m", "cdn"}
+	// Benign vocabulary is chosen so it never overlaps the built-in patterns
+	pathWords = []string{
+		"news", "about", "team", "careers", "article", "photos", "gallery", "recipes",
+		"travel", "weather", "sports", "music", "movies", "books", "garden", "kitchen",
+		"health", "fashion", "events", "history", "science", "nature", "animals", "ocean",
+		"winter", "summer", "autumn", "people", "world", "local", "reviews",
+	}
+	imageExts = []string{"", "", "", ".png", ".jpg", ".gif", ".webp", ".svg"}
+	params    = []string{"page", "utm_medium", "ref", "sort", "q", "p", "tab"}
+)
+
+// Generate writes opts.Count URLs to w, one per line. Suspicious URLs are
+// built from the active pattern lists, so the same seed and lists always
+// produce the same corpus.
+func Generate(w io.Writer, opts Options) (Stats, error) {
+	var stats Stats
+	rng := rand.New(rand.NewSource(opts.Seed))
+	bw := bufio.NewWriter(w)
+
+	for i := 0; i < opts.Count; i++ {
+		var u string
+		if rng.Float64() < opts.SuspiciousRatio {
+			u = suspiciousURL(rng)
+			stats.Suspicious++
+		} else {
+			u = benignURL(rng)
+		}
+		if _, err := bw.WriteString(u + "\n"); err != nil {
+			return stats, err
+		}
+		stats.Total++
+	}
+
+	return stats, bw.Flush()
+}
+
+func pick(rng *rand.Rand, list []string) string {
+	return list[rng.Intn(len(list))]
+}
+
+func host(rng *rand.Rand) string {
+	return fmt.Sprintf("%s.%s.%s", pick(rng, subdomains), pick(rng, hostWords), pick(rng, tlds))
+}
+
+func benignURL(rng *rand.Rand) string {
+	var b strings.Builder
+	b.WriteString("https://")
+	b.WriteString(host(rng))
+
+	depth := 1 + rng.Intn(3)
+	for i := 0; i < depth; i++ {
+		b.WriteByte('/')
+		b.WriteString(pick(rng, pathWords))
+	}
+	if rng.Intn(3) == 0 {
+		fmt.Fprintf(&b, "-%d", rng.Intn(100000))
+	}
+	b.WriteString(pick(rng, imageExts))
+
+	if rng.Intn(4) == 0 {
+		fmt.Fprintf(&b, "?%s=%d", pick(rng, params), rng.Intn(100))
+	}
+	return b.String()
+}
+
+func suspiciousURL(rng *rand.Rand) string {
+	base := "https://" + host(rng)
+	switch rng.Intn(4) {
+	case 0:
+		return fmt.Sprintf("%s/%s?%s=%d", base, pick(rng, pathWords), pick(rng, suspicious.Keywords), rng.Intn(1000))
+	case 1:
+		return fmt.Sprintf("%s/%s/%s%s", base, pick(rng, pathWords), pick(rng, pathWords), pick(rng, suspicious.Extensions))
+	case 2:
+		return base + pick(rng, suspicious.Paths)
+	default:
+		return base + "/" + strings.TrimPrefix(pick(rng, suspicious.Hidden), "/")
+	}
+}
@@ -0,0 +1,48 @@
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+)
+
+// TestGenerateDeterministic ensures the same seed yields the same corpus
+func TestGenerateDeterministic(t *testing.T) {
+	opts := Options{Count: 500, SuspiciousRatio: 0.1, Seed: 42}
+	var a, b bytes.Buffer
+	if _, err := Generate(&a, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(&b, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("same seed produced different corpora")
+	}
+}
+
+// TestGenerateMatchesChecker verifies the suspicious count agrees with the checker
+func TestGenerateMatchesChecker(t *testing.T) {
+	var buf bytes.Buffer
+	stats, err := Generate(&buf, Options{Count: 300, SuspiciousRatio: 0.2, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 300 {
+		t.Errorf("Total = %d; want 300", stats.Total)
+	}
+
+	uc := checker.NewURLChecker("", "")
+	flagged := 0
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		if sus, _, _ := uc.IsSuspicious(scanner.Text()); sus {
+			flagged++
+		}
+	}
+	if flagged != stats.Suspicious {
+		t.Errorf("checker flagged %d URLs; generator reported %d suspicious", flagged, stats.Suspicious)
+	}
+}
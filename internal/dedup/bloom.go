@@ -0,0 +1,69 @@
+package dedup
+
+import (
+	"errors"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// Filter remembers keys well enough to tell whether one was added before
+type Filter interface {
+	// Add records key and reports whether it is new
+	Add(key string) bool
+}
+
+// Bloom is a Filter of fixed size. It may take a new key for one added
+// before, with about the false-positive rate it was sized for until more
+// keys than its capacity are added, and more often after; it never takes
+// a key added before for a new one. It is safe for concurrent use.
+type Bloom struct {
+	seeds [2]maphash.Seed
+	k     uint64 // Bits set per key
+	m     uint64 // Bits in total
+
+	mu   sync.Mutex
+	bits []uint64
+}
+
+// NewBloom returns an empty filter sized for n keys at false-positive rate
+// fp, which takes about -n·ln(fp)/ln(2)² bits
+func NewBloom(n uint64, fp float64) (*Bloom, error) {
+	if n == 0 {
+		return nil, errors.New("capacity must be positive")
+	}
+	if !(fp > 0 && fp < 1) {
+		return nil, errors.New("false-positive rate must be between 0 and 1")
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) &^ 63
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &Bloom{
+		seeds: [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+		k:     k,
+		m:     m,
+		bits:  make([]uint64, m/64),
+	}, nil
+}
+
+// Add records key and reports whether it is new. The k bit positions are
+// h1 + i·h2 for two independent hashes, which does as well as k hashes.
+func (b *Bloom) Add(key string) bool {
+	h1, h2 := maphash.String(b.seeds[0], key), maphash.String(b.seeds[1], key)|1
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	added := false
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if word, mask := bit/64, uint64(1)<<(bit%64); b.bits[word]&mask == 0 {
+			b.bits[word] |= mask
+			added = true
+		}
+	}
+	return added
+}
+
+// Size returns the memory the filter's bits take, in bytes
+func (b *Bloom) Size() int {
+	return len(b.bits) * 8
+}
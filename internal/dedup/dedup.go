@@ -0,0 +1,44 @@
+package dedup
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// Set remembers the keys added to it. It keeps a 128-bit hash of each key
+// rather than the key, so a key costs about 40 bytes however long it is,
+// and two keys are taken for one with a probability too small to matter.
+// It is safe for concurrent use.
+type Set struct {
+	seeds [2]maphash.Seed
+
+	mu   sync.Mutex
+	seen map[[2]uint64]struct{}
+}
+
+// NewSet returns an empty set
+func NewSet() *Set {
+	return &Set{
+		seeds: [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+		seen:  make(map[[2]uint64]struct{}),
+	}
+}
+
+// Add records key and reports whether it is new
+func (s *Set) Add(key string) bool {
+	h := [2]uint64{maphash.String(s.seeds[0], key), maphash.String(s.seeds[1], key)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[h]; ok {
+		return false
+	}
+	s.seen[h] = struct{}{}
+	return true
+}
+
+// Len returns the number of distinct keys added
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
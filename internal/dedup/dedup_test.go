@@ -0,0 +1,72 @@
+package dedup
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet()
+	if !s.Add("https://example.com/") || s.Add("https://example.com/") || !s.Add("https://example.com/a") {
+		t.Error("Add did not report new keys only")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				s.Add(fmt.Sprint(j))
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Len() != 1002 {
+		t.Errorf("Len = %d; want 1002", s.Len())
+	}
+}
+
+func TestBloom(t *testing.T) {
+	const n, fp = 10000, 0.01
+	b, err := NewBloom(n, fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		b.Add(fmt.Sprint("added-", i))
+	}
+	for i := 0; i < n; i++ {
+		if b.Add(fmt.Sprint("added-", i)) {
+			t.Fatalf("key %d added twice was new", i)
+		}
+	}
+	// Keys never added should be taken for old ones at about the rate
+	// the filter was sized for; each is forgotten again so the filter
+	// stays at capacity
+	full := append([]uint64(nil), b.bits...)
+	repeats := 0
+	for i := 0; i < n; i++ {
+		if !b.Add(fmt.Sprint("fresh-", i)) {
+			repeats++
+		}
+		copy(b.bits, full)
+	}
+	if rate := float64(repeats) / n; rate > 2*fp {
+		t.Errorf("false-positive rate %.4f; sized for %.4f", rate, fp)
+	}
+	if size := b.Size(); size > 2*n {
+		t.Errorf("Size = %d bytes for %d keys at %.2f", size, n, fp)
+	}
+
+	for _, bad := range []struct {
+		n  uint64
+		fp float64
+	}{{0, 0.01}, {n, 0}, {n, 1}, {n, math.NaN()}} {
+		if _, err := NewBloom(bad.n, bad.fp); err == nil {
+			t.Errorf("NewBloom(%d, %v) accepted", bad.n, bad.fp)
+		}
+	}
+}
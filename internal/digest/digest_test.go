@@ -0,0 +1,148 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// serveSMTP answers one SMTP session on l well enough for net/smtp, and
+// sends the message it receives on msgs
+func serveSMTP(l net.Listener, msgs chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+	var rcpts []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			fmt.Fprint(conn, "250-localhost\r\n250 8BITMIME\r\n")
+		case strings.HasPrefix(cmd, "RCPT"):
+			rcpts = append(rcpts, strings.TrimSpace(line))
+			fmt.Fprint(conn, "250 OK\r\n")
+		case strings.HasPrefix(cmd, "DATA"):
+			fmt.Fprint(conn, "354 Go ahead\r\n")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			fmt.Fprint(conn, "250 OK\r\n")
+			msgs <- fmt.Sprintf("%d recipients\r\n%s", len(rcpts), data.String())
+		case strings.HasPrefix(cmd, "QUIT"):
+			fmt.Fprint(conn, "221 Bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 OK\r\n")
+		}
+	}
+}
+
+// TestSend emails counts, the top findings and what is new since the
+// baseline, with every finding attached as CSV
+func TestSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msgs := make(chan string, 1)
+	go serveSMTP(l, msgs)
+
+	var dialer net.Dialer
+	d, err := New("smtp://"+l.Addr().String()+"?from=scanner@example.com&to=sec@example.com,ops@example.com", dialer.DialContext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Add(types.Result{URL: "https://example.com/db.sql", Category: "extensions", Severity: "high", RuleID: "ext-sql", Source: "urls.txt", Line: 2})
+	d.Add(types.Result{URL: "https://example.com/.git/config", Category: "paths", Severity: "critical", RuleID: "path-git", Source: "urls.txt", Line: 5})
+	stats := types.Stats{Processed: 10, Known: 3, Seconds: 1.5, Sources: []types.SourceStats{{Source: "urls.txt"}}}
+	if err := d.Send(context.Background(), stats, false, true); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := <-msgs
+	count, raw, _ := strings.Cut(raw, "\r\n")
+	if count != "2 recipients" {
+		t.Errorf("sent to %s; want 2 recipients", count)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "juicyurls: 2 findings in urls.txt (1 critical)" {
+		t.Errorf("subject = %q", subject)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	summary, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(summary) // NextPart decodes quoted-printable
+	for _, want := range []string{"URLs checked:  10", "Since the baseline: 2 new, 3 already known", "paths        1"} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("summary lacks %q:\n%s", want, text)
+		}
+	}
+	if strings.Index(string(text), ".git/config") > strings.Index(string(text), "db.sql") {
+		t.Errorf("summary does not list the critical finding first:\n%s", text)
+	}
+	attachment, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "findings.csv" {
+		t.Errorf("attachment name = %q", attachment.FileName())
+	}
+	csv, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	if lines := strings.Split(strings.TrimSpace(string(csv)), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "url,category,severity") {
+		t.Errorf("findings.csv =\n%s", csv)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, target := range []string{
+		"mail.example.com:587",
+		"http://mail.example.com?from=a@example.com&to=b@example.com",
+		"smtp://mail.example.com?to=b@example.com",
+		"smtp://mail.example.com?from=a@example.com&to=,",
+	} {
+		if _, err := New(target, nil, nil); err == nil {
+			t.Errorf("New(%q) accepted", target)
+		}
+	}
+	d, err := New("smtps://mail.example.com?from=a@example.com&to=b@example.com", nil, nil)
+	if err != nil || d.addr != "mail.example.com:465" {
+		t.Errorf("smtps default address = %v, %v; want port 465", d, err)
+	}
+}
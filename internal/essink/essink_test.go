@@ -0,0 +1,122 @@
+package essink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// cluster is a fake Elasticsearch keeping the indexed documents by ID
+type cluster struct {
+	mu      sync.Mutex
+	indices map[string]bool
+	docs    map[string]map[string]any
+	auth    string
+	reject  string // URL whose documents fail
+}
+
+func (c *cluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	user, pass, _ := r.BasicAuth()
+	c.auth = user + ":" + pass
+	switch {
+	case r.Method == http.MethodPut:
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if c.indices[name] {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":{"type":"resource_already_exists_exception","reason":"exists"}}`)
+			return
+		}
+		c.indices[name] = true
+		io.WriteString(w, `{"acknowledged":true}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+		var items []string
+		errors := false
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(sc.Bytes(), &action)
+			sc.Scan()
+			var doc map[string]any
+			json.Unmarshal(sc.Bytes(), &doc)
+			if doc["url"] == c.reject {
+				errors = true
+				items = append(items, `{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}`)
+				continue
+			}
+			c.docs[action["index"]["_index"]+"/"+action["index"]["_id"]] = doc
+			items = append(items, `{"index":{"status":201}}`)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"errors": errors, "items": json.RawMessage("[" + strings.Join(items, ",") + "]")})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func write(t *testing.T, s *Sink, results ...types.Result) error {
+	t.Helper()
+	in := make(chan types.Result, len(results))
+	for _, r := range results {
+		in <- r
+	}
+	close(in)
+	return s.Write(context.Background(), in)
+}
+
+// TestWrite indexes findings keyed by fingerprint, so a rescan replaces
+// them, and reports documents the cluster rejects
+func TestWrite(t *testing.T) {
+	c := &cluster{indices: map[string]bool{}, docs: map[string]map[string]any{}}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	target := strings.Replace(srv.URL, "http://", "http://elastic:secret@", 1) + "/recon"
+	s, err := Open(context.Background(), srv.Client(), target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.indices["recon"] || c.auth != "elastic:secret" {
+		t.Fatalf("indices = %v, auth %q", c.indices, c.auth)
+	}
+	// Opening again finds the index
+	if _, err := Open(context.Background(), srv.Client(), target); err != nil {
+		t.Fatal(err)
+	}
+
+	env := types.Result{URL: "https://API.example.com/.env", Category: "extensions", Severity: "medium", Fingerprint: "aaaa"}
+	sql := types.Result{URL: "https://example.com/db.sql", Category: "extensions", Severity: "high", Fingerprint: "bbbb"}
+	if err := write(t, s, env, sql); err != nil {
+		t.Fatal(err)
+	}
+	env.Severity = "high"
+	if err := write(t, s, env); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.docs) != 2 {
+		t.Fatalf("docs = %v; want 2", c.docs)
+	}
+	doc := c.docs["recon/aaaa"]
+	if doc["host"] != "api.example.com" || doc["category"] != "extensions" || doc["severity"] != "high" || doc["@timestamp"] == nil {
+		t.Errorf("doc = %v", doc)
+	}
+
+	c.reject = sql.URL
+	if err := write(t, s, env, sql); err == nil || !strings.Contains(err.Error(), "1 of 2 findings not indexed") {
+		t.Errorf("err = %v; want the rejected finding reported", err)
+	}
+
+	for _, bad := range []string{"es.internal:9200", "ftp://es/recon", "http://es/a/b"} {
+		if _, err := Open(context.Background(), srv.Client(), bad); err == nil {
+			t.Errorf("Open(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package extsort sorts findings that may not fit in memory. Findings are
+// held until their estimated size passes a limit, then sorted and spilled
+// to a temporary file as a run; at the end the runs are merged.
+package extsort
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"juicyurls/internal/types"
+)
+
+// DefaultLimit is the memory findings may take before a run is spilled
+const DefaultLimit = 256 << 20
+
+// MinRun is the least memory findings should take before they are spilled
+// early with Spill, so runs do not become too many to merge
+const MinRun = 4 << 20
+
+// MaxFanIn is the most runs merged at once, each holding a file open.
+// More are first merged into longer runs, MaxFanIn at a time.
+const MaxFanIn = 64
+
+// Compare orders findings by URL, then rule ID, then where they were read
+func Compare(a, b *types.Result) int {
+	return cmp.Or(
+		strings.Compare(a.URL, b.URL),
+		strings.Compare(a.RuleID, b.RuleID),
+		strings.Compare(a.Source, b.Source),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.SourceOffset, b.SourceOffset),
+	)
+}
+
+// Size estimates the memory r takes while held
+func Size(r *types.Result) int64 {
+	n := int(unsafe.Sizeof(*r)) + len(r.URL) + len(r.Category) + len(r.Reason) + len(r.Severity) +
+		len(r.Confidence) + len(r.RuleID) + len(r.RuleSource) + len(r.RulesHash) + len(r.Fingerprint) +
+		len(r.Pattern) + len(r.Component) + len(r.Source) + len(r.Context)
+	for _, run := range r.Runs {
+		n += int(unsafe.Sizeof(run)) + len(run)
+	}
+	return int64(n)
+}
+
+// Sorter collects findings and hands them back in Compare order
+type Sorter struct {
+	limit int64  // Held findings past this size are spilled
+	dir   string // Where runs are written; "" uses os.TempDir
+	held  []types.Result
+	size  int64
+	runs  []string // Paths of the runs, closed until they are merged
+}
+
+// New returns a Sorter that holds up to limit bytes of findings in memory
+// and spills the rest to runs in dir
+func New(limit int64, dir string) *Sorter {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Sorter{limit: limit, dir: dir}
+}
+
+// Add collects a finding, spilling a run first if the held findings have
+// reached the limit
+func (s *Sorter) Add(r types.Result) error {
+	if s.size >= s.limit {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+	s.held = append(s.held, r)
+	s.size += Size(&r)
+	return nil
+}
+
+// Held returns the estimated memory of the findings not yet spilled
+func (s *Sorter) Held() int64 {
+	return s.size
+}
+
+// Spill writes the findings held to a run now, as when memory runs short,
+// rather than once they reach the limit
+func (s *Sorter) Spill() error {
+	if len(s.held) == 0 {
+		return nil
+	}
+	return s.spill()
+}
+
+// Runs returns the number of runs spilled so far
+func (s *Sorter) Runs() int {
+	return len(s.runs)
+}
+
+// spill sorts the held findings and writes them to a new run
+func (s *Sorter) spill() error {
+	s.sort()
+	err := s.write(func(add func(*types.Result) error) error {
+		for i := range s.held {
+			if err := add(&s.held[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	clear(s.held)
+	s.held, s.size = s.held[:0], 0
+	return err
+}
+
+// write adds a run holding the findings fill adds, in order
+func (s *Sorter) write(fill func(add func(*types.Result) error) error) error {
+	f, err := os.CreateTemp(s.dir, "juicyurls-sort-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name()) // Removed by Close, even if writing fails
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	if err := fill(func(r *types.Result) error { return enc.Encode(r) }); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sort orders the held findings, by index as they are large to copy
+func (s *Sorter) sort() {
+	sort.Slice(s.held, func(i, j int) bool { return Compare(&s.held[i], &s.held[j]) < 0 })
+}
+
+// Each calls fn with every finding added, in Compare order, until fn
+// returns an error
+func (s *Sorter) Each(fn func(types.Result) error) error {
+	s.sort()
+	if len(s.runs) == 0 {
+		for _, r := range s.held {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Past MaxFanIn, the oldest runs are merged into longer ones until
+	// the rest can be merged at once with the findings still held
+	for len(s.runs) >= MaxFanIn {
+		batch := s.runs[:MaxFanIn]
+		err := s.write(func(add func(*types.Result) error) error {
+			return merge(batch, nil, func(r *types.Result) error { return add(r) })
+		})
+		if err != nil {
+			return err
+		}
+		for _, path := range batch {
+			os.Remove(path)
+		}
+		s.runs = s.runs[MaxFanIn:]
+	}
+	return merge(s.runs, s.held, func(r *types.Result) error { return fn(*r) })
+}
+
+// merge calls fn with the findings of the runs at paths and of held, which
+// are all sorted, in Compare order
+func merge(paths []string, held []types.Result, fn func(*types.Result) error) error {
+	var h merger
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dec := gob.NewDecoder(bufio.NewReader(f))
+		h.add(func(r *types.Result) error {
+			*r = types.Result{} // gob leaves zero fields as they were
+			return dec.Decode(r)
+		})
+	}
+	h.add(func(r *types.Result) error {
+		if len(held) == 0 {
+			return io.EOF
+		}
+		*r, held = held[0], held[1:]
+		return nil
+	})
+	if h.err != nil {
+		return h.err
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		top := h.sources[0]
+		if err := fn(&top.head); err != nil {
+			return err
+		}
+		if err := top.next(&top.head); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return nil
+}
+
+// Close removes the runs
+func (s *Sorter) Close() error {
+	var err error
+	for _, path := range s.runs {
+		if rerr := os.Remove(path); err == nil {
+			err = rerr
+		}
+	}
+	s.runs, s.held = nil, nil
+	return err
+}
+
+// source is one sorted stream being merged, with its next finding
+type source struct {
+	head types.Result
+	next func(*types.Result) error
+}
+
+// merger is a heap of sources ordered by their next finding
+type merger struct {
+	sources []*source
+	err     error
+}
+
+// add reads a stream's first finding and adds the stream unless it is
+// empty
+func (h *merger) add(next func(*types.Result) error) {
+	src := &source{next: next}
+	switch err := next(&src.head); {
+	case err == nil:
+		h.sources = append(h.sources, src)
+	case !errors.Is(err, io.EOF) && h.err == nil:
+		h.err = err
+	}
+}
+
+func (h *merger) Len() int           { return len(h.sources) }
+func (h *merger) Less(i, j int) bool { return Compare(&h.sources[i].head, &h.sources[j].head) < 0 }
+func (h *merger) Swap(i, j int)      { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+func (h *merger) Push(x any)         { h.sources = append(h.sources, x.(*source)) }
+func (h *merger) Pop() any {
+	last := h.sources[len(h.sources)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return last
+}
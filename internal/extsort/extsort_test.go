@@ -0,0 +1,143 @@
+package extsort
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestSorter merges spilled runs with the findings still held into one
+// sorted stream, and removes the runs when closed
+func TestSorter(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var want []types.Result
+	for i := 0; i < 5000; i++ {
+		r := types.Result{
+			URL:    fmt.Sprintf("https://example.com/%d", rng.Intn(1000)),
+			RuleID: fmt.Sprintf("paths:%d", rng.Intn(3)),
+			Source: "urls.txt",
+		}
+		if i%2 == 0 {
+			r.Line, r.Pattern = i+1, "/admin" // Zero fields must not inherit these
+		}
+		want = append(want, r)
+	}
+
+	dir := t.TempDir()
+	s := New(64<<10, dir)
+	for _, r := range want {
+		if err := s.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Runs() < 2 {
+		t.Fatalf("spilled %d runs; want several", s.Runs())
+	}
+	// An early spill takes what is held, however little; with nothing
+	// held it writes no run
+	runs := s.Runs()
+	for range 2 {
+		if err := s.Spill(); err != nil || s.Runs() != runs+1 || s.Held() != 0 {
+			t.Fatalf("Spill: %v, %d runs holding %d bytes; want %d runs holding none", err, s.Runs(), s.Held(), runs+1)
+		}
+	}
+	var got []types.Result
+	if err := s.Each(func(r types.Result) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	slices.SortStableFunc(want, func(a, b types.Result) int { return Compare(&a, &b) })
+	if len(got) != len(want) {
+		t.Fatalf("got %d findings; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !slices.Equal([]any{got[i].URL, got[i].RuleID, got[i].Line, got[i].Pattern}, []any{want[i].URL, want[i].RuleID, want[i].Line, want[i].Pattern}) {
+			t.Fatalf("finding %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("%d runs left after Close", len(left))
+	}
+}
+
+// openRuns counts the files under dir this process has open, or -1 where
+// /proc is not there to tell
+func openRuns(dir string) int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	n := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink("/proc/self/fd/" + fd.Name()); err == nil && strings.HasPrefix(target, dir) {
+			n++
+		}
+	}
+	return n
+}
+
+// TestSorterFanIn merges more runs than MaxFanIn in passes, never holding
+// more than MaxFanIn of them open
+func TestSorterFanIn(t *testing.T) {
+	dir := t.TempDir()
+	s := New(1, dir) // Every finding after the first spills a run
+	defer s.Close()
+	const n = 5 * MaxFanIn
+	for i := range n {
+		s.Add(types.Result{URL: fmt.Sprintf("https://example.com/%05d", (i*7919)%n)})
+	}
+	if s.Runs() < 4*MaxFanIn {
+		t.Fatalf("spilled %d runs; want more than %d", s.Runs(), 4*MaxFanIn)
+	}
+	if open := openRuns(dir); open > 0 {
+		t.Fatalf("%d runs open after spilling; want them closed", open)
+	}
+
+	i, most := 0, 0
+	err := s.Each(func(r types.Result) error {
+		if want := fmt.Sprintf("https://example.com/%05d", i); r.URL != want {
+			return fmt.Errorf("finding %d = %s; want %s", i, r.URL, want)
+		}
+		most = max(most, openRuns(dir))
+		i++
+		return nil
+	})
+	if err != nil || i != n {
+		t.Fatalf("merged %d findings: %v; want %d in order", i, err, n)
+	}
+	if most > MaxFanIn {
+		t.Errorf("%d runs open at once; want at most %d", most, MaxFanIn)
+	}
+	if left, _ := os.ReadDir(dir); len(left) >= MaxFanIn {
+		t.Errorf("%d runs on disk after merging; want fewer than %d", len(left), MaxFanIn)
+	}
+}
+
+// TestSorterInMemory sorts without spilling while under the limit
+func TestSorterInMemory(t *testing.T) {
+	dir := t.TempDir()
+	s := New(0, dir)
+	defer s.Close()
+	for _, u := range []string{"https://b.example/", "https://a.example/", "https://c.example/"} {
+		s.Add(types.Result{URL: u})
+	}
+	var got []string
+	s.Each(func(r types.Result) error {
+		got = append(got, r.URL)
+		return nil
+	})
+	if want := []string{"https://a.example/", "https://b.example/", "https://c.example/"}; !slices.Equal(got, want) || s.Runs() != 0 {
+		t.Errorf("got %v with %d runs; want %v in memory", got, s.Runs(), want)
+	}
+}
@@ -0,0 +1,50 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"juicyurls/internal/urlnorm"
+)
+
+// Compute returns a stable fingerprint for a finding: a hash of the
+// normalized URL and the ID of the rule that matched. It does not depend
+// on input order, worker count or run, so it can key dedup across scans.
+func Compute(rawURL, ruleID string) string {
+	sum := sha256.Sum256([]byte(Normalize(rawURL) + "\x00" + ruleID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Normalize canonicalizes a URL for fingerprinting: lower-cased scheme and
+// host, default ports and fragments dropped, query parameters sorted.
+// Windows paths become file URLs. Unparseable input is returned trimmed.
+func Normalize(rawURL string) string {
+	raw, _ := urlnorm.FromWindows(strings.TrimSpace(rawURL))
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if u.RawQuery != "" {
+		// Encode sorts by key and keeps the order of repeated values
+		u.RawQuery = u.Query().Encode()
+	}
+	return u.String()
+}
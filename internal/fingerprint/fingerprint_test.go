@@ -0,0 +1,32 @@
+package fingerprint
+
+import "testing"
+
+// TestNormalize covers the canonicalization steps
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"HTTPS://Example.COM:443/a?b=2&a=1#frag": "https://example.com/a?a=1&b=2",
+		"http://example.com:8080":                "http://example.com:8080/",
+		"  not a url  ":                          "not a url",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+// TestCompute ensures equivalent URLs share a fingerprint per rule
+func TestCompute(t *testing.T) {
+	a := Compute("https://example.com/.env?x=1&y=2", "hidden:dotenv")
+	b := Compute("HTTPS://EXAMPLE.com:443/.env?y=2&x=1#top", "hidden:dotenv")
+	if a != b {
+		t.Errorf("equivalent URLs: %s != %s", a, b)
+	}
+	if c := Compute("https://example.com/.env?x=1&y=2", "extensions:.env"); c == a {
+		t.Error("different rules share a fingerprint")
+	}
+	if len(a) != 16 {
+		t.Errorf("fingerprint %q has length %d; want 16", a, len(a))
+	}
+}
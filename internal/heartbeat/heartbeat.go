@@ -0,0 +1,44 @@
+package heartbeat
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Snapshot holds the counters reported on each beat
+type Snapshot struct {
+	Consumed   uint64 // URLs read from the input so far
+	Emitted    uint64 // Findings written so far
+	QueueDepth int    // URLs waiting for a worker
+}
+
+// Run logs a heartbeat every interval until ctx is done, so a long-running
+// instance that stalls is visible in the logs rather than silent.
+func Run(ctx context.Context, logger *slog.Logger, interval time.Duration, snapshot func() Snapshot) {
+	if interval <= 0 {
+		return
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s := snapshot()
+			logger.Info("heartbeat",
+				"uptime", time.Since(start).Round(time.Second).String(),
+				"urls_consumed", s.Consumed,
+				"findings_emitted", s.Emitted,
+				"queue_depth", s.QueueDepth,
+			)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// beats passes each log record on as it is written
+type beats chan []byte
+
+func (b beats) Write(p []byte) (int, error) {
+	b <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+// TestRun logs the snapshot taken on each beat, and stops with ctx
+func TestRun(t *testing.T) {
+	out := make(beats, 10)
+	logger := slog.New(slog.NewJSONHandler(out, nil))
+	var calls atomic.Uint64
+	snapshot := func() Snapshot {
+		n := calls.Add(1)
+		return Snapshot{Consumed: 10 * n, Emitted: n, QueueDepth: 3}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, logger, time.Millisecond, snapshot)
+		close(done)
+	}()
+
+	for want := uint64(1); want <= 2; want++ {
+		var beat struct {
+			Msg      string `json:"msg"`
+			Uptime   string `json:"uptime"`
+			Consumed uint64 `json:"urls_consumed"`
+			Emitted  uint64 `json:"findings_emitted"`
+			Queue    int    `json:"queue_depth"`
+		}
+		select {
+		case line := <-out:
+			if err := json.Unmarshal(line, &beat); err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no heartbeat")
+		}
+		if beat.Msg != "heartbeat" || beat.Uptime == "" || beat.Consumed != 10*want || beat.Emitted != want || beat.Queue != 3 {
+			t.Errorf("beat %d = %+v", want, beat)
+		}
+	}
+
+	cancel()
+	for {
+		select {
+		case <-out:
+			continue // Beats logged before the cancel was seen
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after cancel")
+		}
+		break
+	}
+}
+
+// TestRunOff returns at once for an interval of 0, without a snapshot
+func TestRunOff(t *testing.T) {
+	Run(context.Background(), nil, 0, func() Snapshot {
+		t.Error("snapshot taken with heartbeats off")
+		return Snapshot{}
+	})
+}
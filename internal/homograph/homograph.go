@@ -0,0 +1,114 @@
+package homograph
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Brands are the names lookalike hosts are compared against by default.
+// Scope domains can be checked too by passing them to Lookalike.
+var Brands = []string{
+	"adobe", "airbnb", "amazon", "apple", "binance", "booking", "coinbase",
+	"dropbox", "ebay", "facebook", "github", "gitlab", "google", "icloud",
+	"instagram", "linkedin", "microsoft", "netflix", "office", "okta",
+	"outlook", "paypal", "slack", "spotify", "stripe", "twitter", "whatsapp",
+	"yahoo", "youtube", "zoom",
+}
+
+// confusables maps letters that render like ASCII to that ASCII letter
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y',
+	'х': 'x', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l', 'ɡ': 'g',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin lookalikes
+	'ı': 'i', 'ȷ': 'j', 'ℓ': 'l', 'ß': 'b',
+}
+
+// Decode returns the Unicode form of a host and whether it has non-ASCII
+// letters, written either as punycode (xn--) labels or as raw Unicode
+func Decode(host string) (string, bool) {
+	host = strings.ToLower(host)
+	if !strings.Contains(host, "xn--") {
+		return host, !isASCII(host)
+	}
+	u, err := idna.ToUnicode(host)
+	if err != nil {
+		return host, false
+	}
+	return u, !isASCII(u)
+}
+
+// MixedScript reports whether a label of a Unicode host mixes Latin
+// letters with Cyrillic or Greek ones
+func MixedScript(host string) bool {
+	for _, label := range strings.Split(host, ".") {
+		var latin, other bool
+		for _, r := range label {
+			switch {
+			case r < utf8.RuneSelf:
+				latin = latin || unicode.IsLetter(r)
+			case unicode.In(r, unicode.Cyrillic, unicode.Greek):
+				other = true
+			}
+		}
+		if latin && other {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookalike returns the entry of targets that a Unicode host imitates.
+// Targets without a dot are brand names compared with each label; others
+// are domains the whole host or one of its parents must render like.
+func Lookalike(host string, targets []string) (string, bool) {
+	if isASCII(host) {
+		return "", false
+	}
+	skel := Skeleton(host)
+	labels := strings.Split(skel, ".")
+	for _, t := range targets {
+		t = strings.ToLower(t)
+		if strings.Contains(t, ".") {
+			if skel == t || strings.HasSuffix(skel, "."+t) {
+				return t, true
+			}
+			continue
+		}
+		for _, l := range labels {
+			if l == t {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Skeleton lower-cases a host and replaces confusable letters with the
+// ASCII letters they look like
+func Skeleton(host string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(host) {
+		if a, ok := confusables[r]; ok {
+			r = a
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package homograph
+
+import "testing"
+
+// TestDetect decodes punycode hosts and finds what they imitate
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		host      string
+		unicode   bool
+		mixed     bool
+		lookalike string
+	}{
+		{"xn--pypal-4ve.com", true, true, "paypal"},     // pаypal.com, Cyrillic а
+		{"xn--80ak6aa92e.com", true, false, "apple"},    // аррӏе.com, all Cyrillic
+		{"www.xn--ggle-55da.com", true, true, "google"}, // gооgle.com
+		{"xn--exmple-cua.com", true, false, ""},         // exämple.com
+		{"xn--exmple-4nf.org", true, true, "example.org"},
+		{"paypal.com", false, false, ""},
+		{"xn--invalid-.com", false, false, ""},
+	}
+	targets := append([]string{"example.org"}, Brands...)
+	for _, tt := range tests {
+		u, isUnicode := Decode(tt.host)
+		if isUnicode != tt.unicode {
+			t.Errorf("Decode(%q) = %q, %v; want unicode %v", tt.host, u, isUnicode, tt.unicode)
+			continue
+		}
+		if got := MixedScript(u); got != tt.mixed {
+			t.Errorf("MixedScript(%q) = %v; want %v", u, got, tt.mixed)
+		}
+		if got, _ := Lookalike(u, targets); got != tt.lookalike {
+			t.Errorf("Lookalike(%q) = %q; want %q", u, got, tt.lookalike)
+		}
+	}
+}
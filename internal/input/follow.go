@@ -0,0 +1,54 @@
+package input
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// FollowPoll is how often a followed file is checked for new data
+const FollowPoll = 250 * time.Millisecond
+
+// follower reads a file like tail -f
+type follower struct {
+	ctx context.Context
+	f   *os.File
+	pos int64
+}
+
+// Follow reads f from its current offset and, at the end, waits for more
+// data instead of returning io.EOF, as tail -f does. It returns io.EOF once
+// ctx ends. A file truncated in place is read again from the start.
+func Follow(ctx context.Context, f *os.File) (io.Reader, error) {
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return &follower{ctx: ctx, f: f, pos: pos}, nil
+}
+
+func (r *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		r.pos += int64(n)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+
+		if fi, err := r.f.Stat(); err == nil && fi.Size() < r.pos {
+			if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+			r.pos = 0
+			continue
+		}
+		timer := time.NewTimer(FollowPoll)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return 0, io.EOF
+		case <-timer.C:
+		}
+	}
+}
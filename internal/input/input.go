@@ -0,0 +1,71 @@
+package input
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Input formats
+const (
+	FormatURLs     = "urls"     // One URL per line
+	FormatHostPort = "hostport" // host:port lines from port scanners
+)
+
+// Formats lists the supported input formats
+var Formats = []string{FormatURLs, FormatHostPort}
+
+// CheckFormat returns an error naming the supported formats if f is not one
+func CheckFormat(f string) error {
+	for _, v := range Formats {
+		if f == "" || f == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown input format %q (want one of %s)", f, strings.Join(Formats, ", "))
+}
+
+// webPorts maps well-known web ports to the scheme served on them
+var webPorts = map[int]string{
+	80: "http", 81: "http", 591: "http", 3000: "http", 5000: "http", 8000: "http",
+	8008: "http", 8080: "http", 8081: "http", 8088: "http", 8888: "http", 9000: "http",
+	443: "https", 4443: "https", 8443: "https", 9443: "https",
+}
+
+// HostPort turns a port scanner line into the URL to check, and reports
+// false for lines that are not a web port. It accepts host:port and
+// [v6]:port as written by naabu, and masscan's list output
+// ("open tcp 443 10.0.0.1 1700000000").
+func HostPort(line string) (string, bool) {
+	host, portStr, ok := splitHostPort(strings.TrimSpace(line))
+	if !ok {
+		return "", false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", false
+	}
+	scheme, ok := webPorts[port]
+	if !ok {
+		return "", false
+	}
+	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		return scheme + "://" + host + "/", true
+	}
+	return scheme + "://" + net.JoinHostPort(host, portStr) + "/", true
+}
+
+func splitHostPort(line string) (host, port string, ok bool) {
+	if f := strings.Fields(line); len(f) >= 4 && f[0] == "open" {
+		return f[3], f[2], true
+	}
+	host, port, err := net.SplitHostPort(line)
+	if err != nil || host == "" {
+		return "", "", false
+	}
+	return host, port, true
+}
@@ -0,0 +1,204 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHostPort maps web ports to URLs and skips everything else
+func TestHostPort(t *testing.T) {
+	tests := []struct {
+		line, want string
+		ok         bool
+	}{
+		{"example.com:80", "http://example.com/", true},
+		{"example.com:443", "https://example.com/", true},
+		{"10.0.0.5:8443", "https://10.0.0.5:8443/", true},
+		{"[2001:db8::1]:8080", "http://[2001:db8::1]:8080/", true},
+		{"[2001:db8::1]:443", "https://[2001:db8::1]/", true},
+		{"open tcp 8080 10.0.0.1 1700000000", "http://10.0.0.1:8080/", true},
+		{"example.com:22", "", false},
+		{"example.com", "", false},
+		{"https://example.com/", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := HostPort(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("HostPort(%q) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestExtractURLs finds URLs in log messages without trailing punctuation
+func TestExtractURLs(t *testing.T) {
+	line := `<14>Oct 15 10:00:00 web nginx: GET "https://a.example/.env" from (see http://b.example/x?y=1).`
+	got := ExtractURLs(line)
+	want := []string{"https://a.example/.env", "http://b.example/x?y=1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExtractURLs = %q, want %q", got, want)
+	}
+
+	loc := FindURLs(line)[1]
+	if got := Context(line, loc[0], loc[1], 5); got != "(see http://b.example/x?y=1)." {
+		t.Errorf("Context = %q", got)
+	}
+	if got := Context("é\tab\u202ehttps://x.example/\n", 5, 24, 80); got != "é ab https://x.example/" {
+		t.Errorf("Context = %q; want controls as spaces", got)
+	}
+}
+
+// TestSyslog receives newline-delimited and octet-counted TCP messages
+// and UDP datagrams
+func TestSyslog(t *testing.T) {
+	for _, network := range []string{"udp", "tcp"} {
+		s, err := Syslog(network + "://127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		lines := make(chan string, 4)
+		done := make(chan error, 1)
+		go func() {
+			done <- s.Read(ctx, func(line string) bool {
+				lines <- line
+				return true
+			})
+		}()
+
+		conn, err := net.Dial(network, strings.TrimPrefix(s.Name, "syslog:"+network+"://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs := []string{"<14>1 - web app - - - first", "<14>1 - web app - - - second"}
+		if network == "udp" {
+			conn.Write([]byte(msgs[0]))
+			conn.Write([]byte(msgs[1] + "\n"))
+		} else {
+			fmt.Fprintf(conn, "%s\n%d %s", msgs[0], len(msgs[1]), msgs[1])
+		}
+		conn.Close()
+
+		for _, want := range msgs {
+			select {
+			case got := <-lines:
+				if got != want {
+					t.Errorf("%s: got %q, want %q", network, got, want)
+				}
+			case <-ctx.Done():
+				t.Fatalf("%s: timed out waiting for %q", network, want)
+			}
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("%s: Read = %v", network, err)
+		}
+	}
+
+	if _, err := Syslog("unix:///tmp/x"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+}
+
+// TestLines splits lines as bufio.Scanner does, across chunks
+func TestLines(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 3*linesChunk; i++ {
+		fmt.Fprintf(&b, "https://example.com/%d/%s\r\n\n", i, strings.Repeat("a", i%300))
+	}
+	b.WriteString(strings.Repeat("b", linesChunk+10) + "\nhttps://example.com/last")
+	data := b.String()
+
+	var want []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, 4*linesChunk)
+	for scanner.Scan() {
+		want = append(want, scanner.Text())
+	}
+	var got []string
+	total := 0
+	if err := Lines([]byte(data), 4*linesChunk, func(line string, size int) bool {
+		got = append(got, line)
+		total += size
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Lines gave %d lines, bufio.Scanner %d", len(got), len(want))
+	}
+	if total != len(data) {
+		t.Errorf("line sizes add up to %d; want %d", total, len(data))
+	}
+
+	got = got[:0]
+	err := Lines([]byte("one\ntwo\n"+strings.Repeat("c", 2*linesChunk)+"\nthree\n"), linesChunk, func(line string, size int) bool {
+		got = append(got, line)
+		return true
+	})
+	if !errors.Is(err, bufio.ErrTooLong) || !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("over-long line: %q, %v; want the lines before it and ErrTooLong", got, err)
+	}
+}
+
+// TestChunks cuts data on line ends only, keeping every byte
+func TestChunks(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "https://example.com/%s\n", strings.Repeat("a", i%37))
+	}
+	b.WriteString("https://example.com/last")
+	data := []byte(b.String())
+	for _, n := range []int{0, 1, 2, 7, 64, 5000} {
+		chunks := Chunks(data, n)
+		if len(chunks) > max(n, 1) {
+			t.Errorf("Chunks(%d) made %d ranges", n, len(chunks))
+		}
+		if joined := bytes.Join(chunks, nil); !bytes.Equal(joined, data) {
+			t.Errorf("Chunks(%d) lost or reordered bytes", n)
+		}
+		for i, c := range chunks[:len(chunks)-1] {
+			if c[len(c)-1] != '\n' {
+				t.Errorf("Chunks(%d): range %d ends inside a line", n, i)
+			}
+		}
+	}
+	if chunks := Chunks(nil, 4); len(chunks) != 0 {
+		t.Errorf("Chunks(nil) = %d ranges; want none", len(chunks))
+	}
+}
+
+func TestMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	want := "https://example.com/.env\nhttps://example.com/db.sql\n"
+	if err := os.WriteFile(path, []byte(want), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, unmap, err := Map(f)
+	f.Close()
+	if errors.Is(err, ErrNoMap) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("mapped %q; want %q", data, want)
+	}
+	if err := unmap(); err != nil {
+		t.Error(err)
+	}
+}
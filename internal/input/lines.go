@@ -0,0 +1,72 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// ErrNoMap is returned by Map for files that cannot be memory-mapped
+var ErrNoMap = errors.New("file cannot be memory-mapped")
+
+// linesChunk is how much of the data Lines turns into a string at once
+const linesChunk = 64 << 10
+
+// Lines calls fn with each line of data without its line ending, as a
+// bufio.Scanner splitting lines would, and the bytes the line took with
+// its ending, until fn returns false. The data is
+// copied into strings a chunk of lines at a time, so a line costs no
+// allocation of its own; a line kept after fn returns keeps its chunk in
+// memory, so long-lived copies should be cloned. A line longer than max
+// fails with bufio.ErrTooLong, after the lines before it.
+func Lines(data []byte, max int, fn func(line string, size int) bool) error {
+	for len(data) > 0 {
+		n := len(data)
+		if n > linesChunk {
+			// End the chunk after its last whole line, or stretch it
+			// to the end of a line longer than a chunk
+			if i := bytes.LastIndexByte(data[:linesChunk], '\n'); i >= 0 {
+				n = i + 1
+			} else if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				n = i + 1
+			}
+			if n > max {
+				return bufio.ErrTooLong
+			}
+		}
+		chunk := string(data[:n])
+		data = data[n:]
+		for chunk != "" {
+			line, rest, _ := strings.Cut(chunk, "\n")
+			if len(line) >= max {
+				return bufio.ErrTooLong
+			}
+			if !fn(strings.TrimSuffix(line, "\r"), len(chunk)-len(rest)) {
+				return nil
+			}
+			chunk = rest
+		}
+	}
+	return nil
+}
+
+// Chunks cuts data into at most n ranges of about equal size, each but
+// the last ending after a newline, so the ranges can be read with Lines
+// side by side. A line is never split between ranges.
+func Chunks(data []byte, n int) [][]byte {
+	n = max(n, 1)
+	size := (len(data) + n - 1) / n
+	var out [][]byte
+	for len(data) > 0 {
+		end := len(data)
+		if size < len(data) {
+			if i := bytes.IndexByte(data[size-1:], '\n'); i >= 0 {
+				end = size + i
+			}
+		}
+		out = append(out, data[:end])
+		data = data[end:]
+	}
+	return out
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package input
+
+import "os"
+
+// Map is unavailable here; files are read instead
+func Map(*os.File) ([]byte, func() error, error) {
+	return nil, nil, ErrNoMap
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package input
+
+import (
+	"os"
+	"syscall"
+)
+
+// Map maps the contents of f into memory, read-only. The mapping outlives
+// f being closed, until unmap is called; the file must not be truncated
+// meanwhile. An empty file maps to nil.
+func Map(f *os.File) (data []byte, unmap func() error, err error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if !fi.Mode().IsRegular() || int64(int(size)) != size {
+		return nil, nil, ErrNoMap
+	}
+	if size == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
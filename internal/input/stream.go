@@ -0,0 +1,240 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"juicyurls/internal/urlnorm"
+)
+
+// Stream is a live source of log lines, read until its context ends
+type Stream struct {
+	Name string // Reported as the findings' source
+	// Read calls emit with each line until ctx ends, the source closes or
+	// emit returns false
+	Read func(ctx context.Context, emit func(line string) bool) error
+}
+
+// urlPattern finds http(s) URLs in free text such as log messages
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s"'<>\x60]+`)
+
+// ExtractURLs returns the http(s) URLs in a log line, without the
+// punctuation that usually follows a URL in prose
+func ExtractURLs(line string) []string {
+	var found []string
+	for _, loc := range FindURLs(line) {
+		found = append(found, line[loc[0]:loc[1]])
+	}
+	return found
+}
+
+// FindURLs returns where the http(s) URLs in free text start and end, as
+// ExtractURLs finds them
+func FindURLs(text string) [][2]int {
+	var found [][2]int
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		u := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)]}")
+		found = append(found, [2]int{loc[0], loc[0] + len(u)})
+	}
+	return found
+}
+
+// Context returns text[start:end] with up to n characters of the text on
+// either side, on one line: control and bidi characters become spaces
+func Context(text string, start, end, n int) string {
+	for i := 0; i < n && start > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	for i := 0; i < n && end < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if urlnorm.Hidden(r) {
+			return ' '
+		}
+		return r
+	}, text[start:end]))
+}
+
+// maxMessage bounds a syslog message; larger datagrams are truncated
+const maxMessage = 64 * 1024
+
+// Syslog listens on addr as a syslog receiver. The address is
+// udp://host:port (the default when no scheme is given) or tcp://host:port;
+// TCP accepts both newline-delimited and octet-counted framing (RFC 6587).
+// The listener is bound before Syslog returns, so address errors surface
+// immediately, and the stream's name carries the bound address.
+func Syslog(addr string) (Stream, error) {
+	network, hostPort, ok := strings.Cut(addr, "://")
+	if !ok {
+		network, hostPort = "udp", addr
+	}
+	name := func(a net.Addr) string { return "syslog:" + network + "://" + a.String() }
+	switch network {
+	case "udp":
+		conn, err := net.ListenPacket("udp", hostPort)
+		if err != nil {
+			return Stream{}, err
+		}
+		return Stream{Name: name(conn.LocalAddr()), Read: func(ctx context.Context, emit func(string) bool) error {
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+			defer conn.Close()
+			buf := make([]byte, maxMessage)
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				for _, line := range strings.Split(strings.TrimRight(string(buf[:n]), "\r\n"), "\n") {
+					if !emit(line) {
+						return nil
+					}
+				}
+			}
+		}}, nil
+	case "tcp":
+		ln, err := net.Listen("tcp", hostPort)
+		if err != nil {
+			return Stream{}, err
+		}
+		return Stream{Name: name(ln.Addr()), Read: func(ctx context.Context, emit func(string) bool) error {
+			var conns sync.WaitGroup
+			defer conns.Wait()
+			ctx, cancel := context.WithCancel(ctx) // Ends open connections on return
+			defer cancel()
+			stop := context.AfterFunc(ctx, func() { ln.Close() })
+			defer stop()
+			defer ln.Close()
+
+			var mu sync.Mutex // emit is called from one connection at a time
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				conns.Add(1)
+				go func() {
+					defer conns.Done()
+					stop := context.AfterFunc(ctx, func() { conn.Close() })
+					defer stop()
+					defer conn.Close()
+					readFrames(bufio.NewReader(conn), func(line string) bool {
+						mu.Lock()
+						defer mu.Unlock()
+						if !emit(line) {
+							cancel()
+							return false
+						}
+						return true
+					})
+				}()
+			}
+		}}, nil
+	}
+	return Stream{}, fmt.Errorf("unknown syslog network %q (want udp or tcp)", network)
+}
+
+// readFrames reads syslog messages from a TCP stream. A message starting
+// with a digit is octet-counted ("<len> <msg>"); any other is
+// newline-terminated.
+func readFrames(r *bufio.Reader, emit func(string) bool) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		var msg string
+		if b[0] >= '0' && b[0] <= '9' {
+			lenStr, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+			if err != nil || n < 0 || n > maxMessage {
+				return // Framing is lost
+			}
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			msg = string(buf)
+		} else {
+			line, err := r.ReadString('\n')
+			if line == "" && err != nil {
+				return
+			}
+			msg = line
+		}
+		if !emit(strings.TrimRight(msg, "\r\n")) {
+			return
+		}
+	}
+}
+
+// Journal follows the systemd journal through journalctl, starting with
+// new entries. With no units, every unit's messages are read.
+func Journal(units []string) Stream {
+	name := "journal"
+	if len(units) > 0 {
+		name += ":" + strings.Join(units, ",")
+	}
+	return Stream{Name: name, Read: func(ctx context.Context, emit func(string) bool) error {
+		args := []string{"--follow", "--lines=0", "--output=cat"}
+		for _, u := range units {
+			args = append(args, "--unit="+u)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "journalctl", args...)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(out)
+		scanner.Buffer(make([]byte, maxMessage), maxMessage)
+		stopped := false
+		for scanner.Scan() {
+			if !emit(scanner.Text()) {
+				stopped = true
+				break
+			}
+		}
+		stopped = stopped || ctx.Err() != nil
+		cancel()
+		err = cmd.Wait()
+		if stopped {
+			return nil
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("journalctl: %s", msg)
+		}
+		if err == nil {
+			err = errors.New("journalctl exited")
+		}
+		return err
+	}}
+}
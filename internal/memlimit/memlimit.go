@@ -0,0 +1,139 @@
+// Package memlimit keeps a scan under a memory limit on constrained
+// machines, such as CI runners, by telling the scan when the heap nears the
+// limit so it can read less ahead and spill what it holds.
+package memlimit
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// High is the share of the limit past which memory is under pressure
+	High = 0.8
+	// Interval is how often memory use is sampled
+	Interval = 50 * time.Millisecond
+)
+
+// Samples read for memory use, as the Go runtime counts it against its own
+// limit: everything it has mapped, less what it gave back to the OS
+var samples = []metrics.Sample{
+	{Name: "/memory/classes/total:bytes"},
+	{Name: "/memory/classes/heap/released:bytes"},
+}
+
+// units are the suffixes ParseSize accepts, binary like Kubernetes and
+// docker --memory
+var units = []struct {
+	suffix string
+	shift  uint
+}{{"T", 40}, {"G", 30}, {"M", 20}, {"K", 10}, {"", 0}}
+
+// ParseSize parses a size such as 2G, 512M or 512MiB into bytes. Units are
+// powers of 1024; a bare number is bytes.
+func ParseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "B"), "I")
+	for _, u := range units {
+		if !strings.HasSuffix(v, u.suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), 64)
+		if err != nil || n <= 0 || n*float64(int64(1)<<u.shift) >= 1<<63 {
+			break
+		}
+		return int64(n * float64(int64(1)<<u.shift)), nil
+	}
+	return 0, fmt.Errorf("invalid size %q (want a positive size such as 512M or 2G)", s)
+}
+
+// Monitor samples memory use while a scan runs. Its methods are safe for
+// concurrent use, and a nil *Monitor is never under pressure.
+type Monitor struct {
+	limit    int64
+	used     atomic.Int64
+	pressure atomic.Bool
+	lastGC   atomic.Int64 // Unix nanoseconds of the last collection Wait ran
+	stop     context.CancelFunc
+}
+
+// Start samples memory use against limit until Stop is called or ctx is
+// done. The limit should also be set with debug.SetMemoryLimit, so the
+// garbage collector works harder as it nears.
+func Start(ctx context.Context, limit int64) *Monitor {
+	ctx, stop := context.WithCancel(ctx)
+	m := &Monitor{limit: limit, stop: stop}
+	m.sample()
+	go func() {
+		ticker := time.NewTicker(Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.sample()
+			}
+		}
+	}()
+	return m
+}
+
+// sample reads memory use and updates the pressure
+func (m *Monitor) sample() {
+	s := make([]metrics.Sample, len(samples))
+	copy(s, samples)
+	metrics.Read(s)
+	used := int64(s[0].Value.Uint64() - s[1].Value.Uint64())
+	m.used.Store(used)
+	m.pressure.Store(float64(used) > High*float64(m.limit))
+}
+
+// Pressure reports whether memory use was past High at the last sample
+func (m *Monitor) Pressure() bool {
+	return m != nil && m.pressure.Load()
+}
+
+// Used returns the memory in use at the last sample, in bytes
+func (m *Monitor) Used() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.used.Load()
+}
+
+// Wait blocks while memory is under pressure and busy reports that work
+// still in flight may free some. Garbage is collected first, at most once
+// an Interval, as it may be all that is over. It returns false if ctx ends
+// first.
+func (m *Monitor) Wait(ctx context.Context, busy func() bool) bool {
+	if !m.Pressure() {
+		return true
+	}
+	if last, now := m.lastGC.Load(), time.Now().UnixNano(); now-last >= int64(Interval) && m.lastGC.CompareAndSwap(last, now) {
+		runtime.GC()
+		m.sample()
+	}
+	for m.Pressure() && busy() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(Interval):
+		}
+		m.sample()
+	}
+	return true
+}
+
+// Stop ends the sampling
+func (m *Monitor) Stop() {
+	if m != nil {
+		m.stop()
+	}
+}
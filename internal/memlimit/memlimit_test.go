@@ -0,0 +1,65 @@
+package memlimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"2G":      2 << 30,
+		"512M":    512 << 20,
+		"512MiB":  512 << 20,
+		"1.5g":    3 << 29,
+		"64KB":    64 << 10,
+		"1048576": 1 << 20,
+		" 1T ":    1 << 40,
+	} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "G", "-1G", "0", "2X", "twoG", "9000000T"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded; want an error", in)
+		}
+	}
+}
+
+// TestMonitor reports pressure past the limit, and Wait holds back only
+// while work is in flight
+func TestMonitor(t *testing.T) {
+	var none *Monitor
+	if none.Pressure() || !none.Wait(context.Background(), func() bool { return true }) {
+		t.Error("a nil monitor is under pressure")
+	}
+
+	roomy := Start(context.Background(), 1<<50)
+	defer roomy.Stop()
+	if roomy.Pressure() || roomy.Used() <= 0 {
+		t.Errorf("pressure %v using %d bytes of a large limit; want none", roomy.Pressure(), roomy.Used())
+	}
+
+	tight := Start(context.Background(), 1)
+	defer tight.Stop()
+	if !tight.Pressure() {
+		t.Fatal("no pressure over a limit of one byte")
+	}
+	if !tight.Wait(context.Background(), func() bool { return false }) {
+		t.Error("Wait held back with nothing in flight")
+	}
+	busy := 3
+	if !tight.Wait(context.Background(), func() bool { busy--; return busy > 0 }) || busy != 0 {
+		t.Errorf("Wait returned with %d checks left; want it to wait until nothing is in flight", busy)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*Interval)
+	defer cancel()
+	start := time.Now()
+	if tight.Wait(ctx, func() bool { return true }) {
+		t.Error("Wait returned true once its context ended")
+	}
+	if time.Since(start) < 2*Interval {
+		t.Error("Wait returned before its context ended")
+	}
+}
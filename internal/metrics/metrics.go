@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateWindow is the time constant of the processing rate's moving average
+const rateWindow = time.Minute
+
+// Metrics counts what a long-running instance has done across all its
+// scans, for Prometheus to scrape. It is safe for concurrent use, and a
+// nil *Metrics counts nothing.
+type Metrics struct {
+	start     time.Time
+	processed atomic.Uint64
+	workers   atomic.Int64
+	busy      atomic.Int64
+
+	mu       sync.Mutex
+	findings map[string]uint64 // By category
+
+	rateMu     sync.Mutex
+	rate       float64 // URLs per second, moving average
+	sampled    time.Time
+	lastCount  uint64
+	rateSeeded bool
+}
+
+// New starts counting
+func New() *Metrics {
+	now := time.Now()
+	return &Metrics{start: now, sampled: now, findings: make(map[string]uint64)}
+}
+
+// Processed counts a checked URL
+func (m *Metrics) Processed() {
+	if m != nil {
+		m.processed.Add(1)
+	}
+}
+
+// Finding counts a finding in category
+func (m *Metrics) Finding(category string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.findings[category]++
+	m.mu.Unlock()
+}
+
+// Workers adds n workers, or removes them when n is negative
+func (m *Metrics) Workers(n int) {
+	if m != nil {
+		m.workers.Add(int64(n))
+	}
+}
+
+// Busy marks n workers as checking a URL, or as waiting for one when n is
+// negative
+func (m *Metrics) Busy(n int) {
+	if m != nil {
+		m.busy.Add(int64(n))
+	}
+}
+
+// sampleRate folds the URLs processed since the last sample into the
+// moving average. Samples come from scrapes, so their spacing varies; the
+// weight of each grows with the time it covers.
+func (m *Metrics) sampleRate(now time.Time) float64 {
+	m.rateMu.Lock()
+	defer m.rateMu.Unlock()
+	elapsed := now.Sub(m.sampled)
+	if elapsed < time.Second {
+		return m.rate // Several scrapers at once
+	}
+	count := m.processed.Load()
+	current := float64(count-m.lastCount) / elapsed.Seconds()
+	if m.rateSeeded {
+		m.rate += (1 - math.Exp(-elapsed.Seconds()/rateWindow.Seconds())) * (current - m.rate)
+	} else {
+		m.rate, m.rateSeeded = current, true
+	}
+	m.sampled, m.lastCount = now, count
+	return m.rate
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w, time.Now())
+	})
+}
+
+func (m *Metrics) write(w io.Writer, now time.Time) {
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric("juicyurls_urls_processed_total", "counter", "URLs checked against the rules.")
+	fmt.Fprintf(w, "juicyurls_urls_processed_total %d\n", m.processed.Load())
+
+	metric("juicyurls_findings_total", "counter", "Findings reported, by category.")
+	m.mu.Lock()
+	categories := make([]string, 0, len(m.findings))
+	for c := range m.findings {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	for _, c := range categories {
+		fmt.Fprintf(w, "juicyurls_findings_total{category=\"%s\"} %d\n", labelValue.Replace(c), m.findings[c])
+	}
+	m.mu.Unlock()
+
+	metric("juicyurls_urls_per_second", "gauge", "URLs checked per second, averaged over about a minute.")
+	fmt.Fprintf(w, "juicyurls_urls_per_second %g\n", m.sampleRate(now))
+
+	workers, busy := m.workers.Load(), m.busy.Load()
+	metric("juicyurls_workers", "gauge", "Workers of the scans running.")
+	fmt.Fprintf(w, "juicyurls_workers %d\n", workers)
+	metric("juicyurls_workers_busy", "gauge", "Workers checking a URL rather than waiting for one.")
+	fmt.Fprintf(w, "juicyurls_workers_busy %d\n", busy)
+	utilization := 0.0
+	if workers > 0 {
+		utilization = float64(busy) / float64(workers)
+	}
+	metric("juicyurls_worker_utilization", "gauge", "Share of workers busy, from 0 to 1.")
+	fmt.Fprintf(w, "juicyurls_worker_utilization %g\n", utilization)
+
+	metric("juicyurls_start_time_seconds", "gauge", "When the instance started, in seconds since the Unix epoch.")
+	fmt.Fprintf(w, "juicyurls_start_time_seconds %d\n", m.start.Unix())
+}
+
+// labelValue escapes a label value for the text format
+var labelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWrite reports counters, escaped category labels, the moving rate
+// and worker use in the text format
+func TestWrite(t *testing.T) {
+	m := New()
+	for range 30 {
+		m.Processed()
+	}
+	m.Finding("hidden")
+	m.Finding("hidden")
+	m.Finding(`odd"name`)
+	m.Workers(4)
+	m.Busy(1)
+
+	var out strings.Builder
+	m.write(&out, m.start.Add(10*time.Second))
+	for _, want := range []string{
+		"# TYPE juicyurls_urls_processed_total counter\njuicyurls_urls_processed_total 30\n",
+		`juicyurls_findings_total{category="hidden"} 2`,
+		`juicyurls_findings_total{category="odd\"name"} 1`,
+		"juicyurls_urls_per_second 3\n",
+		"juicyurls_workers 4\n",
+		"juicyurls_worker_utilization 0.25\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// A minute later with nothing processed, the rate has decayed
+	if rate := m.sampleRate(m.start.Add(70 * time.Second)); rate <= 0 || rate >= 3 {
+		t.Errorf("rate = %g after an idle minute; want it between 0 and 3", rate)
+	}
+	var nilMetrics *Metrics
+	nilMetrics.Processed()
+	nilMetrics.Finding("hidden")
+}
@@ -0,0 +1,123 @@
+package netclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultUserAgent is sent when Options.UserAgent is empty
+const DefaultUserAgent = "juicyurls"
+
+// DefaultTimeout bounds a request when Options.Timeout is zero
+const DefaultTimeout = 10 * time.Second
+
+// ErrOffline is returned instead of a client or connection when
+// Options.Offline is set
+var ErrOffline = errors.New("network access is disabled by -offline")
+
+// Options are the network settings shared by every feature that makes
+// requests, so a scan presents one configurable fingerprint
+type Options struct {
+	UserAgent string        // Sent with every request; default DefaultUserAgent
+	Timeout   time.Duration // Whole request, including reading the body; default DefaultTimeout
+	IPVersion int           // 4 or 6 to connect over that IP version only; 0 uses both
+	Insecure  bool          // Skip TLS certificate verification
+	CAFile    string        // PEM certificates trusted as well as the system roots
+	Offline   bool          // Refuse all outgoing connections, for air-gapped use
+}
+
+// Check reports settings that cannot work, such as an unreadable CA file
+func (o Options) Check() error {
+	_, err := o.TLSConfig()
+	if err == nil && o.IPVersion != 0 && o.IPVersion != 4 && o.IPVersion != 6 {
+		err = fmt.Errorf("IP version must be 4 or 6, not %d", o.IPVersion)
+	}
+	return err
+}
+
+// New returns an HTTP client with the settings applied. Proxies are taken
+// from the environment, as for http.DefaultTransport. Offline, it fails
+// with ErrOffline, so features build their client up front and a scan that
+// needs the network fails before it starts.
+func New(o Options) (*http.Client, error) {
+	if o.Offline {
+		return nil, ErrOffline
+	}
+	if err := o.Check(); err != nil {
+		return nil, err
+	}
+	tlsConfig, _ := o.TLSConfig()
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = o.DialContext
+	t.TLSClientConfig = tlsConfig
+
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ua := o.UserAgent
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	return &http.Client{Transport: userAgent{ua, t}, Timeout: timeout}, nil
+}
+
+// DialContext connects like net.Dialer, limited to Options.IPVersion. It
+// suits features that open their own connections, such as SMTP or syslog.
+// Offline, every dial fails with ErrOffline.
+func (o Options) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if o.Offline {
+		return nil, ErrOffline
+	}
+	if o.IPVersion != 0 && (network == "tcp" || network == "udp") {
+		network = fmt.Sprintf("%s%d", network, o.IPVersion)
+	}
+	d := net.Dialer{Timeout: o.Timeout, KeepAlive: 30 * time.Second}
+	if d.Timeout == 0 {
+		d.Timeout = DefaultTimeout
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+// TLSConfig returns the TLS settings of the options, for features that
+// open their own connections
+func (o Options) TLSConfig() (*tls.Config, error) {
+	c := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: o.Insecure}
+	if o.CAFile == "" {
+		return c, nil
+	}
+	pem, err := os.ReadFile(o.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates", o.CAFile)
+	}
+	c.RootCAs = roots
+	return c, nil
+}
+
+// userAgent sets the User-Agent header on requests that have none
+type userAgent struct {
+	ua   string
+	next http.RoundTripper
+}
+
+func (u userAgent) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get("User-Agent") == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("User-Agent", u.ua)
+	}
+	return u.next.RoundTrip(r)
+}
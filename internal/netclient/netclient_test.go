@@ -0,0 +1,69 @@
+package netclient
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNew applies the user agent, IP version and trusted CAs
+func TestNew(t *testing.T) {
+	var gotUA string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	get := func(o Options) error {
+		c, err := New(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// The test server's certificate is self-signed
+	if err := get(Options{}); err == nil {
+		t.Error("untrusted certificate accepted")
+	}
+	if err := get(Options{Insecure: true, UserAgent: "acme-recon"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotUA != "acme-recon" {
+		t.Errorf("User-Agent = %q, want acme-recon", gotUA)
+	}
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644)
+	if err := get(Options{CAFile: ca, IPVersion: 4}); err != nil {
+		t.Errorf("trusted CA: %v", err)
+	}
+	if gotUA != DefaultUserAgent {
+		t.Errorf("User-Agent = %q, want %q", gotUA, DefaultUserAgent)
+	}
+	// The test server listens on 127.0.0.1
+	if err := get(Options{CAFile: ca, IPVersion: 6}); err == nil {
+		t.Error("IPv6 only reached an IPv4 server")
+	}
+
+	if _, err := New(Options{Insecure: true, Offline: true}); err != ErrOffline {
+		t.Errorf("New(offline) error = %v, want ErrOffline", err)
+	}
+	if _, err := (Options{Offline: true}).DialContext(context.Background(), "tcp", srv.Listener.Addr().String()); err != ErrOffline {
+		t.Errorf("DialContext(offline) error = %v, want ErrOffline", err)
+	}
+
+	for _, o := range []Options{{IPVersion: 5}, {CAFile: filepath.Join(t.TempDir(), "missing.pem")}} {
+		if err := o.Check(); err == nil {
+			t.Errorf("Check(%+v) = nil, want an error", o)
+		}
+	}
+}
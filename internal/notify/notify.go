@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"juicyurls/internal/types"
+	"juicyurls/suspicious"
+)
+
+// maxFindingMessages caps the per-finding messages of one scan, so a noisy
+// scan cannot flood the channel or run into the webhook's rate limit; the
+// rest are counted in the summary
+const maxFindingMessages = 20
+
+// discordLimit is the longest message Discord accepts, in characters
+const discordLimit = 2000
+
+// Notifier posts scan results to a Slack or Discord incoming webhook: a
+// summary when the scan finishes and, with a severity threshold, a message
+// per finding at or above it. URLs with a Discord webhook path
+// (/api/webhooks/) get Discord's payload; others get Slack's, which
+// Mattermost and Rocket.Chat accept as well.
+type Notifier struct {
+	client  *http.Client
+	url     string
+	discord bool
+	min     int // Lowest severity rank posted per finding; -1 for none
+
+	mu         sync.Mutex
+	bySeverity map[string]int
+	posted     int // Per-finding messages sent
+	held       int // Findings at or above min not posted, over the cap
+}
+
+// New returns a notifier for the webhook at endpoint. minSeverity enables
+// per-finding messages; empty posts the summary only.
+func New(client *http.Client, endpoint, minSeverity string) (*Notifier, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q (want a Slack or Discord incoming webhook)", endpoint)
+	}
+	n := &Notifier{
+		client:     client,
+		url:        endpoint,
+		discord:    strings.Contains(u.Path, "/api/webhooks/"),
+		min:        -1,
+		bySeverity: make(map[string]int),
+	}
+	if minSeverity != "" {
+		if n.min = suspicious.SeverityRank(minSeverity); n.min < 0 {
+			return nil, fmt.Errorf("unknown severity %q (want one of %s)", minSeverity, strings.Join(suspicious.Severities, ", "))
+		}
+	}
+	return n, nil
+}
+
+// Finding counts a finding for the summary, and posts it when it is at or
+// above the threshold and under the cap
+func (n *Notifier) Finding(ctx context.Context, r types.Result) error {
+	n.mu.Lock()
+	n.bySeverity[r.Severity]++
+	post := n.min >= 0 && suspicious.SeverityRank(r.Severity) >= n.min
+	if post && n.posted == maxFindingMessages {
+		n.held++
+		post = false
+	}
+	if post {
+		n.posted++
+	}
+	n.mu.Unlock()
+	if !post {
+		return nil
+	}
+
+	msg := fmt.Sprintf("[%s] `%s`: %s (%s)", r.Severity, r.URL, r.Reason, r.RuleID)
+	if r.Source != "" {
+		msg += fmt.Sprintf(" in %s:%d", r.Source, r.Line)
+	}
+	return n.send(ctx, msg)
+}
+
+// Summary posts the totals of a finished scan. stopped marks a scan cut
+// short by Ctrl-C or its timeout.
+func (n *Notifier) Summary(ctx context.Context, s types.Stats, stopped bool) error {
+	var names []string
+	for _, src := range s.Sources {
+		names = append(names, src.Source)
+	}
+	if len(names) > 3 {
+		names = append(names[:3], fmt.Sprintf("%d more", len(names)-3))
+	}
+	verb := "finished"
+	if stopped {
+		verb = "stopped early"
+	}
+	msg := fmt.Sprintf("juicyurls scan of %s %s in %s: %d URLs checked, %d findings",
+		strings.Join(names, ", "), verb, time.Duration(s.Seconds*float64(time.Second)).Round(time.Millisecond),
+		s.Processed, s.Suspicious)
+
+	n.mu.Lock()
+	var counts []string
+	for i := len(suspicious.Severities) - 1; i >= 0; i-- {
+		if c := n.bySeverity[suspicious.Severities[i]]; c > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", c, suspicious.Severities[i]))
+		}
+	}
+	if len(counts) > 0 {
+		msg += " (" + strings.Join(counts, ", ") + ")"
+	}
+	if n.held > 0 {
+		msg += fmt.Sprintf("; %d more at or above %s not posted individually", n.held, suspicious.Severities[n.min])
+	}
+	n.mu.Unlock()
+	if s.Known > 0 {
+		msg += fmt.Sprintf("; %d already in the baseline", s.Known)
+	}
+	if s.Failed > 0 {
+		msg += fmt.Sprintf("; %d inputs failed", s.Failed)
+	}
+	return n.send(ctx, msg)
+}
+
+// Overflow posts how many findings at or above the threshold went over the
+// cap, if any did
+func (n *Notifier) Overflow(ctx context.Context) error {
+	n.mu.Lock()
+	held := n.held
+	n.mu.Unlock()
+	if held == 0 {
+		return nil
+	}
+	return n.send(ctx, fmt.Sprintf("%d more findings not posted individually", held))
+}
+
+// Post sends a message of its own, such as an alert, to the webhook
+func (n *Notifier) Post(ctx context.Context, msg string) error {
+	return n.send(ctx, msg)
+}
+
+// send posts one message
+func (n *Notifier) send(ctx context.Context, msg string) error {
+	var payload map[string]string
+	if n.discord {
+		if r := []rune(msg); len(r) > discordLimit {
+			msg = string(r[:discordLimit-1]) + "…"
+		}
+		payload = map[string]string{"content": msg}
+	} else {
+		payload = map[string]string{"text": msg}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("notify: webhook answered %s", resp.Status)
+	}
+	return nil
+}
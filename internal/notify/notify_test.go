@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// hook records the payloads posted to it
+type hook struct {
+	mu       sync.Mutex
+	payloads []map[string]string
+}
+
+func (h *hook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var p map[string]string
+	json.NewDecoder(r.Body).Decode(&p)
+	h.mu.Lock()
+	h.payloads = append(h.payloads, p)
+	h.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestNotifier posts findings over the threshold up to the cap, then a
+// summary counting the rest, in each service's payload
+func TestNotifier(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	ctx := context.Background()
+
+	n, err := New(srv.Client(), srv.URL+"/services/T0/B0/x", "high")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range maxFindingMessages + 2 {
+		n.Finding(ctx, types.Result{URL: "https://example.com/.git/config", Severity: "critical", Reason: "Exposed", RuleID: "hidden:.git", Source: "urls.txt", Line: i + 1})
+	}
+	n.Finding(ctx, types.Result{URL: "https://example.com/a.zip", Severity: "medium"})
+	stats := types.Stats{Processed: 100, Suspicious: 23, Seconds: 1.5, Sources: []types.SourceStats{{Source: "urls.txt"}}}
+	if err := n.Summary(ctx, stats, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.payloads) != maxFindingMessages+1 {
+		t.Fatalf("%d messages; want %d findings and a summary", len(h.payloads), maxFindingMessages)
+	}
+	if got := h.payloads[0]["text"]; got != "[critical] `https://example.com/.git/config`: Exposed (hidden:.git) in urls.txt:1" {
+		t.Errorf("finding message = %q", got)
+	}
+	want := "juicyurls scan of urls.txt finished in 1.5s: 100 URLs checked, 23 findings (22 critical, 1 medium); 2 more at or above high not posted individually"
+	if got := h.payloads[maxFindingMessages]["text"]; got != want {
+		t.Errorf("summary = %q; want %q", got, want)
+	}
+
+	h.payloads = nil
+	n, _ = New(srv.Client(), srv.URL+"/api/webhooks/1/x", "")
+	n.Finding(ctx, types.Result{Severity: "critical"})
+	n.Summary(ctx, types.Stats{}, true)
+	if len(h.payloads) != 1 || !strings.Contains(h.payloads[0]["content"], "stopped early") {
+		t.Errorf("payloads = %v; want one Discord summary", h.payloads)
+	}
+
+	if _, err := New(srv.Client(), srv.URL, "severe"); err == nil {
+		t.Error("unknown severity accepted")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package offsets
+
+import "os"
+
+// inode is unavailable here; rotation is then detected by truncation only
+func inode(os.FileInfo) uint64 { return 0 }
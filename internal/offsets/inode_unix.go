@@ -0,0 +1,16 @@
+//go:build unix
+
+package offsets
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode returns the file's inode number
+func inode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
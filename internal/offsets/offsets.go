@@ -0,0 +1,109 @@
+package offsets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Position is how far a file has been read
+type Position struct {
+	Offset int64  `json:"offset"`          // Bytes consumed, always at a line boundary
+	Line   int    `json:"line"`            // Lines consumed
+	Inode  uint64 `json:"inode,omitempty"` // File identity; zero where unavailable
+}
+
+// Store records a Position per input file between runs, so re-scans of a
+// growing log only read lines appended since the last run
+type Store struct {
+	path  string
+	Files map[string]Position `json:"files"` // Keyed by absolute path
+}
+
+// Load reads a store from path. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Files: map[string]Position{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Files == nil {
+		s.Files = map[string]Position{}
+	}
+	return s, nil
+}
+
+// Resume seeks f, opened from name, to where the last run stopped and
+// returns that position. A file that was replaced (new inode) or truncated
+// is read from the start.
+func (s *Store) Resume(name string, f *os.File) (Position, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return Position{}, err
+	}
+	start := Position{Inode: inode(fi)}
+	p, ok := s.Files[key(name)]
+	if !ok || p.Inode != start.Inode || p.Offset > fi.Size() {
+		return start, nil
+	}
+	if _, err := f.Seek(p.Offset, io.SeekStart); err != nil {
+		return Position{}, err
+	}
+	return p, nil
+}
+
+// Rotated finds the file name was rotated to since the last run, the way
+// logrotate renames app.log to app.log.1, so its unread tail can be
+// scanned before the new file. It returns the rotated path and the
+// position to resume it from.
+func (s *Store) Rotated(name string) (string, Position, bool) {
+	p, ok := s.Files[key(name)]
+	if !ok || p.Inode == 0 {
+		return "", Position{}, false
+	}
+	if fi, err := os.Stat(name); err == nil && inode(fi) == p.Inode {
+		return "", Position{}, false // Not rotated
+	}
+	rotated := name + ".1"
+	fi, err := os.Stat(rotated)
+	if err != nil || inode(fi) != p.Inode || fi.Size() < p.Offset {
+		return "", Position{}, false
+	}
+	return rotated, p, true
+}
+
+// Set records the position reached in name
+func (s *Store) Set(name string, p Position) {
+	s.Files[key(name)] = p
+}
+
+// Save writes the store back to its file, replacing it atomically
+func (s *Store) Save() error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// key identifies a file independently of the working directory
+func key(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return name
+}
@@ -0,0 +1,85 @@
+package offsets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestResume picks up where the stored position left off, and starts
+// over on a truncated or rotated file
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(log, []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(dir, "state.json")
+	s, err := Load(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.Resume(log, f)
+	f.Close()
+	if err != nil || p.Offset != 0 {
+		t.Fatalf("first Resume = %+v, %v; want offset 0", p, err)
+	}
+	p.Offset, p.Line = 4, 2
+	s.Set(log, p)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reloaded store resumes after the recorded lines
+	if s, err = Load(statePath); err != nil {
+		t.Fatal(err)
+	}
+	f, _ = os.Open(log)
+	got, err := s.Resume(log, f)
+	f.Close()
+	if err != nil || got != p {
+		t.Errorf("Resume = %+v, %v; want %+v", got, err, p)
+	}
+
+	// Truncated: read from the start
+	if err := os.WriteFile(log, []byte("c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, _ = os.Open(log)
+	got, _ = s.Resume(log, f)
+	f.Close()
+	if got.Offset != 0 || got.Line != 0 {
+		t.Errorf("Resume after truncation = %+v; want start", got)
+	}
+
+	if runtime.GOOS == "windows" {
+		return // No inodes
+	}
+	// Rotated: the old inode now lives at app.log.1
+	if err := os.WriteFile(log, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.Set(log, Position{Offset: 4, Line: 2, Inode: p.Inode})
+	if err := os.Rename(log, log+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(log, []byte("d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rotated, pos, ok := s.Rotated(log)
+	if !ok || rotated != log+".1" || pos.Offset != 4 {
+		t.Errorf("Rotated = %q, %+v, %v; want %s.1 at offset 4", rotated, pos, ok, log)
+	}
+	f, _ = os.Open(log)
+	got, _ = s.Resume(log, f)
+	f.Close()
+	if got.Offset != 0 {
+		t.Errorf("Resume of new file = %+v; want start", got)
+	}
+}
@@ -0,0 +1,332 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interval is how often the metrics of a running scan are exported; a
+// trace is exported once its scan ends
+const Interval = time.Minute
+
+// scopeName identifies the instrumentation in exported data
+const scopeName = "juicyurls"
+
+// Exporter sends traces and metrics of scans to an OpenTelemetry
+// collector over OTLP/HTTP with JSON encoding, which the collector's
+// default otlp receiver accepts. A nil *Exporter sends nothing and its
+// spans record nothing.
+type Exporter struct {
+	client   *http.Client
+	endpoint string // Base URL; /v1/traces and /v1/metrics are appended
+	resource resource
+}
+
+// New returns an exporter for the collector at endpoint, such as
+// http://localhost:4318
+func New(client *http.Client, endpoint string) (*Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q (want http://host:4318)", endpoint)
+	}
+	attrs := []attribute{attr("service.name", "juicyurls")}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, attr("host.name", hostname))
+	}
+	attrs = append(attrs, attr("process.pid", os.Getpid()))
+	return &Exporter{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		resource: resource{Attributes: attrs},
+	}, nil
+}
+
+// Span times one stage of a scan. Its methods are safe for concurrent use.
+type Span struct {
+	trace  *trace
+	id     string
+	parent string
+	name   string
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []attribute
+	err   string
+}
+
+// trace collects the ended spans of one scan
+type trace struct {
+	id    string
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Start begins the root span of a new trace
+func (e *Exporter) Start(name string) *Span {
+	if e == nil {
+		return nil
+	}
+	return &Span{trace: &trace{id: newID(16)}, id: newID(8), name: name, start: time.Now()}
+}
+
+// Child begins a span within s
+func (s *Span) Child(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{trace: s.trace, id: newID(8), parent: s.id, name: name, start: time.Now()}
+}
+
+// Set records an attribute: a string, bool, int, uint64 or float64
+func (s *Span) Set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attr(key, value))
+	s.mu.Unlock()
+}
+
+// Fail marks the span as failed with err
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.err = err.Error()
+	s.mu.Unlock()
+}
+
+// End ends the span; later calls are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	ended := !s.end.IsZero()
+	if !ended {
+		s.end = time.Now()
+	}
+	s.mu.Unlock()
+	if ended {
+		return
+	}
+	s.trace.mu.Lock()
+	s.trace.spans = append(s.trace.spans, s)
+	s.trace.mu.Unlock()
+}
+
+// ExportTrace sends the ended spans of root's trace
+func (e *Exporter) ExportTrace(ctx context.Context, root *Span) error {
+	if e == nil || root == nil {
+		return nil
+	}
+	root.trace.mu.Lock()
+	spans := make([]span, 0, len(root.trace.spans))
+	for _, s := range root.trace.spans {
+		s.mu.Lock()
+		out := span{
+			TraceID:      root.trace.id,
+			SpanID:       s.id,
+			ParentSpanID: s.parent,
+			Name:         s.name,
+			Kind:         1, // Internal
+			Start:        nanos(s.start),
+			End:          nanos(s.end),
+			Attributes:   s.attrs,
+		}
+		if s.err != "" {
+			out.Status = &status{Code: 2, Message: s.err}
+		}
+		s.mu.Unlock()
+		spans = append(spans, out)
+	}
+	root.trace.mu.Unlock()
+	return e.post(ctx, "/v1/traces", map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   e.resource,
+			"scopeSpans": []any{map[string]any{"scope": scope{Name: scopeName}, "spans": spans}},
+		}},
+	})
+}
+
+// Counts are the totals of a scan, exported as cumulative metrics
+type Counts struct {
+	Start     time.Time // When the scan started
+	Read      uint64    // URLs read from the input
+	Processed uint64    // URLs checked
+	Findings  uint64    // Findings, written or held back
+	Bytes     uint64    // Input bytes read
+	Probes    uint64    // Liveness checks of findings' targets
+
+	Categories map[string]uint64 // Findings per category
+}
+
+// ExportMetrics sends the scan's counts, and its rate so far
+func (e *Exporter) ExportMetrics(ctx context.Context, c Counts) error {
+	if e == nil {
+		return nil
+	}
+	now := time.Now()
+	sum := func(name, unit, description string, value uint64) map[string]any {
+		return map[string]any{
+			"name": name, "unit": unit, "description": description,
+			"sum": map[string]any{
+				"aggregationTemporality": 2, // Cumulative
+				"isMonotonic":            true,
+				"dataPoints": []any{map[string]any{
+					"startTimeUnixNano": nanos(c.Start),
+					"timeUnixNano":      nanos(now),
+					"asInt":             strconv.FormatUint(value, 10),
+				}},
+			},
+		}
+	}
+	rate := 0.0
+	if secs := now.Sub(c.Start).Seconds(); secs > 0 {
+		rate = float64(c.Processed) / secs
+	}
+	metrics := []any{
+		sum("juicyurls.urls.read", "{url}", "URLs read from the input", c.Read),
+		sum("juicyurls.urls.processed", "{url}", "URLs checked against the rules", c.Processed),
+		sum("juicyurls.findings", "{finding}", "Findings made", c.Findings),
+		sum("juicyurls.input.size", "By", "Input bytes read", c.Bytes),
+		sum("juicyurls.probes", "{check}", "Liveness checks of findings' targets", c.Probes),
+		map[string]any{
+			"name": "juicyurls.urls.rate", "unit": "{url}/s", "description": "URLs checked per second since the scan started",
+			"gauge": map[string]any{"dataPoints": []any{map[string]any{
+				"timeUnixNano": nanos(now),
+				"asDouble":     rate,
+			}}},
+		},
+	}
+	if len(c.Categories) > 0 {
+		names := make([]string, 0, len(c.Categories))
+		for name := range c.Categories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		points := make([]any, 0, len(names))
+		for _, name := range names {
+			points = append(points, map[string]any{
+				"attributes":        []attribute{attr("juicyurls.category", name)},
+				"startTimeUnixNano": nanos(c.Start),
+				"timeUnixNano":      nanos(now),
+				"asInt":             strconv.FormatUint(c.Categories[name], 10),
+			})
+		}
+		metrics = append(metrics, map[string]any{
+			"name": "juicyurls.category.findings", "unit": "{finding}", "description": "Findings made, per category",
+			"sum": map[string]any{
+				"aggregationTemporality": 2,
+				"isMonotonic":            true,
+				"dataPoints":             points,
+			},
+		})
+	}
+	return e.post(ctx, "/v1/metrics", map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource":     e.resource,
+			"scopeMetrics": []any{map[string]any{"scope": scope{Name: scopeName}, "metrics": metrics}},
+		}},
+	})
+}
+
+// post sends one export request
+func (e *Exporter) post(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: collector answered %s for %s", resp.Status, path)
+	}
+	return nil
+}
+
+// The OTLP/JSON shapes; integers of 64 bits travel as strings, IDs as hex
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID      string      `json:"traceId"`
+	SpanID       string      `json:"spanId"`
+	ParentSpanID string      `json:"parentSpanId,omitempty"`
+	Name         string      `json:"name"`
+	Kind         int         `json:"kind"`
+	Start        string      `json:"startTimeUnixNano"`
+	End          string      `json:"endTimeUnixNano"`
+	Attributes   []attribute `json:"attributes,omitempty"`
+	Status       *status     `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"` // 2 is an error
+	Message string `json:"message"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func attr(key string, value any) attribute {
+	var v map[string]any
+	switch value := value.(type) {
+	case string:
+		v = map[string]any{"stringValue": value}
+	case bool:
+		v = map[string]any{"boolValue": value}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(value)}
+	case uint64:
+		v = map[string]any{"intValue": strconv.FormatUint(value, 10)}
+	case float64:
+		v = map[string]any{"doubleValue": value}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(value)}
+	}
+	return attribute{Key: key, Value: v}
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// newID returns n random bytes in hex
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,98 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector keeps the bodies posted to it by path
+type collector struct {
+	mu     sync.Mutex
+	bodies map[string]map[string]any
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.bodies[r.URL.Path] = body
+	c.mu.Unlock()
+}
+
+// TestExport sends a scan's spans with their parents, attributes and
+// errors, and its counts as cumulative sums
+func TestExport(t *testing.T) {
+	c := &collector{bodies: make(map[string]map[string]any)}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	e, err := New(srv.Client(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	root := e.Start("scan")
+	read := root.Child("read")
+	read.Set("juicyurls.urls.read", uint64(42))
+	read.End()
+	write := root.Child("write")
+	write.Fail(errors.New("disk full"))
+	write.End()
+	root.End()
+	root.End()
+	if err := e.ExportTrace(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	scopeSpans := c.bodies["/v1/traces"]["resourceSpans"].([]any)[0].(map[string]any)["scopeSpans"].([]any)
+	spans := scopeSpans[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 3 {
+		t.Fatalf("%d spans; want read, write and scan once each", len(spans))
+	}
+	first, last := spans[0].(map[string]any), spans[2].(map[string]any)
+	if first["name"] != "read" || first["parentSpanId"] != last["spanId"] || first["traceId"] != last["traceId"] || len(last["traceId"].(string)) != 32 {
+		t.Errorf("read span = %v; want a child of %v", first, last)
+	}
+	if attr := first["attributes"].([]any)[0].(map[string]any); attr["value"].(map[string]any)["intValue"] != "42" {
+		t.Errorf("attribute = %v; want intValue 42", attr)
+	}
+	if status := spans[1].(map[string]any)["status"].(map[string]any); status["code"] != 2.0 || status["message"] != "disk full" {
+		t.Errorf("write status = %v; want an error", status)
+	}
+
+	err = e.ExportMetrics(ctx, Counts{Start: time.Now().Add(-time.Second), Processed: 10, Findings: 2,
+		Categories: map[string]uint64{"paths": 1, "hidden": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := c.bodies["/v1/metrics"]["resourceMetrics"].([]any)[0].(map[string]any)["scopeMetrics"].([]any)[0].(map[string]any)["metrics"].([]any)
+	processed := metrics[1].(map[string]any)
+	point := processed["sum"].(map[string]any)["dataPoints"].([]any)[0].(map[string]any)
+	if processed["name"] != "juicyurls.urls.processed" || point["asInt"] != "10" {
+		t.Errorf("metric = %v; want 10 URLs processed", processed)
+	}
+
+	byCategory := metrics[len(metrics)-1].(map[string]any)
+	points := byCategory["sum"].(map[string]any)["dataPoints"].([]any)
+	category := points[0].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	if byCategory["name"] != "juicyurls.category.findings" || len(points) != 2 || category["value"].(map[string]any)["stringValue"] != "hidden" {
+		t.Errorf("metric = %v; want a point per category, in name order", byCategory)
+	}
+
+	var none *Exporter
+	none.Start("scan").Child("read").End()
+	if err := none.ExportTrace(ctx, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := New(srv.Client(), "localhost:4318"); err == nil {
+		t.Error("endpoint without a scheme accepted")
+	}
+}
@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"juicyurls/internal/checker"
+)
+
+// command is a plugin shipped as a separate executable. It speaks
+// line-based JSON on standard input and output: for every URL it is sent
+//
+//	{"url":"https://example.com/x"}
+//
+// it writes one line holding an array of findings, possibly empty:
+//
+//	[{"category":"acme","rule_id":"acme:x","reason":"Internal host","severity":"high"}]
+//
+// Each process handles one URL at a time, so the command runs as a pool of
+// processes, started as concurrent matches need them. Anything they write
+// to standard error is passed through.
+type command struct {
+	health
+	ctx  context.Context
+	args []string
+
+	idle  chan *process // Processes waiting for a URL
+	slots chan struct{} // One token for each process started
+	mu    sync.Mutex    // Guards procs
+	procs []*process    // Every process started, for Close
+}
+
+// process is one running instance of a command
+type process struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+// request is sent to the plugin for each URL
+type request struct {
+	URL string `json:"url"`
+}
+
+// startCommand starts the first process of a pool of up to size, so a
+// command that does not run fails at once
+func startCommand(ctx context.Context, args []string, size int, logger *slog.Logger) (*command, error) {
+	c := &command{
+		health: health{name: args[0], logger: logger},
+		ctx:    ctx,
+		args:   args,
+		idle:   make(chan *process, size),
+		slots:  make(chan struct{}, size),
+	}
+	c.slots <- struct{}{}
+	p, err := c.start()
+	if err != nil {
+		return nil, err
+	}
+	c.idle <- p
+	return c, nil
+}
+
+// start runs one more process of the command
+func (c *command) start() (*process, error) {
+	cmd := exec.CommandContext(c.ctx, c.args[0], c.args[1:]...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", c.name, err)
+	}
+	p := &process{cmd: cmd, in: in, out: bufio.NewReader(out)}
+	c.mu.Lock()
+	c.procs = append(c.procs, p)
+	c.mu.Unlock()
+	return p, nil
+}
+
+// Match sends a URL to an idle process and returns its findings
+func (c *command) Match(u *checker.ParsedURL) []checker.Finding {
+	if c.failed.Load() {
+		return nil
+	}
+	p, err := c.acquire()
+	if err != nil {
+		c.fail(err)
+		return nil
+	}
+	defer func() { c.idle <- p }()
+	if c.failed.Load() {
+		return nil
+	}
+	found, err := p.query(u.Raw, c.name)
+	if err != nil {
+		c.fail(err)
+		return nil
+	}
+	return found
+}
+
+// acquire takes an idle process, starting another while fewer than the
+// pool's size run, and otherwise waits for one to be released
+func (c *command) acquire() (*process, error) {
+	select {
+	case p := <-c.idle:
+		return p, nil
+	default:
+	}
+	select {
+	case p := <-c.idle:
+		return p, nil
+	case c.slots <- struct{}{}:
+		p, err := c.start()
+		if err != nil {
+			<-c.slots
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// query runs one request/reply exchange
+func (p *process) query(rawURL, name string) ([]checker.Finding, error) {
+	req, err := json.Marshal(request{URL: rawURL})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.in.Write(append(req, '\n')); err != nil {
+		return nil, err
+	}
+	line, err := p.out.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("reading reply: %w", err)
+	}
+
+	var reply []finding
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return nil, fmt.Errorf("invalid reply %q: %w", strings.TrimSpace(string(line)), err)
+	}
+	return checkerFindings(reply, name)
+}
+
+// Close ends the input of every process and waits for them to exit
+func (c *command) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for _, p := range c.procs {
+		p.in.Close()
+		errs = append(errs, p.cmd.Wait())
+	}
+	return errors.Join(errs...)
+}
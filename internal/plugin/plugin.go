@@ -0,0 +1,123 @@
+// Package plugin loads detectors at runtime with -plugin, so teams can ship
+// proprietary detection logic without forking juicyurls. A plugin is either
+// a Starlark script, run in process in a sandbox, or an executable speaking
+// JSON lines, run as a pool of processes.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"juicyurls/internal/checker"
+	"juicyurls/internal/rules"
+	"juicyurls/suspicious"
+)
+
+// ScriptExt marks a plugin as a Starlark script rather than a command
+const ScriptExt = ".star"
+
+// Plugin is a detector loaded at runtime. It is a checker.Matcher, safe for
+// concurrent use by the scan's workers. Errors while scanning are logged
+// once and disable the plugin for the rest of the run.
+type Plugin interface {
+	checker.Matcher
+	// Close releases the plugin, waiting for its processes to exit
+	Close() error
+}
+
+// Start loads the plugin spec names: a Starlark script when it ends in
+// ScriptExt, or else a command, split on spaces into a program and its
+// arguments. A command is started as up to size processes, as many as
+// URLs are matched at once; size 0 means one per CPU, the scan's default
+// number of workers. The processes are killed when ctx ends.
+func Start(ctx context.Context, spec string, size int, logger *slog.Logger) (Plugin, error) {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	spec = strings.TrimSpace(spec)
+	if strings.HasSuffix(spec, ScriptExt) {
+		return loadScript(spec, logger)
+	}
+	args := strings.Fields(spec)
+	if len(args) == 0 {
+		return nil, errors.New("empty plugin command")
+	}
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	return startCommand(ctx, args, size, logger)
+}
+
+// health disables a plugin after its first error
+type health struct {
+	name   string
+	logger *slog.Logger
+	failed atomic.Bool
+}
+
+// fail disables the plugin, logging err unless it already failed
+func (h *health) fail(err error) {
+	if h.failed.CompareAndSwap(false, true) {
+		h.logger.Error("plugin disabled", "plugin", h.name, "err", err)
+	}
+}
+
+// finding is one finding reported by a plugin
+type finding struct {
+	Category   string `json:"category"`
+	RuleID     string `json:"rule_id"`
+	Reason     string `json:"reason"`
+	Severity   string `json:"severity"`
+	Confidence string `json:"confidence"`
+	Weight     int    `json:"weight"`
+	Pattern    string `json:"pattern"`
+	Component  string `json:"component"`
+	Offset     int    `json:"offset"`
+}
+
+func (f *finding) validate() error {
+	switch {
+	case f.Category == "":
+		return errors.New("finding without category")
+	case f.RuleID == "":
+		return fmt.Errorf("%s finding without rule_id", f.Category)
+	case f.Severity != "" && !suspicious.ValidSeverity(f.Severity):
+		return fmt.Errorf("%s: invalid severity %q", f.RuleID, f.Severity)
+	case f.Confidence != "" && !suspicious.ValidConfidence(f.Confidence):
+		return fmt.Errorf("%s: invalid confidence %q", f.RuleID, f.Confidence)
+	case f.Weight < 0:
+		return fmt.Errorf("%s: negative weight", f.RuleID)
+	}
+	if f.Reason == "" {
+		f.Reason = "Flagged by plugin " + f.Category
+	}
+	return nil
+}
+
+// checkerFindings validates a plugin's findings and converts them for the
+// checker, crediting them to the plugin name
+func checkerFindings(found []finding, name string) ([]checker.Finding, error) {
+	out := make([]checker.Finding, 0, len(found))
+	for _, f := range found {
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+		out = append(out, checker.Finding{
+			Category:   f.Category,
+			Reason:     f.Reason,
+			Severity:   f.Severity,
+			Confidence: f.Confidence,
+			RuleID:     f.RuleID,
+			RuleSource: "plugin " + name,
+			Weight:     f.Weight,
+			Match:      rules.Location{Pattern: f.Pattern, Component: f.Component, Offset: f.Offset},
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+	"github.com/alwalxed/juicyurls/v2/internal/rules"
+)
+
+// TestMain turns the test binary into a plugin when asked to, so the tests
+// need no separate executable
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("JUICYURLS_TEST_PLUGIN"); mode != "" {
+		servePlugin(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// servePlugin flags URLs on internal.example, answers "bad" with garbage
+// and takes a second per URL when "slow"
+func servePlugin(mode string) {
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		var req request
+		json.Unmarshal(in.Bytes(), &req)
+		switch {
+		case mode == "bad":
+			fmt.Println("not json")
+		case mode == "slow":
+			time.Sleep(time.Second)
+			fmt.Println(`[]`)
+		case strings.Contains(req.URL, "internal.example"):
+			fmt.Println(`[{"category":"inventory","rule_id":"inventory:internal","severity":"high","pattern":"internal","component":"host","offset":0}]`)
+		default:
+			fmt.Println(`[]`)
+		}
+	}
+}
+
+func start(t *testing.T, mode string, size int) *command {
+	t.Helper()
+	t.Setenv("JUICYURLS_TEST_PLUGIN", mode)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Start(context.Background(), exe, size, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p.(*command)
+}
+
+// TestPlugin reports a plugin's findings through the checker
+func TestPlugin(t *testing.T) {
+	uc := checker.NewURLChecker("keywords", "")
+	uc.Register(start(t, "ok", 1))
+
+	f, ok := uc.Check("https://internal.example/")
+	if !ok || f.RuleID != "inventory:internal" || f.Severity != "high" || f.Confidence != "likely" || f.Match.Component != "host" {
+		t.Errorf("got %+v, %v; want inventory:internal finding", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/"); ok {
+		t.Errorf("got %+v; want no finding", f)
+	}
+}
+
+// TestPluginBadReply disables a plugin that breaks the protocol
+func TestPluginBadReply(t *testing.T) {
+	p := start(t, "bad", 1)
+	u := rules.NewTarget("https://internal.example/")
+	if found := p.Match(u); found != nil {
+		t.Errorf("got %+v; want nothing", found)
+	}
+	if !p.failed.Load() {
+		t.Error("plugin not disabled after a bad reply")
+	}
+	if _, err := Start(context.Background(), " ", 1, nil); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
+
+// TestPluginPool matches URLs in parallel on up to size processes
+func TestPluginPool(t *testing.T) {
+	const size = 4
+	p := start(t, "slow", size)
+	begin := time.Now()
+	var wg sync.WaitGroup
+	for range 2 * size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Match(rules.NewTarget("https://example.com/"))
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(begin); elapsed > 4*time.Second {
+		t.Errorf("%d URLs took %v on %d processes; want about 2s", 2*size, elapsed, size)
+	}
+	if len(p.procs) != size {
+		t.Errorf("started %d processes; want %d", len(p.procs), size)
+	}
+	if p.failed.Load() {
+		t.Error("plugin disabled")
+	}
+}
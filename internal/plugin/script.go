@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"juicyurls/internal/checker"
+)
+
+// MaxSteps bounds the Starlark computation steps a script may take for one
+// URL, or to load, so a runaway loop disables the plugin instead of
+// stalling the scan
+const MaxSteps = 1_000_000
+
+// script is a plugin written in Starlark, a small Python dialect run in
+// process. A script defines match, called with each URL and returning a
+// list of findings as dicts with the keys of the JSON protocol:
+//
+//	def match(url):
+//	    if url.host.endswith(".acme.internal"):
+//	        return [{"category": "acme", "rule_id": "acme:internal", "severity": "high"}]
+//	    return []
+//
+// url has the fields raw, text (percent-decoded), scheme, user, host,
+// path, query and fragment. Scripts are sandboxed: they cannot load other
+// files or reach the file system, the network or the clock, and print
+// writes to standard error. Globals are frozen once the script is loaded,
+// so matches run concurrently.
+type script struct {
+	health
+	match starlark.Callable
+}
+
+// loadScript runs the script at path and takes its match function
+func loadScript(path string, logger *slog.Logger) (*script, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	thread := newThread(path)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	match, ok := globals["match"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: no match function", path)
+	}
+	globals.Freeze()
+	return &script{health: health{name: path, logger: logger}, match: match}, nil
+}
+
+// newThread returns a thread for one call into a script: it has no load,
+// and at most MaxSteps steps
+func newThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+	thread.SetMaxExecutionSteps(MaxSteps)
+	return thread
+}
+
+// Match calls the script's match function with a URL
+func (s *script) Match(u *checker.ParsedURL) []checker.Finding {
+	if s.failed.Load() {
+		return nil
+	}
+	v, err := starlark.Call(newThread(s.name), s.match, starlark.Tuple{urlValue(u)}, nil)
+	if err != nil {
+		s.fail(err)
+		return nil
+	}
+	found, err := scriptFindings(v)
+	if err == nil && len(found) > 0 {
+		var out []checker.Finding
+		if out, err = checkerFindings(found, s.name); err == nil {
+			return out
+		}
+	}
+	if err != nil {
+		s.fail(err)
+	}
+	return nil
+}
+
+// urlValue is the url a script's match function is called with
+func urlValue(u *checker.ParsedURL) *starlarkstruct.Struct {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"raw":      starlark.String(u.Raw),
+		"text":     starlark.String(u.Text()),
+		"scheme":   starlark.String(u.Scheme()),
+		"user":     starlark.String(u.User()),
+		"host":     starlark.String(u.Host()),
+		"path":     starlark.String(u.Path()),
+		"query":    starlark.String(u.Query()),
+		"fragment": starlark.String(u.Fragment()),
+	})
+}
+
+// scriptFindings reads the findings a match function returned: a list or
+// tuple of dicts, or None for none
+func scriptFindings(v starlark.Value) ([]finding, error) {
+	if v == starlark.None {
+		return nil, nil
+	}
+	items, ok := v.(starlark.Indexable)
+	if !ok {
+		return nil, fmt.Errorf("match returned %s, want a list of findings", v.Type())
+	}
+	out := make([]finding, items.Len())
+	for i := range out {
+		d, ok := items.Index(i).(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("finding %d is a %s, want a dict", i, items.Index(i).Type())
+		}
+		for _, kv := range d.Items() {
+			if err := out[i].set(kv[0], kv[1]); err != nil {
+				return nil, fmt.Errorf("finding %d: %w", i, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// set sets the field of a finding named by a dict key
+func (f *finding) set(key, v starlark.Value) error {
+	k, ok := starlark.AsString(key)
+	if !ok {
+		return fmt.Errorf("key %s is not a string", key)
+	}
+	strings := map[string]*string{
+		"category": &f.Category, "rule_id": &f.RuleID, "reason": &f.Reason, "severity": &f.Severity,
+		"confidence": &f.Confidence, "pattern": &f.Pattern, "component": &f.Component,
+	}
+	ints := map[string]*int{"weight": &f.Weight, "offset": &f.Offset}
+	if p, ok := strings[k]; ok {
+		if *p, ok = starlark.AsString(v); !ok {
+			return fmt.Errorf("%s is a %s, want a string", k, v.Type())
+		}
+		return nil
+	}
+	if p, ok := ints[k]; ok {
+		if err := starlark.AsInt(v, p); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown key %q", k)
+}
+
+// Close does nothing; a script holds no resources
+func (s *script) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+	"github.com/alwalxed/juicyurls/v2/internal/rules"
+)
+
+// loadSrc loads a script plugin from source
+func loadSrc(t *testing.T, src string) (*script, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "detect"+ScriptExt)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Start(context.Background(), path, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*script), nil
+}
+
+const internalScript = `
+HOSTS = ["internal.example"]
+
+def match(url):
+    if url.host in HOSTS:
+        return [{"category": "inventory", "rule_id": "inventory:internal", "severity": "high",
+                 "pattern": url.host, "component": "host", "offset": 0}]
+    return []
+`
+
+// TestScript reports a script's findings through the checker
+func TestScript(t *testing.T) {
+	p, err := loadSrc(t, internalScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := checker.NewURLChecker("keywords", "")
+	uc.Register(p)
+
+	f, ok := uc.Check("https://internal.example/")
+	if !ok || f.RuleID != "inventory:internal" || f.Severity != "high" || f.Match.Pattern != "internal.example" ||
+		!strings.HasPrefix(f.RuleSource, "plugin ") {
+		t.Errorf("got %+v, %v; want inventory:internal finding", f, ok)
+	}
+	if f, ok := uc.Check("https://example.com/"); ok {
+		t.Errorf("got %+v; want no finding", f)
+	}
+}
+
+// TestScriptLoad rejects scripts that cannot run
+func TestScriptLoad(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"no match", "x = 1\n", "no match function"},
+		{"syntax", "def match(url)\n", "got newline"},
+		{"load", "load('other.star', 'x')\ndef match(url):\n    return []\n", "load not implemented"},
+		{"os", "def match(url):\n    return open('/etc/passwd')\nx = match(None)\n", "undefined: open"},
+		{"runaway", "def f():\n    for i in range(100000000):\n        pass\nf()\n", "too many steps"},
+	}
+	for _, tt := range tests {
+		if _, err := loadSrc(t, tt.src); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v; want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestScriptFailure disables a script that fails or returns something that
+// is not a findings list
+func TestScriptFailure(t *testing.T) {
+	tests := []struct {
+		name, src string
+	}{
+		{"error", "def match(url):\n    return 1 // 0\n"},
+		{"not a list", "def match(url):\n    return 'inventory'\n"},
+		{"unknown key", "def match(url):\n    return [{'category': 'x', 'rule_id': 'x:y', 'sev': 'high'}]\n"},
+		{"invalid", "def match(url):\n    return [{'category': 'x', 'rule_id': 'x:y', 'severity': 'severe'}]\n"},
+		{"runaway", "def match(url):\n    for i in range(100000000):\n        pass\n"},
+		{"frozen", "SEEN = []\ndef match(url):\n    SEEN.append(url.raw)\n"},
+	}
+	for _, tt := range tests {
+		p, err := loadSrc(t, tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if found := p.Match(rules.NewTarget("https://example.com/")); found != nil {
+			t.Errorf("%s: got %+v; want nothing", tt.name, found)
+		}
+		if !p.failed.Load() {
+			t.Errorf("%s: plugin not disabled", tt.name)
+		}
+	}
+}
+
+// TestScriptConcurrent calls a script from many goroutines at once
+func TestScriptConcurrent(t *testing.T) {
+	p, err := loadSrc(t, internalScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if found := p.Match(rules.NewTarget("https://internal.example/x")); len(found) != 1 {
+				t.Errorf("got %+v; want one finding", found)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -2,32 +2,976 @@ package processor
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"juicyurls/config"
 	"juicyurls/internal/checker"
+	"juicyurls/internal/checkpoint"
+	"juicyurls/internal/dedup"
+	"juicyurls/internal/digest"
+	"juicyurls/internal/essink"
+	"juicyurls/internal/extsort"
+	"juicyurls/internal/fingerprint"
+	"juicyurls/internal/heartbeat"
+	"juicyurls/internal/input"
+	"juicyurls/internal/memlimit"
+	"juicyurls/internal/netclient"
+	"juicyurls/internal/notify"
+	"juicyurls/internal/offsets"
+	"juicyurls/internal/otlp"
+	"juicyurls/internal/quarantine"
+	"juicyurls/internal/route"
+	"juicyurls/internal/sqlsink"
+	"juicyurls/internal/syslogsink"
+	"juicyurls/internal/techstack"
 	"juicyurls/internal/types"
+	"juicyurls/internal/urlnorm"
+	"juicyurls/internal/verify"
+	"juicyurls/internal/webhook"
 	"juicyurls/pkg/writer"
+	"juicyurls/suspicious"
 )
 
+// ArgsSource names the source of URLs given on the command line
+const ArgsSource = "args"
+
+// entry is a URL queued for checking, with where it was read
+type entry struct {
+	url     string
+	source  string // Input file path, or ArgsSource
+	line    int    // 1-based line in source, or position among the arguments
+	offset  int64  // Byte offset of the URL in source, with context
+	context string // Text around the URL, with Config.Extract
+}
+
+// contextChars is how much text either side of an extracted URL its
+// findings carry
+const contextChars = 80
+
+// ReaderSource names the source of URLs read from Config.Reader
+const ReaderSource = "-"
+
+// source is an opened input
+type source struct {
+	name  string // Path reported in findings
+	key   string // Path its position is stored under; empty to not store
+	r     io.Reader
+	start offsets.Position // Where reading resumes
+	err   error            // Why it could not be opened; nil if it was
+}
+
+// mapped memory-maps the rest of src, from where reading resumes, if it is
+// a regular file of at least config.MapThreshold bytes
+func mapped(src source) (data []byte, unmap func() error, ok bool) {
+	f, ok := src.r.(*os.File)
+	if !ok {
+		return nil, nil, false
+	}
+	if fi, err := f.Stat(); err != nil || fi.Size() < config.MapThreshold {
+		return nil, nil, false
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, false
+	}
+	data, unmap, err = input.Map(f)
+	if err != nil || offset > int64(len(data)) {
+		if unmap != nil {
+			unmap()
+		}
+		return nil, nil, false
+	}
+	return data[offset:], unmap, true
+}
+
+// parallel calls fn for each index below n from up to k goroutines, and
+// waits for them
+func parallel(n, k int, fn func(i int)) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(n, k) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// counters tracks a pipeline's totals, and each input source's share
+type counters struct {
+	total, processed, suspicious, bytesRead, outOfScope, known, otherShards, quarantined, duplicates, repeated, unresolved, falsePositives uint64
+	probes, probeNanos                                                                                                                     uint64 // Liveness checks made, and the time they took
+	emitted                                                                                                                                uint64 // Findings handed to the writer; suspicious also counts known, repeated and capped ones
+	start                                                                                                                                  time.Time
+
+	sources    []string // Source names in reading order
+	bySource   map[string]*sourceCounters
+	severities []uint64 // Findings reported, by suspicious.SeverityRank
+
+	categoryMu sync.Mutex
+	categories map[string]uint64 // Findings per category, as counted in suspicious
+
+	ruleMu  sync.Mutex
+	perRule map[string]int    // Findings written per rule, with a per-rule limit
+	capped  map[string]uint64 // Findings over the limit per rule
+
+	tech *techstack.Profile // Technologies per host; nil unless TechStack
+
+	errMu sync.Mutex
+	errs  map[string]error // Why sources failed, by name
+}
+
+// sourceCounters tracks one input source's share of the totals
+type sourceCounters struct {
+	total, processed, suspicious, outOfScope uint64
+}
+
+// newCounters starts counting, with a share for each named source. The
+// map is not written once reading starts, so shares are updated
+// atomically without a lock.
+func newCounters(sources []string) *counters {
+	c := &counters{
+		start:      time.Now(),
+		bySource:   make(map[string]*sourceCounters),
+		perRule:    make(map[string]int),
+		capped:     make(map[string]uint64),
+		errs:       make(map[string]error),
+		severities: make([]uint64, len(suspicious.Severities)),
+		categories: make(map[string]uint64),
+	}
+	for _, name := range sources {
+		if c.bySource[name] == nil {
+			c.bySource[name] = &sourceCounters{}
+			c.sources = append(c.sources, name)
+		}
+	}
+	return c
+}
+
+// allow reports whether a finding of a rule is under the per-rule limit,
+// and counts it as capped if not
+func (c *counters) allow(ruleID string, limit int) bool {
+	c.ruleMu.Lock()
+	defer c.ruleMu.Unlock()
+	if c.perRule[ruleID] < limit {
+		c.perRule[ruleID]++
+		return true
+	}
+	c.capped[ruleID]++
+	return false
+}
+
+// found counts a finding of category read from source
+func (c *counters) found(source, category string) {
+	atomic.AddUint64(&c.suspicious, 1)
+	atomic.AddUint64(&c.bySource[source].suspicious, 1)
+	c.categoryMu.Lock()
+	c.categories[category]++
+	c.categoryMu.Unlock()
+}
+
+// live asks v whether the target of rawURL is live, timing the check
+func (c *counters) live(ctx context.Context, v verify.Verifier, rawURL string) bool {
+	start := time.Now()
+	ok := v.Live(ctx, rawURL)
+	atomic.AddUint64(&c.probeNanos, uint64(time.Since(start)))
+	atomic.AddUint64(&c.probes, 1)
+	return ok
+}
+
+// fail records that a source could not be read to the end
+func (c *counters) fail(source string, err error) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	c.errs[source] = err
+}
+
+// restore adds the totals of the runs a resumed scan continues
+func (c *counters) restore(s types.Stats) {
+	c.total += s.Total
+	c.processed += s.Processed
+	c.suspicious += s.Suspicious
+	c.outOfScope += s.OutOfScope
+	c.known += s.Known
+	c.repeated += s.Repeated
+	c.unresolved += s.Unresolved
+	c.falsePositives += s.FalsePositives
+	c.otherShards += s.OtherShards
+	for i, sev := range suspicious.Severities {
+		c.severities[i] += s.Severities[sev]
+	}
+	c.start = c.start.Add(-time.Duration(s.Seconds * float64(time.Second)))
+	for _, src := range s.Sources {
+		if sc := c.bySource[src.Source]; sc != nil {
+			sc.total += src.Total
+			sc.processed += src.Processed
+			sc.suspicious += src.Suspicious
+			sc.outOfScope += src.OutOfScope
+		}
+	}
+}
+
+// telemetry returns the totals exported as OTLP metrics
+func (c *counters) telemetry() otlp.Counts {
+	counts := otlp.Counts{
+		Start:     c.start,
+		Read:      atomic.LoadUint64(&c.total),
+		Processed: atomic.LoadUint64(&c.processed),
+		Findings:  atomic.LoadUint64(&c.suspicious),
+		Bytes:     atomic.LoadUint64(&c.bytesRead),
+		Probes:    atomic.LoadUint64(&c.probes),
+	}
+	c.categoryMu.Lock()
+	counts.Categories = maps.Clone(c.categories)
+	c.categoryMu.Unlock()
+	return counts
+}
+
+// stats snapshots the counters
+func (c *counters) stats() types.Stats {
+	elapsed := time.Since(c.start)
+	s := types.Stats{
+		Total:          atomic.LoadUint64(&c.total),
+		Processed:      atomic.LoadUint64(&c.processed),
+		Suspicious:     atomic.LoadUint64(&c.suspicious),
+		OutOfScope:     atomic.LoadUint64(&c.outOfScope),
+		Known:          atomic.LoadUint64(&c.known),
+		OtherShards:    atomic.LoadUint64(&c.otherShards),
+		Quarantined:    atomic.LoadUint64(&c.quarantined),
+		Duplicates:     atomic.LoadUint64(&c.duplicates),
+		Repeated:       atomic.LoadUint64(&c.repeated),
+		Unresolved:     atomic.LoadUint64(&c.unresolved),
+		FalsePositives: atomic.LoadUint64(&c.falsePositives),
+		Seconds:        elapsed.Seconds(),
+		Sources:        make([]types.SourceStats, 0, len(c.sources)),
+	}
+	if s.Seconds > 0 {
+		s.Rate = float64(s.Processed) / s.Seconds
+	}
+	if c.tech != nil {
+		s.Tech = c.tech.Hosts()
+	}
+	for i, sev := range suspicious.Severities {
+		if n := atomic.LoadUint64(&c.severities[i]); n > 0 {
+			if s.Severities == nil {
+				s.Severities = make(map[string]uint64)
+			}
+			s.Severities[sev] = n
+		}
+	}
+	c.ruleMu.Lock()
+	if len(c.capped) > 0 {
+		s.Capped = maps.Clone(c.capped)
+	}
+	c.ruleMu.Unlock()
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	for _, name := range c.sources {
+		sc := c.bySource[name]
+		src := types.SourceStats{
+			Source:     name,
+			Total:      atomic.LoadUint64(&sc.total),
+			Processed:  atomic.LoadUint64(&sc.processed),
+			Suspicious: atomic.LoadUint64(&sc.suspicious),
+			OutOfScope: atomic.LoadUint64(&sc.outOfScope),
+		}
+		if err := c.errs[name]; err != nil {
+			src.Error = err.Error()
+			s.Failed++
+		}
+		s.Sources = append(s.Sources, src)
+	}
+	return s
+}
+
+// PartialError is returned by Scan when its context ends before the input
+// is exhausted. The results returned alongside it are the findings made
+// up to that point.
+type PartialError struct {
+	Processed uint64 // URLs checked before the scan stopped
+	Err       error  // The context's error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("scan stopped after %d URLs: %v", e.Processed, e.Err)
+}
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// ProcessFile scans the configured input and writes findings to
+// cfg.OutputPath
 func ProcessFile(ctx context.Context, cfg *config.Config) error {
-	// 1) Open & stat
-	f, err := os.Open(cfg.FilePath)
+	consume := func(results <-chan types.Result) error {
+		return writer.WriteStream(ctx, results, cfg.OutputPath, cfg.Format, cfg.Verbose, cfg.MaxFieldLength)
+	}
+	if cfg.Sort {
+		consume = func(results <-chan types.Result) error {
+			return writeSorted(ctx, cfg, results)
+		}
+	}
+	if cp := cfg.Checkpoint; cp != nil {
+		consume = func(results <-chan types.Result) error {
+			f, err := os.OpenFile(cfg.OutputPath, os.O_WRONLY|os.O_CREATE, 0o644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			// Output written after the checkpoint was saved is dropped; its
+			// findings are made again
+			if err := f.Truncate(cp.OutputSize); err != nil {
+				return err
+			}
+			if _, err := f.Seek(cp.OutputSize, io.SeekStart); err != nil {
+				return err
+			}
+			return writer.WriteStreamTo(ctx, results, cp.Output(f), cfg.Format, cfg.Verbose, cfg.MaxFieldLength, func(r types.Result) {
+				cp.Done(r.Source, r.Line, checkpoint.Found)
+			})
+		}
+	}
+	var sink *sqlsink.Sink
+	if sqlsink.IsTarget(cfg.OutputPath) {
+		var err error
+		if sink, err = sqlsink.Open(ctx, cfg.OutputPath, cfg.URLChecker.RulesHash()); err != nil {
+			return err
+		}
+		consume = func(results <-chan types.Result) error {
+			return sink.Write(ctx, results)
+		}
+	}
+	if open, ok := writer.LookupSink(cfg.OutputPath); ok {
+		custom, err := open(ctx, cfg.OutputPath)
+		if err != nil {
+			return err
+		}
+		if c, ok := custom.(io.Closer); ok {
+			defer c.Close()
+		}
+		consume = func(results <-chan types.Result) error {
+			return writer.WriteSink(ctx, results, custom, cfg.MaxFieldLength)
+		}
+	}
+	if cfg.PostgresDSN != "" {
+		pg, err := sqlsink.OpenPostgres(ctx, cfg.PostgresDSN)
+		if err != nil {
+			return err
+		}
+		defer pg.Close()
+		consume = func(results <-chan types.Result) error {
+			return pg.Write(ctx, results)
+		}
+	}
+	if cfg.Elasticsearch != "" {
+		client, err := netclient.New(cfg.Net)
+		if err != nil {
+			return err
+		}
+		es, err := essink.Open(ctx, client, cfg.Elasticsearch)
+		if err != nil {
+			return err
+		}
+		es.MaxFieldLength = cfg.MaxFieldLength
+		consume = func(results <-chan types.Result) error {
+			return es.Write(ctx, results)
+		}
+	}
+	if cfg.Webhook != "" {
+		client, err := netclient.New(cfg.Net)
+		if err != nil {
+			return err
+		}
+		hook, err := webhook.New(client, cfg.Webhook)
+		if err != nil {
+			return err
+		}
+		hook.MaxFieldLength = cfg.MaxFieldLength
+		consume = func(results <-chan types.Result) error {
+			return hook.Write(ctx, results)
+		}
+	}
+	if cfg.SyslogOut != "" {
+		sl, err := syslogsink.Dial(ctx, cfg.SyslogOut, cfg.Net.DialContext)
+		if err != nil {
+			return err
+		}
+		defer sl.Close()
+		sl.MaxFieldLength = cfg.MaxFieldLength
+		consume = func(results <-chan types.Result) error {
+			return sl.Write(ctx, results)
+		}
+	}
+	if len(cfg.Routes) > 0 {
+		routes, err := openRoutes(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		rest := consume
+		consume = func(results <-chan types.Result) error {
+			return routeResults(results, routes, rest)
+		}
+	}
+	var notifier *notify.Notifier
+	if cfg.Notify != "" {
+		client, err := netclient.New(cfg.Net)
+		if err != nil {
+			return err
+		}
+		if notifier, err = notify.New(client, cfg.Notify, cfg.NotifySeverity); err != nil {
+			return err
+		}
+		write := consume
+		consume = func(results <-chan types.Result) error {
+			return write(each(ctx, results, func(r types.Result) {
+				// A failed message is logged and the scan carries on
+				if err := notifier.Finding(ctx, r); err != nil && cfg.Logger != nil && ctx.Err() == nil {
+					cfg.Logger.Warn("finding notification failed", "url", r.URL, "err", err)
+				}
+			}))
+		}
+	}
+	var mail *digest.Digest
+	if cfg.Email != "" {
+		// The email goes out at the end; offline, fail before scanning
+		if cfg.Net.Offline {
+			return netclient.ErrOffline
+		}
+		tlsConfig, err := cfg.Net.TLSConfig()
+		if err != nil {
+			return err
+		}
+		if mail, err = digest.New(cfg.Email, cfg.Net.DialContext, tlsConfig); err != nil {
+			return err
+		}
+		write := consume
+		consume = func(results <-chan types.Result) error {
+			return write(each(ctx, results, mail.Add))
+		}
+	}
+	c, err := run(ctx, cfg, consume)
+	if c != nil && cfg.Finished != nil {
+		cfg.Finished(c.stats())
+	}
+	if c != nil && cfg.StatsPath != "" {
+		if err := writeStats(cfg.StatsPath, c.stats()); err != nil {
+			return err
+		}
+	}
+	if sink != nil {
+		var s types.Stats
+		if c != nil {
+			s = c.stats()
+		}
+		if err := sink.Close(s); err != nil {
+			return err
+		}
+	}
+	if notifier != nil && c != nil {
+		nerr := notifier.Summary(context.WithoutCancel(ctx), c.stats(), ctx.Err() != nil)
+		if nerr != nil && (err == nil || err == context.DeadlineExceeded) {
+			return nerr
+		}
+	}
+	if mail != nil && c != nil {
+		merr := mail.Send(context.WithoutCancel(ctx), c.stats(), ctx.Err() != nil, cfg.Baseline != nil)
+		if merr != nil && (err == nil || err == context.DeadlineExceeded) {
+			return merr
+		}
+	}
+	if err == context.DeadlineExceeded {
+		if cfg.Verbose {
+			fmt.Println("⏱  Timeout reached, partial results written.")
+		}
+		return nil
+	}
+
+	// Final stats, broken down when there were several sources
+	if cfg.Verbose && c != nil {
+		s := c.stats()
+		fmt.Printf(
+			"Total: %d processed: %d suspicious: %d out of scope: %d rate: %.0f URLs/sec\n",
+			s.Total, s.Processed, s.Suspicious, s.OutOfScope, s.Rate,
+		)
+		if cfg.Baseline != nil {
+			fmt.Printf("Known from the baseline: %d new: %d\n", s.Known, s.Suspicious-s.Known)
+		}
+		if cfg.Shard != nil {
+			fmt.Printf("Shard %s: %d URLs left to other shards\n", cfg.Shard, s.OtherShards)
+		}
+		if cfg.Unique != nil {
+			fmt.Printf("Duplicates: %d\n", s.Duplicates)
+		}
+		if cfg.Dedup != nil {
+			fmt.Printf("Repeated findings: %d\n", s.Repeated)
+		}
+		if cfg.FalsePositives != nil {
+			fmt.Printf("Known false positives: %d\n", s.FalsePositives)
+		}
+		if cfg.Verify != nil {
+			fmt.Printf("Findings dropped on dead hosts: %d\n", s.Unresolved)
+		}
+		if cfg.ErrorsPath != "" {
+			fmt.Printf("Quarantined %d lines to %s\n", s.Quarantined, cfg.ErrorsPath)
+		}
+		if len(s.Tech) > 0 {
+			fmt.Println("Tech stack (URLs per technology):")
+			techstack.Write(os.Stdout, s.Tech)
+		}
+		if len(s.Sources) > 1 {
+			for _, src := range s.Sources {
+				fmt.Printf("  %s: total: %d processed: %d suspicious: %d out of scope: %d\n",
+					src.Source, src.Total, src.Processed, src.Suspicious, src.OutOfScope)
+				if src.Error != "" {
+					fmt.Printf("    failed: %s\n", src.Error)
+				}
+			}
+		}
+		if s.Failed > 0 {
+			fmt.Printf("Inputs failed: %d of %d\n", s.Failed, len(s.Sources))
+		}
+	}
+
+	// Capped findings are always reported, so none go missing unnoticed
+	if c != nil && cfg.Logger != nil {
+		capped := c.stats().Capped
+		ids := make([]string, 0, len(capped))
+		for id := range capped {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			cfg.Logger.Warn("findings over -max-per-rule not written", "rule", id, "count", capped[id])
+		}
+	}
+
+	return err
+}
+
+// routes holds the writers of -route targets
+type routes struct {
+	writers    []func(<-chan types.Result) error // One per target
+	byCategory map[string]int                    // Index of each routed category's writer
+}
+
+// openRoutes sets up the writer of each route target. Routes sharing a
+// target share its writer.
+func openRoutes(ctx context.Context, cfg *config.Config) (*routes, error) {
+	rs := &routes{byCategory: make(map[string]int)}
+	byTarget := make(map[string]int)
+	for _, r := range cfg.Routes {
+		i, ok := byTarget[r.Target]
+		if !ok {
+			var write func(<-chan types.Result) error
+			switch r.Kind {
+			case route.KindChat:
+				client, err := netclient.New(cfg.Net)
+				if err != nil {
+					return nil, err
+				}
+				// Every finding is posted, up to the notifier's cap
+				n, err := notify.New(client, r.Target, suspicious.Severities[0])
+				if err != nil {
+					return nil, err
+				}
+				write = func(in <-chan types.Result) error {
+					for f := range in {
+						if err := n.Finding(ctx, f); err != nil && cfg.Logger != nil && ctx.Err() == nil {
+							cfg.Logger.Warn("routed finding not posted", "url", f.URL, "err", err)
+						}
+					}
+					return n.Overflow(context.WithoutCancel(ctx))
+				}
+			case route.KindSink:
+				open, _ := writer.LookupSink(r.Target)
+				sink, err := open(ctx, r.Target)
+				if err != nil {
+					return nil, err
+				}
+				write = func(in <-chan types.Result) error {
+					if c, ok := sink.(io.Closer); ok {
+						defer c.Close()
+					}
+					return writer.WriteSink(ctx, in, sink, cfg.MaxFieldLength)
+				}
+			default:
+				path, format := r.Target, r.Format
+				if path == "-" {
+					path = ""
+				}
+				if format == "" {
+					format = cfg.Format
+				}
+				write = func(in <-chan types.Result) error {
+					return writer.WriteStream(ctx, in, path, format, cfg.Verbose, cfg.MaxFieldLength)
+				}
+			}
+			i = len(rs.writers)
+			rs.writers = append(rs.writers, write)
+			byTarget[r.Target] = i
+		}
+		rs.byCategory[r.Category] = i
+	}
+	return rs, nil
+}
+
+// routeResults hands each finding to the writer routed its category, and
+// the others on to rest. Writers run side by side; one that fails stops
+// taking findings, and its error is returned once the others are done.
+func routeResults(results <-chan types.Result, rs *routes, rest func(<-chan types.Result) error) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var first error
+	start := func(write func(<-chan types.Result) error) chan types.Result {
+		ch := make(chan types.Result, 64)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := write(ch); err != nil {
+				mu.Lock()
+				if first == nil {
+					first = err
+				}
+				mu.Unlock()
+			}
+			for range ch {
+				// Drained so the other writers keep getting theirs
+			}
+		}()
+		return ch
+	}
+	chans := make([]chan types.Result, len(rs.writers))
+	for i, write := range rs.writers {
+		chans[i] = start(write)
+	}
+	others := start(rest)
+	for r := range results {
+		if i, ok := rs.byCategory[r.Category]; ok {
+			chans[i] <- r
+		} else {
+			others <- r
+		}
+	}
+	for _, ch := range chans {
+		close(ch)
+	}
+	close(others)
+	wg.Wait()
+	return first
+}
+
+// writeSorted collects the findings until the scan ends, then writes them
+// to cfg.OutputPath in extsort.Compare order. A scan cut short still has
+// what it found written, sorted.
+func writeSorted(ctx context.Context, cfg *config.Config, results <-chan types.Result) error {
+	s := extsort.New(cfg.SortMemory, "")
+	defer s.Close()
+	// Near the memory limit, runs are spilled before they reach SortMemory
+	var mem *memlimit.Monitor
+	if cfg.MaxMemory > 0 {
+		mem = memlimit.Start(ctx, cfg.MaxMemory)
+		defer mem.Stop()
+	}
+	var stopped error
+collect:
+	for {
+		select {
+		case <-ctx.Done():
+			stopped = ctx.Err()
+			break collect
+		case r, ok := <-results:
+			if !ok {
+				break collect
+			}
+			if err := s.Add(r); err != nil {
+				return err
+			}
+			if mem.Pressure() && s.Held() >= extsort.MinRun {
+				if err := s.Spill(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if cfg.Verbose && s.Runs() > 0 {
+		fmt.Printf("Merging %d sorted runs...\n", s.Runs()+1)
+	}
+
+	sorted := make(chan types.Result)
+	done := make(chan struct{})
+	merged := make(chan error, 1)
+	go func() {
+		defer close(sorted)
+		merged <- s.Each(func(r types.Result) error {
+			select {
+			case sorted <- r:
+				return nil
+			case <-done:
+				return context.Canceled // The writer gave up
+			}
+		})
+	}()
+	err := writer.WriteStream(context.WithoutCancel(ctx), sorted, cfg.OutputPath, cfg.Format, cfg.Verbose, cfg.MaxFieldLength)
+	close(done)
+	if merr := <-merged; err == nil && merr != context.Canceled {
+		err = merr
+	}
+	if err == nil {
+		err = stopped
+	}
+	return err
+}
+
+// each passes findings through to the output, handing each to fn on the
+// way
+func each(ctx context.Context, in <-chan types.Result, fn func(types.Result)) <-chan types.Result {
+	out := make(chan types.Result)
+	go func() {
+		defer close(out)
+		for r := range in {
+			fn(r)
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// writeStats saves a scan's statistics as JSON
+func writeStats(path string, s types.Stats) error {
+	b, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	if cfg.Verbose {
-		fmt.Printf("Streaming %s...\n", cfg.FilePath)
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Scan checks the configured input and returns the findings in memory.
+// If ctx ends first, it returns the findings gathered so far together
+// with a *PartialError.
+func Scan(ctx context.Context, cfg *config.Config) ([]types.Result, error) {
+	var found []types.Result
+	c, err := run(ctx, cfg, func(results <-chan types.Result) error {
+		// Drain until the workers exit so nothing already sent is lost
+		for r := range results {
+			found = append(found, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return found, err
+	}
+	if ctx.Err() != nil {
+		return found, &PartialError{Processed: atomic.LoadUint64(&c.processed), Err: ctx.Err()}
 	}
+	return found, nil
+}
 
-	startTime := time.Now()
+// Stream checks the configured input and delivers findings on the
+// returned channel, which is closed when the input is exhausted or ctx
+// ends. Errors opening the input are returned before any finding.
+func Stream(ctx context.Context, cfg *config.Config) (<-chan types.Result, error) {
+	out := make(chan types.Result)
+	started := make(chan error, 1)
+	go func() {
+		defer close(out)
+		_, err := run(ctx, cfg, func(results <-chan types.Result) error {
+			started <- nil
+			for r := range results {
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			select {
+			case started <- err:
+			default: // Findings were already flowing
+			}
+		}
+	}()
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// run starts the reader and workers and hands their findings to consume.
+// With cfg.Telemetry set, the scan is traced and its metrics exported.
+func run(ctx context.Context, cfg *config.Config, consume func(<-chan types.Result) error) (*counters, error) {
+	if cfg.Telemetry == nil {
+		return pipeline(ctx, cfg, consume, nil)
+	}
+	span := cfg.Telemetry.Start("scan")
+	span.Set("juicyurls.rules_hash", cfg.URLChecker.RulesHash())
+	c, err := pipeline(ctx, cfg, consume, span)
+	span.Fail(err)
+	span.End()
+	// Exporting is best effort: the scan's results are already written
+	export := context.WithoutCancel(ctx)
+	if err := cfg.Telemetry.ExportTrace(export, span); err != nil && cfg.Logger != nil {
+		cfg.Logger.Error("exporting trace failed", "err", err)
+	}
+	if c != nil {
+		if err := cfg.Telemetry.ExportMetrics(export, c.telemetry()); err != nil && cfg.Logger != nil {
+			cfg.Logger.Error("exporting metrics failed", "err", err)
+		}
+	}
+	return c, err
+}
+
+// pipeline runs a scan, timing its read, match, probe and write stages as
+// children of span
+func pipeline(ctx context.Context, cfg *config.Config, consume func(<-chan types.Result) error, span *otlp.Span) (*counters, error) {
+	// 1) Open input files, if any, resuming from stored offsets
+	var paths []string
+	if cfg.FilePath != "" {
+		paths = append(paths, cfg.FilePath)
+	}
+	paths = append(paths, cfg.InputFiles...)
+	var store *offsets.Store
+	if cfg.StatePath != "" {
+		var err error
+		if store, err = offsets.Load(cfg.StatePath); err != nil {
+			return nil, err
+		}
+	}
+	// With several inputs, one that cannot be opened is reported and
+	// skipped rather than failing the scan
+	inputs := len(paths) + len(cfg.Streams)
+	if cfg.Reader != nil {
+		inputs++
+	}
+	if len(cfg.URLs) > 0 {
+		inputs++
+	}
+	var sources []source
+	var files []*os.File
+	open := func(name, key string, resume func(*os.File) (offsets.Position, error)) error {
+		f, err := os.Open(name)
+		if err != nil {
+			if inputs == 1 {
+				return err
+			}
+			sources = append(sources, source{name: name, err: err})
+			return nil
+		}
+		src := source{name: name, key: key, r: f}
+		if resume != nil {
+			if src.start, err = resume(f); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		if cfg.Follow {
+			if src.r, err = input.Follow(ctx, f); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		sources = append(sources, src)
+		files = append(files, f)
+		return nil
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	cp := cfg.Checkpoint
+	for _, path := range paths {
+		if store == nil {
+			var resume func(*os.File) (offsets.Position, error)
+			if cp != nil {
+				resume = func(f *os.File) (offsets.Position, error) { return cp.Resume(path, f) }
+			}
+			if err := open(path, "", resume); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Finish the file a log rotation renamed before starting the new one
+		if rotated, pos, ok := store.Rotated(path); ok {
+			err := open(rotated, "", func(f *os.File) (offsets.Position, error) {
+				_, err := f.Seek(pos.Offset, io.SeekStart)
+				return pos, err
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := open(path, path, func(f *os.File) (offsets.Position, error) {
+			return store.Resume(path, f)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Reader != nil {
+		sources = append(sources, source{name: ReaderSource, r: cfg.Reader})
+	}
+
+	names := make([]string, 0, len(sources)+1+len(cfg.Streams))
+	for _, src := range sources {
+		names = append(names, src.name)
+	}
+	if len(cfg.URLs) > 0 {
+		names = append(names, ArgsSource)
+	}
+	for _, s := range cfg.Streams {
+		names = append(names, s.Name)
+	}
+	c := newCounters(names)
+	var bad *quarantine.Writer
+	if cfg.ErrorsPath != "" {
+		var err error
+		if bad, err = quarantine.Create(cfg.ErrorsPath); err != nil {
+			return nil, err
+		}
+		defer bad.Close()
+	}
+	// reject quarantines a line that cannot be checked
+	reject := func(source string, line int, text, reason string) {
+		if bad != nil {
+			atomic.AddUint64(&c.quarantined, 1)
+			bad.Add(source, line, text, reason)
+		}
+	}
+	for _, src := range sources {
+		if src.err != nil {
+			c.fail(src.name, src.err)
+			if cfg.Logger != nil {
+				cfg.Logger.Error("input failed, skipping it", "source", src.name, "err", src.err)
+			}
+		}
+	}
+	if cfg.TechStack {
+		c.tech = techstack.NewProfile()
+	}
+	if cp != nil {
+		c.restore(cp.Stats)
+	}
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
 	// 2) Channels & atomics
@@ -35,64 +979,569 @@ func ProcessFile(ctx context.Context, cfg *config.Config) error {
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
-	urlChan := make(chan string, workers*100)
+	readers := cfg.Readers
+	if readers <= 0 {
+		readers = max(1, workers/4)
+	}
+	urlChan := make(chan entry, workers*100)
 	resultsChan := make(chan types.Result, workers*10)
+	m := cfg.Metrics
+
+	// Near the memory limit, the reader holds back until the URLs and
+	// findings in flight have been dealt with
+	var mem *memlimit.Monitor
+	if cfg.MaxMemory > 0 {
+		mem = memlimit.Start(ctx, cfg.MaxMemory)
+		defer mem.Stop()
+	}
+	inFlight := func() bool { return len(urlChan) > 0 || len(resultsChan) > 0 }
+	var throttled atomic.Bool
+	m.Workers(workers)
+	defer m.Workers(-workers)
+
+	// Findings carry the rule set's hash so runs can be compared
+	rulesHash := cfg.URLChecker.RulesHash()
+	if cfg.Verbose {
+		fmt.Printf("Rules %s\n", rulesHash)
+	}
+
+	// Heartbeat runs until processing finishes
+	if cfg.Heartbeat > 0 {
+		hbCtx, stopHeartbeat := context.WithCancel(ctx)
+		defer stopHeartbeat()
+		go heartbeat.Run(hbCtx, cfg.Logger, cfg.Heartbeat, func() heartbeat.Snapshot {
+			return heartbeat.Snapshot{
+				Consumed:   atomic.LoadUint64(&c.processed),
+				Emitted:    atomic.LoadUint64(&c.emitted),
+				QueueDepth: len(urlChan),
+			}
+		})
+	}
+
+	// Metrics of long scans are exported as they go
+	if cfg.Telemetry != nil {
+		exportCtx, stopExporting := context.WithCancel(ctx)
+		defer stopExporting()
+		go func() {
+			ticker := time.NewTicker(otlp.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-exportCtx.Done():
+					return
+				case <-ticker.C:
+					if err := cfg.Telemetry.ExportMetrics(exportCtx, c.telemetry()); err != nil && cfg.Logger != nil {
+						cfg.Logger.Error("exporting metrics failed", "err", err)
+					}
+				}
+			}
+		}()
+	}
+
+	// Progress callback runs on its own goroutine so calls never overlap
+	report := func(done bool) {
+		p := types.Progress{
+			Processed: atomic.LoadUint64(&c.processed),
+			Matched:   atomic.LoadUint64(&c.suspicious),
+			Skipped:   atomic.LoadUint64(&c.outOfScope),
+			BytesRead: atomic.LoadUint64(&c.bytesRead),
+			Elapsed:   time.Since(c.start),
+			Done:      done,
+		}
+		if secs := p.Elapsed.Seconds(); secs > 0 {
+			p.Rate = float64(p.Processed) / secs
+		}
+		cfg.Progress(p)
+	}
+	if cfg.Progress != nil {
+		every := cfg.ProgressEvery
+		if every <= 0 {
+			every = config.DefaultProgress
+		}
+		progressCtx, stopProgress := context.WithCancel(ctx)
+		progressDone := make(chan struct{})
+		go func() {
+			defer close(progressDone)
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-progressCtx.Done():
+					return
+				case <-ticker.C:
+					report(false)
+				}
+			}
+		}()
+		defer func() {
+			stopProgress()
+			<-progressDone
+			report(true)
+		}()
+	}
 
-	var total, processed, suspicious uint64
+	// Distinct input URLs are passed through as they are read, flushed
+	// whenever the reader catches up
+	var seen *dedup.Set
+	var passed chan string
+	var passing sync.WaitGroup
+	if cfg.Unique != nil {
+		seen = dedup.NewSet()
+		passed = make(chan string, workers*100)
+		passing.Add(1)
+		go func() {
+			defer passing.Done()
+			w := bufio.NewWriter(cfg.Unique)
+			defer w.Flush()
+			for u := range passed {
+				w.WriteString(u)
+				w.WriteByte('\n')
+				if len(passed) == 0 {
+					w.Flush()
+				}
+			}
+		}()
+	}
 
 	// 3) Reader
+	reading := span.Child("read")
 	var readerWG sync.WaitGroup
 	readerWG.Add(1)
 	go func() {
 		defer readerWG.Done()
-		scanner := bufio.NewScanner(f)
-		buf := make([]byte, config.BufferSize)
-		scanner.Buffer(buf, config.BufferSize)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "" || line[0] == '#' || line[0] == '/' {
-				continue
+		hostPorts := cfg.InputFormat == input.FormatHostPort
+		queue := func(e entry) bool {
+			if mem.Pressure() {
+				if !throttled.Swap(true) && cfg.Logger != nil {
+					cfg.Logger.Warn("memory near the limit, reading less ahead", "used", mem.Used(), "limit", cfg.MaxMemory)
+				}
+				if !mem.Wait(ctx, inFlight) {
+					return false
+				}
+			}
+			atomic.AddUint64(&c.total, 1)
+			atomic.AddUint64(&c.bySource[e.source].total, 1)
+			if seen != nil {
+				if !seen.Add(fingerprint.Normalize(e.url)) {
+					atomic.AddUint64(&c.duplicates, 1)
+					return true
+				}
+				select {
+				case <-ctx.Done():
+					return false
+				case passed <- e.url:
+				}
 			}
-			atomic.AddUint64(&total, 1)
 			select {
 			case <-ctx.Done():
+				return false
+			case urlChan <- e:
+				return true
+			}
+		}
+		send := func(line string, size int, source string, pos offsets.Position) bool {
+			atomic.AddUint64(&c.bytesRead, uint64(size))
+			if line == "" || line[0] == '#' || line[0] == '/' {
+				return true
+			}
+			if hostPorts {
+				u, ok := input.HostPort(line)
+				if !ok {
+					reject(source, pos.Line, line, "not a web host:port")
+					return true
+				}
+				line = u
+			}
+			if cp != nil {
+				cp.Track(source, pos)
+			}
+			return queue(entry{url: line, source: source, line: pos.Line})
+		}
+		// extract queues the URLs found anywhere in text, which starts at
+		// byte offset of the source
+		extract := func(text string, size int, source string, line int, offset int64) bool {
+			atomic.AddUint64(&c.bytesRead, uint64(size))
+			for _, loc := range input.FindURLs(text) {
+				e := entry{
+					url:     text[loc[0]:loc[1]],
+					source:  source,
+					line:    line,
+					offset:  offset + int64(loc[0]),
+					context: input.Context(text, loc[0], loc[1], contextChars),
+				}
+				if !queue(e) {
+					return false
+				}
+			}
+			return true
+		}
+
+		// A read error ends its source only; the lines before it were
+		// checked
+		failed := func(src source, line int, err error) {
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, bufio.ErrTooLong) {
+				reject(src.name, line+1, "", err.Error())
+			}
+			c.fail(src.name, err)
+			if cfg.Logger != nil {
+				cfg.Logger.Error("input failed, skipping the rest of it", "source", src.name, "line", line, "err", err)
+			}
+		}
+		// readRanges reads a mapped file with several goroutines, each
+		// taking the next range of lines. Line numbers come from counting
+		// the newlines of the ranges before it first. Checkpoints and
+		// -unique need lines in order, so they keep to one reader.
+		readRanges := func(data []byte, src source) bool {
+			chunks := input.Chunks(data, readers*4)
+			starts := make([]offsets.Position, len(chunks))
+			parallel(len(chunks), readers, func(i int) {
+				starts[i].Line = bytes.Count(chunks[i], []byte{'\n'})
+				starts[i].Offset = int64(len(chunks[i]))
+			})
+			pos := src.start
+			for i, n := range starts {
+				starts[i] = pos
+				pos.Line += n.Line
+				pos.Offset += n.Offset
+			}
+
+			var stop, canceled atomic.Bool // stop is set on cancellation or a read error
+			errs := make([]error, len(chunks))
+			ends := make([]int, len(chunks))
+			parallel(len(chunks), readers, func(i int) {
+				pos := starts[i]
+				errs[i] = input.Lines(chunks[i], config.BufferSize, func(line string, size int) bool {
+					if stop.Load() {
+						return false
+					}
+					pos.Line++
+					pos.Offset += int64(size)
+					if !send(line, size, src.name, pos) {
+						canceled.Store(true)
+						stop.Store(true)
+						return false
+					}
+					return true
+				})
+				ends[i] = pos.Line
+				if errs[i] != nil {
+					stop.Store(true)
+				}
+			})
+			for i, err := range errs {
+				if err != nil {
+					failed(src, ends[i], err)
+					break
+				}
+			}
+			return !canceled.Load()
+		}
+		read := func(src source, buf []byte) bool {
+			if src.err != nil {
+				return true
+			}
+			if cfg.Verbose {
+				fmt.Printf("Streaming %s...\n", src.name)
+			}
+			// Large files are mapped rather than copied through the
+			// scanner, unless they may still grow or lines are cut
+			if !cfg.Follow && !cfg.Extract && store == nil {
+				if data, unmap, ok := mapped(src); ok {
+					if readers > 1 && cp == nil && seen == nil {
+						more := readRanges(data, src)
+						unmap()
+						return more
+					}
+					pos := src.start
+					more := true
+					err := input.Lines(data, config.BufferSize, func(line string, size int) bool {
+						pos.Line++
+						pos.Offset += int64(size)
+						more = send(line, size, src.name, pos)
+						return more
+					})
+					unmap()
+					failed(src, pos.Line, err)
+					return more
+				}
+			}
+			scanner := bufio.NewScanner(src.r)
+			scanner.Buffer(buf, config.BufferSize)
+			pos := src.start
+			var start int64 // Offset of the last token
+			cut := false    // The last token ends inside a line
+			if store != nil || cp != nil || cfg.Extract {
+				scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+					cut = false
+					var advance int
+					var token []byte
+					var err error
+					switch {
+					case cfg.Extract && len(data) >= config.BufferSize && bytes.IndexByte(data, '\n') < 0:
+						// A line longer than the buffer, as in a minified
+						// bundle, is cut where no URL goes on
+						i := bytes.LastIndexAny(data, " \t\"'<>`")
+						if i < 0 {
+							i = len(data) - 1
+						}
+						advance, token, cut = i+1, data[:i+1], true
+					case store != nil && bytes.IndexByte(data, '\n') < 0:
+						// Leave a line still being written for the next run
+						return 0, nil, nil
+					default:
+						advance, token, err = bufio.ScanLines(data, atEOF)
+					}
+					start = pos.Offset
+					pos.Offset += int64(advance)
+					return advance, token, err
+				})
+			}
+			continued := false
+			for scanner.Scan() {
+				if !continued {
+					pos.Line++
+				}
+				size := len(scanner.Bytes()) + 1
+				if cut {
+					size--
+				}
+				continued = cut
+				if cfg.Extract {
+					if !extract(scanner.Text(), size, src.name, pos.Line, start) {
+						return false
+					}
+				} else if !send(scanner.Text(), size, src.name, pos) {
+					return false
+				}
+				if src.key != "" && !cut {
+					store.Set(src.key, pos)
+				}
+			}
+			failed(src, pos.Line, scanner.Err())
+			return true
+		}
+		if cfg.Follow {
+			// Followed files never end, so they are read side by side
+			var followed sync.WaitGroup
+			for _, src := range sources {
+				followed.Add(1)
+				go func() {
+					defer followed.Done()
+					read(src, make([]byte, config.BufferSize))
+				}()
+			}
+			followed.Wait()
+			if ctx.Err() != nil {
 				return
-			case urlChan <- line:
 			}
+		} else {
+			buf := make([]byte, config.BufferSize)
+			for _, src := range sources {
+				if !read(src, buf) {
+					return
+				}
+			}
+		}
+		skip := 0
+		if cp != nil {
+			skip = cp.Position(ArgsSource).Line
+		}
+		for i, u := range cfg.URLs[min(skip, len(cfg.URLs)):] {
+			if !send(strings.TrimSpace(u), len(u), ArgsSource, offsets.Position{Line: skip + i + 1}) {
+				return
+			}
+		}
+
+		// Live streams run side by side until ctx ends; URLs are taken
+		// from anywhere in their messages
+		var streams sync.WaitGroup
+		for _, s := range cfg.Streams {
+			streams.Add(1)
+			go func() {
+				defer streams.Done()
+				n := 0
+				err := s.Read(ctx, func(line string) bool {
+					n++
+					atomic.AddUint64(&c.bytesRead, uint64(len(line)+1))
+					for _, u := range input.ExtractURLs(line) {
+						if !queue(entry{url: u, source: s.Name, line: n}) {
+							return false
+						}
+					}
+					return true
+				})
+				if err != nil {
+					c.fail(s.Name, err)
+					if cfg.Logger != nil {
+						cfg.Logger.Error("input stream failed", "source", s.Name, "err", err)
+					}
+				}
+			}()
 		}
+		streams.Wait()
 	}()
 
 	// 4) Close urlChan when reader finishes
 	go func() {
 		readerWG.Wait()
 		close(urlChan)
+		if passed != nil {
+			close(passed)
+		}
+		reading.Set("juicyurls.urls.read", atomic.LoadUint64(&c.total))
+		reading.Set("juicyurls.input.size", atomic.LoadUint64(&c.bytesRead))
+		reading.Set("juicyurls.sources", len(c.sources))
+		reading.End()
 	}()
 
 	// 5) Workers
+	matching := span.Child("match")
+	matching.Set("juicyurls.workers", workers)
+	var probing *otlp.Span // Liveness checks, made by the workers as they go
+	if cfg.Verify != nil {
+		probing = span.Child("probe")
+	}
 	var workerWG sync.WaitGroup
 	for i := 0; i < workers; i++ {
 		workerWG.Add(1)
 		go func() {
 			defer workerWG.Done()
 			uc := cfg.URLChecker
+			// A worker counts as busy until it runs out of URLs, so a
+			// full queue costs no metrics updates
+			busy := false
+			defer func() {
+				if busy {
+					m.Busy(-1)
+				}
+			}()
 			for {
+				if busy && len(urlChan) == 0 {
+					m.Busy(-1)
+					busy = false
+				}
 				select {
 				case <-ctx.Done():
 					return
-				case u, ok := <-urlChan:
+				case e, ok := <-urlChan:
 					if !ok {
 						return
 					}
-					atomic.AddUint64(&processed, 1)
-					if cfg.ValidateURLs && !checker.IsValidURL(u) {
+					if m != nil && !busy {
+						m.Busy(1)
+						busy = true
+					}
+					u := e.url
+					if cfg.Shard != nil && !cfg.Shard.Contains(u) {
+						atomic.AddUint64(&c.otherShards, 1)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.OtherShard)
+						}
+						continue
+					}
+					if cfg.Scope != nil && !cfg.Scope.Contains(u) {
+						atomic.AddUint64(&c.outOfScope, 1)
+						atomic.AddUint64(&c.bySource[e.source].outOfScope, 1)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.OutOfScope)
+						}
 						continue
 					}
-					if sus, cat, why := uc.IsSuspicious(u); sus {
-						atomic.AddUint64(&suspicious, 1)
+					atomic.AddUint64(&c.processed, 1)
+					atomic.AddUint64(&c.bySource[e.source].processed, 1)
+					m.Processed()
+					if cfg.ValidateURLs {
+						if err := checker.ValidateURL(u); err != nil {
+							reject(e.source, e.line, u, err.Error())
+							if cp != nil {
+								cp.Done(e.source, e.line, checkpoint.Clean)
+							}
+							continue
+						}
+					}
+					if c.tech != nil {
+						c.tech.Add(u)
+					}
+					var f checker.Finding
+					var score int
+					var sus bool
+					if cfg.Scoring || cfg.MinScore > 0 {
+						f, score, sus = uc.Score(u)
+						sus = sus && score >= cfg.MinScore
+					} else {
+						f, sus = uc.Check(u)
+					}
+					var fp string
+					if sus {
+						fp = fingerprint.Compute(u, f.RuleID)
+					}
+					switch {
+					case !sus:
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Clean)
+						}
+					case cfg.FalsePositives != nil && cfg.FalsePositives.Contains(u, f.RuleID, fp):
+						atomic.AddUint64(&c.falsePositives, 1)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Clean)
+						}
+					case cfg.Baseline != nil && cfg.Baseline.Contains(fp):
+						atomic.AddUint64(&c.known, 1)
+						c.found(e.source, f.Category)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Known)
+						}
+					case cfg.Verify != nil && !c.live(ctx, cfg.Verify, u):
+						atomic.AddUint64(&c.unresolved, 1)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Clean)
+						}
+					case cfg.Dedup != nil && !cfg.Dedup.Add(fp):
+						atomic.AddUint64(&c.repeated, 1)
+						c.found(e.source, f.Category)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Found)
+						}
+					case cp != nil && cp.Reported(e.source, e.line),
+						cfg.MaxPerRule > 0 && !c.allow(f.RuleID, cfg.MaxPerRule):
+						// Written before the scan was resumed, or over the limit
+						c.found(e.source, f.Category)
+						if cp != nil {
+							cp.Done(e.source, e.line, checkpoint.Found)
+						}
+					default:
+						c.found(e.source, f.Category)
+						if rank := suspicious.SeverityRank(f.Severity); rank >= 0 {
+							atomic.AddUint64(&c.severities[rank], 1)
+						}
+						m.Finding(f.Category)
+						cfg.Anomaly.Finding(f.Category)
 						select {
 						case <-ctx.Done():
 							return
-						case resultsChan <- types.Result{URL: u, Category: cat, Reason: why}:
+						case resultsChan <- types.Result{
+							URL:          strings.Clone(urlnorm.Display(u)), // Not to keep a mapped input's chunk
+							Category:     f.Category,
+							Reason:       f.Reason,
+							Severity:     f.Severity,
+							Confidence:   f.Confidence,
+							RuleID:       f.RuleID,
+							RuleSource:   f.RuleSource,
+							RulesHash:    rulesHash,
+							Fingerprint:  fp,
+							Score:        score,
+							Pattern:      f.Match.Pattern,
+							Component:    f.Match.Component,
+							Offset:       f.Match.Offset,
+							Source:       e.source,
+							Line:         e.line,
+							SourceOffset: e.offset,
+							Context:      e.context,
+						}:
+							atomic.AddUint64(&c.emitted, 1)
 						}
 					}
 				}
@@ -103,27 +1552,67 @@ func ProcessFile(ctx context.Context, cfg *config.Config) error {
 	// 6) Close resultsChan when all workers are done
 	go func() {
 		workerWG.Wait()
+		matching.Set("juicyurls.urls.processed", atomic.LoadUint64(&c.processed))
+		matching.Set("juicyurls.findings", atomic.LoadUint64(&c.suspicious))
+		matching.End()
+		probing.Set("juicyurls.probes", atomic.LoadUint64(&c.probes))
+		probing.Set("juicyurls.probe.seconds", time.Duration(atomic.LoadUint64(&c.probeNanos)).Seconds())
+		probing.Set("juicyurls.findings.unresolved", atomic.LoadUint64(&c.unresolved))
+		probing.End()
 		close(resultsChan)
 	}()
 
-	// 7) Writer—and wait until it’s done or context expires
-	err = writer.WriteStream(ctx, resultsChan, cfg.OutputPath, cfg.Verbose)
-	if err == context.DeadlineExceeded {
-		if cfg.Verbose {
-			fmt.Println("⏱  Timeout reached, partial results written.")
-		}
-		return nil
+	// Checkpoints are saved as the scan goes, in case it is killed
+	if cp != nil {
+		saveCtx, stopSaving := context.WithCancel(ctx)
+		defer stopSaving()
+		go func() {
+			ticker := time.NewTicker(checkpoint.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-saveCtx.Done():
+					return
+				case <-ticker.C:
+					if err := cp.Save(time.Since(c.start)); err != nil && cfg.Logger != nil {
+						cfg.Logger.Error("saving checkpoint failed", "err", err)
+					}
+				}
+			}
+		}()
 	}
 
-	// 8) Final stats
-	if cfg.Verbose {
-		elapsed := time.Since(startTime)
-		fmt.Printf(
-			"Total: %d processed: %d suspicious: %d rate: %.0f URLs/sec\n",
-			total, processed, suspicious,
-			float64(processed)/elapsed.Seconds(),
-		)
+	// 7) Consume results until the workers are done or consume gives up.
+	// Offsets are only stored after a complete run, so an interrupted one
+	// is re-read rather than skipped. A checkpoint is kept until the scan
+	// completes.
+	writing := span.Child("write")
+	err := consume(resultsChan)
+	writing.Fail(err)
+	writing.End()
+	if err == nil {
+		passing.Wait() // The reader is done, so the last URLs are on their way
 	}
-
-	return err
+	reading.End() // Readers still stopping after a cancel end with the scan
+	if bad != nil {
+		if cerr := bad.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		if cp != nil {
+			cp.Save(time.Since(c.start))
+		}
+		return c, err
+	}
+	if store != nil && ctx.Err() == nil {
+		return c, store.Save()
+	}
+	if cp != nil {
+		if ctx.Err() == nil {
+			return c, cp.Remove()
+		}
+		return c, cp.Save(time.Since(c.start))
+	}
+	return c, nil
 }
@@ -0,0 +1,771 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alwalxed/juicyurls/v2/config"
+	"github.com/alwalxed/juicyurls/v2/internal/baseline"
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+	"github.com/alwalxed/juicyurls/v2/internal/dedup"
+	"github.com/alwalxed/juicyurls/v2/internal/fingerprint"
+	"github.com/alwalxed/juicyurls/v2/internal/input"
+	"github.com/alwalxed/juicyurls/v2/internal/otlp"
+	"github.com/alwalxed/juicyurls/v2/internal/route"
+	"github.com/alwalxed/juicyurls/v2/internal/shard"
+	"github.com/alwalxed/juicyurls/v2/internal/triage"
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestProgress delivers a final report with the scan totals
+func TestProgress(t *testing.T) {
+	var reports []types.Progress
+	cfg := &config.Config{
+		URLs:       []string{"https://example.com/.env", "https://example.com/", "https://example.com/admin"},
+		OutputPath: filepath.Join(t.TempDir(), "out.txt"),
+		Workers:    2,
+		URLChecker: checker.NewURLChecker("", ""),
+		Progress:   func(p types.Progress) { reports = append(reports, p) },
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatalf("ProcessFile returned error: %v", err)
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("no progress reports")
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Processed != 3 || last.Matched != 2 || last.BytesRead != 69 {
+		t.Errorf("final report = %+v; want done, 3 processed, 2 matched, 69 bytes", last)
+	}
+}
+
+// TestScanPartial returns a typed error when the context ends early
+func TestScanPartial(t *testing.T) {
+	cfg := &config.Config{
+		URLs:       []string{"https://example.com/.env", "https://example.com/admin"},
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	results, err := Scan(context.Background(), cfg)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Scan = %d results, %v; want 2, nil", len(results), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Scan(ctx, cfg)
+	var partial *PartialError
+	if !errors.As(err, &partial) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan with canceled context: err = %v; want *PartialError wrapping context.Canceled", err)
+	}
+}
+
+// TestIncremental scans only lines appended since the last run, leaving
+// an unterminated line for later
+func TestIncremental(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "urls.log")
+	cfg := &config.Config{
+		FilePath:   log,
+		StatePath:  filepath.Join(dir, "state.json"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	scan := func(content string) []types.Result {
+		t.Helper()
+		f, err := os.OpenFile(log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(content)
+		f.Close()
+		results, err := Scan(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	if got := scan("https://example.com/.env\nhttps://example.com/ad"); len(got) != 1 || got[0].Line != 1 {
+		t.Fatalf("first run = %+v; want the .env finding on line 1", got)
+	}
+	got := scan("min\n")
+	if len(got) != 1 || got[0].URL != "https://example.com/admin" || got[0].Line != 2 {
+		t.Fatalf("second run = %+v; want the completed admin line only, on line 2", got)
+	}
+	if got := scan(""); len(got) != 0 {
+		t.Errorf("third run = %+v; want nothing new", got)
+	}
+}
+
+// TestFollow reports lines appended to a followed file, and holds back a
+// line until its newline is written
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.txt")
+	if err := os.WriteFile(path, []byte("https://example.com/.env\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	found, err := Stream(ctx, &config.Config{FilePath: path, Follow: true, URLChecker: checker.NewURLChecker("", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := <-found; r.URL != "https://example.com/.env" || r.Line != 1 {
+		t.Fatalf("first finding = %+v", r)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("https://example.com/backup.sql")
+	select {
+	case r := <-found:
+		t.Fatalf("finding %+v before the line was complete", r)
+	case <-time.After(3 * input.FollowPoll):
+	}
+	f.WriteString("\n")
+	if r := <-found; r.URL != "https://example.com/backup.sql" || r.Line != 2 {
+		t.Fatalf("appended finding = %+v", r)
+	}
+
+	cancel()
+	for range found {
+	}
+}
+
+// TestSourceStats breaks the totals down by input source
+func TestSourceStats(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("https://example.com/.env\nhttps://example.com/\n"), 0o644)
+	os.WriteFile(b, []byte("https://example.com/\n"), 0o644)
+	statsPath := filepath.Join(dir, "stats.json")
+	cfg := &config.Config{
+		FilePath:   a,
+		InputFiles: []string{b},
+		URLs:       []string{"https://example.com/backup.sql"},
+		OutputPath: filepath.Join(dir, "out.txt"),
+		StatsPath:  statsPath,
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got types.Stats
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []types.SourceStats{
+		{Source: a, Total: 2, Processed: 2, Suspicious: 1},
+		{Source: b, Total: 1, Processed: 1},
+		{Source: ArgsSource, Total: 1, Processed: 1, Suspicious: 1},
+	}
+	if got.Total != 4 || got.Suspicious != 2 || !slices.Equal(got.Sources, want) {
+		t.Errorf("stats = %+v; want sources %+v", got, want)
+	}
+}
+
+func TestMaxPerRule(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	os.WriteFile(in, []byte("https://example.com/a.php\nhttps://example.com/b.php\nhttps://example.com/c.php\nhttps://example.com/.env\n"), 0o644)
+	out, statsPath := filepath.Join(dir, "out.txt"), filepath.Join(dir, "stats.json")
+	cfg := &config.Config{
+		FilePath:   in,
+		OutputPath: out,
+		StatsPath:  statsPath,
+		MaxPerRule: 1,
+		Workers:    1,
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	written, _ := os.ReadFile(out)
+	if got := strings.Count(string(written), ".php"); got != 1 {
+		t.Errorf("wrote %d .php findings; want 1:\n%s", got, written)
+	}
+	raw, err := os.ReadFile(statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got types.Stats
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Suspicious != 4 || len(got.Capped) != 1 || got.Capped["extensions:.php"] != 2 {
+		t.Errorf("stats = %+v; want 4 suspicious, 2 capped for extensions:.php", got)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	last := filepath.Join(dir, "last.json")
+	os.WriteFile(last, []byte(`{"url":"https://example.com/.env","rule_id":"hidden:dotenv"}`+"\n"), 0o644)
+	b, err := baseline.Load(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, statsPath := filepath.Join(dir, "out.txt"), filepath.Join(dir, "stats.json")
+	cfg := &config.Config{
+		URLs:       []string{"https://example.com/.env", "https://example.com/backup.sql"},
+		OutputPath: out,
+		StatsPath:  statsPath,
+		Baseline:   b,
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	written, _ := os.ReadFile(out)
+	if strings.Contains(string(written), ".env") || !strings.Contains(string(written), "backup.sql") {
+		t.Errorf("output = %q; want only backup.sql", written)
+	}
+	raw, _ := os.ReadFile(statsPath)
+	var got types.Stats
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Suspicious != 2 || got.Known != 1 {
+		t.Errorf("stats = %+v; want 2 suspicious, 1 known", got)
+	}
+}
+
+func TestShard(t *testing.T) {
+	urls := []string{"https://a.example/.env", "https://b.example/.env", "https://c.example/.env",
+		"https://d.example/.env", "https://a.example/backup.sql"}
+	seen := make(map[string]int)
+	var other uint64
+	for i := 1; i <= 3; i++ {
+		dir := t.TempDir()
+		out, statsPath := filepath.Join(dir, "out.txt"), filepath.Join(dir, "stats.json")
+		cfg := &config.Config{
+			URLs:       urls,
+			OutputPath: out,
+			StatsPath:  statsPath,
+			Shard:      &shard.Shard{Index: i, Count: 3},
+			URLChecker: checker.NewURLChecker("", ""),
+		}
+		if err := ProcessFile(context.Background(), cfg); err != nil {
+			t.Fatal(err)
+		}
+		written, _ := os.ReadFile(out)
+		for _, u := range strings.Fields(string(written)) {
+			seen[u]++
+		}
+		raw, _ := os.ReadFile(statsPath)
+		var s types.Stats
+		json.Unmarshal(raw, &s)
+		other += s.OtherShards
+	}
+
+	for _, u := range urls {
+		if seen[u] != 1 {
+			t.Errorf("%s reported by %d shards, want 1", u, seen[u])
+		}
+	}
+	if other != uint64(2*len(urls)) {
+		t.Errorf("other shards counted %d URLs, want %d", other, 2*len(urls))
+	}
+}
+
+// TestSourceErrors skips an input that cannot be opened, and the rest of
+// one that cannot be read, counting both, while a lone input still fails
+func TestSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing, long, good := filepath.Join(dir, "missing.txt"), filepath.Join(dir, "long.txt"), filepath.Join(dir, "good.txt")
+	os.WriteFile(long, []byte("https://example.com/.env\nhttps://example.com/"+strings.Repeat("a", config.BufferSize)+"\nhttps://example.com/db.sql\n"), 0o644)
+	os.WriteFile(good, []byte("https://example.com/backup.sql\n"), 0o644)
+	cfg := &config.Config{
+		FilePath:   missing,
+		InputFiles: []string{long, good},
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	found, err := Scan(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, r := range found {
+		urls = append(urls, r.URL)
+	}
+	slices.Sort(urls)
+	if want := []string{"https://example.com/.env", "https://example.com/backup.sql"}; !slices.Equal(urls, want) {
+		t.Errorf("found %v; want %v", urls, want)
+	}
+
+	cfg.StatsPath = filepath.Join(dir, "stats.json")
+	cfg.OutputPath = filepath.Join(dir, "out.txt")
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(cfg.StatsPath)
+	var stats types.Stats
+	json.Unmarshal(raw, &stats)
+	if stats.Failed != 2 || len(stats.Sources) != 3 || stats.Sources[0].Error == "" ||
+		stats.Sources[1].Error == "" || stats.Sources[1].Total != 1 || stats.Sources[2].Error != "" {
+		t.Errorf("stats = %+v; want the missing and long inputs failed", stats)
+	}
+
+	if _, err := Scan(context.Background(), &config.Config{FilePath: missing, URLChecker: cfg.URLChecker}); err == nil {
+		t.Error("scan of a lone missing input succeeded")
+	}
+}
+
+// TestErrorsOut quarantines the lines that cannot be checked, with their
+// place and reason, and checks the rest
+func TestErrorsOut(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "ports.txt")
+	os.WriteFile(in, []byte("example.com:443\nexample.com:22\n\xff:8080\n"), 0o644)
+	cfg := &config.Config{
+		FilePath:     in,
+		InputFormat:  input.FormatHostPort,
+		ValidateURLs: true,
+		ErrorsPath:   filepath.Join(dir, "bad.txt"),
+		OutputPath:   filepath.Join(dir, "out.txt"),
+		StatsPath:    filepath.Join(dir, "stats.json"),
+		URLChecker:   checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(cfg.ErrorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := in + ":2\tnot a web host:port\texample.com:22\n" +
+		in + ":3\tinvalid UTF-8\thttp://\xff:8080/\n"
+	if string(raw) != want {
+		t.Errorf("quarantine = %q; want %q", raw, want)
+	}
+	raw, _ = os.ReadFile(cfg.StatsPath)
+	var stats types.Stats
+	json.Unmarshal(raw, &stats)
+	if stats.Quarantined != 2 || stats.Processed != 2 {
+		t.Errorf("stats = %+v; want 2 quarantined of 2 processed", stats)
+	}
+}
+
+// TestRoutes writes routed categories to their targets, sharing one, in
+// the route's format, and everything else to the output
+func TestRoutes(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "urls.txt")
+	os.WriteFile(in, []byte("https://example.com/.DS_Store\nhttps://example.com/db.sql\nhttps://example.com/manager/\nhttps://example.com/ok\n"), 0o644)
+	var routes []route.Route
+	for _, spec := range []string{"hidden=json:" + filepath.Join(dir, "exposed.jsonl"), "extensions=json:" + filepath.Join(dir, "exposed.jsonl")} {
+		r, err := route.Parse(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routes = append(routes, r)
+	}
+	cfg := &config.Config{
+		FilePath:   in,
+		OutputPath: filepath.Join(dir, "rest.txt"),
+		Routes:     routes,
+		URLChecker: checker.NewURLChecker("hidden,extensions,paths", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := os.ReadFile(cfg.OutputPath)
+	if string(rest) != "https://example.com/manager/\n" {
+		t.Errorf("output = %q; want the paths finding only", rest)
+	}
+	exposed, _ := os.ReadFile(filepath.Join(dir, "exposed.jsonl"))
+	if n := strings.Count(string(exposed), `"category":`); n != 2 || !strings.Contains(string(exposed), `"category":"hidden"`) {
+		t.Errorf("routed = %q; want the hidden and extensions findings as JSON", exposed)
+	}
+}
+
+// TestExtract checks URLs from anywhere in a bundle, each with its offset
+// and the text around it, and cuts a line longer than the buffer
+func TestExtract(t *testing.T) {
+	long := strings.Repeat("x ", config.BufferSize) + `fetch("https://cdn.example/db.sql")`
+	bundle := "!function(){var u=\"https://api.example/.env\";}\n" + long + "\n"
+	path := filepath.Join(t.TempDir(), "app.js")
+	if err := os.WriteFile(path, []byte(bundle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{FilePath: path, Extract: true, URLChecker: checker.NewURLChecker("", "")}
+	results, err := Scan(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.SortFunc(results, func(a, b types.Result) int { return a.Line - b.Line })
+	if len(results) != 2 {
+		t.Fatalf("%d findings; want 2: %+v", len(results), results)
+	}
+	for i, want := range []struct {
+		url     string
+		line    int
+		context string
+	}{
+		{"https://api.example/.env", 1, `!function(){var u="https://api.example/.env";}`},
+		{"https://cdn.example/db.sql", 2, `fetch("https://cdn.example/db.sql")`}, // The cut ends the context
+	} {
+		r := results[i]
+		offset := int64(strings.Index(bundle, want.url))
+		if r.URL != want.url || r.Line != want.line || r.SourceOffset != offset || !strings.HasSuffix(r.Context, want.context) {
+			t.Errorf("finding = %s line %d at %d in %q; want %s line %d at %d in %q",
+				r.URL, r.Line, r.SourceOffset, r.Context, want.url, want.line, offset, want.context)
+		}
+	}
+}
+
+// TestUnique passes each distinct URL through once, in input order, and
+// checks only those
+func TestUnique(t *testing.T) {
+	dir := t.TempDir()
+	var passed bytes.Buffer
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://Example.com:443/.env#top",
+			"https://example.com/?b=2&a=1",
+			"https://example.com/.env",
+			"https://example.com/?a=1&b=2",
+		},
+		Unique:     &passed,
+		OutputPath: filepath.Join(dir, "out.txt"),
+		StatsPath:  filepath.Join(dir, "stats.json"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/.env\nhttps://example.com/?b=2&a=1\n"; passed.String() != want {
+		t.Errorf("passed through %q; want %q", passed.String(), want)
+	}
+	raw, err := os.ReadFile(cfg.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Suspicious != 1 || stats.Duplicates != 3 || stats.Processed != 2 {
+		t.Errorf("%d findings, %d duplicates, %d processed; want 1, 3, 2", stats.Suspicious, stats.Duplicates, stats.Processed)
+	}
+}
+
+// TestSeverities counts the findings reported by severity, in -stats and in
+// the statistics handed to Finished
+func TestSeverities(t *testing.T) {
+	dir := t.TempDir()
+	var finished types.Stats
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://example.com/a.php",
+			"https://example.com/b.bak",
+			"https://example.com/x.js",
+			"https://example.com/",
+		},
+		OutputPath: filepath.Join(dir, "out.txt"),
+		StatsPath:  filepath.Join(dir, "stats.json"),
+		Finished:   func(s types.Stats) { finished = s },
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]uint64{"info": 1, "medium": 1, "high": 1, "critical": 1}
+	if !reflect.DeepEqual(finished.Severities, want) {
+		t.Errorf("Finished got severities %v; want %v", finished.Severities, want)
+	}
+	raw, err := os.ReadFile(cfg.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stats.Severities, want) {
+		t.Errorf("-stats severities = %v; want %v", stats.Severities, want)
+	}
+}
+
+// TestSort writes findings sorted by URL, whatever order the workers
+// finish in
+func TestSort(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/z/.env",
+			"https://example.com/b/db.sql",
+			"https://example.com/y/.git/config",
+			"https://example.com/a/backup.zip",
+		},
+		Sort:       true,
+		Workers:    4,
+		OutputPath: filepath.Join(dir, "out.txt"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com/a/backup.zip\nhttps://example.com/b/db.sql\nhttps://example.com/y/.git/config\nhttps://example.com/z/.env\n"
+	if string(out) != want {
+		t.Errorf("wrote:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// TestDedup writes a finding once however often its URL is read, spelled
+// differently or not
+func TestDedup(t *testing.T) {
+	dir := t.TempDir()
+	filter, err := dedup.NewBloom(100, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://example.com/db.sql",
+			"https://EXAMPLE.com:443/.env",
+			"https://example.com/.env",
+		},
+		Dedup:      filter,
+		OutputPath: filepath.Join(dir, "out.txt"),
+		StatsPath:  filepath.Join(dir, "stats.json"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); len(lines) != 2 {
+		t.Errorf("wrote %d findings; want 2:\n%s", len(lines), out)
+	}
+	raw, err := os.ReadFile(cfg.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Suspicious != 4 || stats.Repeated != 2 {
+		t.Errorf("%d findings, %d repeated; want 4, 2", stats.Suspicious, stats.Repeated)
+	}
+}
+
+// deadHosts is a Verifier finding the URLs on its hosts dead
+type deadHosts []string
+
+func (d deadHosts) Live(ctx context.Context, rawURL string) bool {
+	for _, host := range d {
+		if strings.Contains(rawURL, "//"+host+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// TestVerify drops the findings the verifier finds dead, counting them
+// apart from the suspicious ones
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://gone.example.com/.env",
+			"https://gone.example.com/db.sql",
+			"https://gone.example.com/",
+		},
+		Verify:     deadHosts{"gone.example.com"},
+		OutputPath: filepath.Join(dir, "out.txt"),
+		StatsPath:  filepath.Join(dir, "stats.json"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "https://example.com/.env" {
+		t.Errorf("wrote %q; want only the finding on the live host", got)
+	}
+	raw, err := os.ReadFile(cfg.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Suspicious != 1 || stats.Unresolved != 2 {
+		t.Errorf("%d findings, %d unresolved; want 1, 2", stats.Suspicious, stats.Unresolved)
+	}
+}
+
+// TestTelemetry traces the liveness checks as a probe stage and exports
+// findings per category
+func TestTelemetry(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = b
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	exp, err := otlp.New(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://example.com/.git/config",
+			"https://gone.example.com/db.sql",
+			"https://gone.example.com/",
+		},
+		Verify:     deadHosts{"gone.example.com"},
+		Telemetry:  exp,
+		OutputPath: filepath.Join(t.TempDir(), "out.txt"),
+		URLChecker: checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	type attribute struct {
+		Key   string
+		Value struct{ IntValue, StringValue string }
+	}
+	var traces struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name       string
+					Attributes []attribute
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(bodies["/v1/traces"], &traces); err != nil {
+		t.Fatal(err)
+	}
+	probes := ""
+	for _, sp := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		for _, a := range sp.Attributes {
+			if sp.Name == "probe" && a.Key == "juicyurls.probes" {
+				probes = a.Value.IntValue
+			}
+		}
+	}
+	if probes != "3" {
+		t.Errorf("probe span counted %q checks; want 3, one per finding", probes)
+	}
+
+	var metrics struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string
+					Sum  struct {
+						DataPoints []struct {
+							AsInt      string
+							Attributes []attribute
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(bodies["/v1/metrics"], &metrics); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		if m.Name == "juicyurls.category.findings" {
+			for _, p := range m.Sum.DataPoints {
+				got = append(got, p.Attributes[0].Value.StringValue+"="+p.AsInt)
+			}
+		}
+	}
+	if want := []string{"hidden=2"}; !slices.Equal(got, want) {
+		t.Errorf("findings per category = %v; want %v", got, want)
+	}
+}
+
+// TestFalsePositives leaves out the findings the triage store covers
+func TestFalsePositives(t *testing.T) {
+	dir := t.TempDir()
+	store, err := triage.Load(filepath.Join(dir, "fp.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(triage.Entry{Fingerprint: fingerprint.Compute("https://example.com/.env", "hidden:dotenv")})
+	store.Add(triage.Entry{Host: "static.example.com"})
+	cfg := &config.Config{
+		URLs: []string{
+			"https://example.com/.env",
+			"https://cdn.static.example.com/db.sql",
+			"https://example.com/db.sql",
+		},
+		FalsePositives: store,
+		OutputPath:     filepath.Join(dir, "out.txt"),
+		StatsPath:      filepath.Join(dir, "stats.json"),
+		URLChecker:     checker.NewURLChecker("", ""),
+	}
+	if err := ProcessFile(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(cfg.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "https://example.com/db.sql" {
+		t.Errorf("wrote %q; want only the finding no decision covers", got)
+	}
+	raw, err := os.ReadFile(cfg.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats types.Stats
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Suspicious != 1 || stats.FalsePositives != 2 {
+		t.Errorf("%d findings, %d false positives; want 1, 2", stats.Suspicious, stats.FalsePositives)
+	}
+}
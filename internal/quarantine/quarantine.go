@@ -0,0 +1,61 @@
+package quarantine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Writer records input lines that could not be checked, one per line as
+//
+//	source:line<TAB>reason<TAB>text
+//
+// so problems in the input can be fixed without rerunning with -v. Text is
+// written as read, with tabs and carriage returns kept; a line too long to
+// read is recorded without it.
+type Writer struct {
+	mu     sync.Mutex
+	f      *os.File
+	w      *bufio.Writer
+	err    error // First write error, returned by Close
+	closed bool
+}
+
+// Create starts a quarantine file at path, replacing any earlier one
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Add records a line. It is safe for concurrent use; lines added after
+// Close are dropped.
+func (w *Writer) Add(source string, line int, text, reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.err != nil {
+		return
+	}
+	reason = strings.NewReplacer("\t", " ", "\n", " ").Replace(reason)
+	_, w.err = fmt.Fprintf(w.w, "%s:%d\t%s\t%s\n", source, line, reason, strings.TrimRight(text, "\n"))
+}
+
+// Close flushes the file and closes it
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := errors.Join(w.err, w.w.Flush(), w.f.Close())
+	if err != nil {
+		return fmt.Errorf("quarantine: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestWriter records each line as source:line, reason and text
+func TestWriter(t *testing.T) {
+	type line struct {
+		source       string
+		n            int
+		text, reason string
+	}
+	tests := []struct {
+		name  string
+		lines []line
+		want  string
+	}{
+		{"none", nil, ""},
+		{"one", []line{{"urls.txt", 3, "http://[::1", "invalid URL"}}, "urls.txt:3\tinvalid URL\thttp://[::1\n"},
+		{"reason flattened", []line{{"-", 1, "x", "bad\tinput\nhere"}}, "-:1\tbad input here\tx\n"},
+		{"text kept", []line{{"a", 2, "x\ty\r\n", "r"}}, "a:2\tr\tx\ty\r\n"},
+		{"no text", []line{{"a", 9, "", "line too long"}}, "a:9\tline too long\t\n"},
+		{"in order", []line{{"a", 1, "x", "r"}, {"b", 1, "y", "s"}}, "a:1\tr\tx\nb:1\ts\ty\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "bad-lines.txt")
+		w, err := Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range tt.lines {
+			w.Add(l.source, l.n, l.text, l.reason)
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("%s: Close = %v", tt.name, err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestCreate replaces an earlier file at the path, and fails where no file
+// can be created
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	os.WriteFile(existing, []byte("old contents\n"), 0o644)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"new", filepath.Join(dir, "new.txt"), false},
+		{"existing", existing, false},
+		{"missing directory", filepath.Join(dir, "nope", "bad-lines.txt"), true},
+		{"directory", dir, true},
+	}
+	for _, tt := range tests {
+		w, err := Create(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Create error = %v; want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		w.Add("a", 1, "x", "r")
+		w.Close()
+		if got, _ := os.ReadFile(tt.path); string(got) != "a:1\tr\tx\n" {
+			t.Errorf("%s: got %q; want only the new line", tt.name, got)
+		}
+	}
+}
+
+// TestWriterClose drops lines added after Close, closes once, and reports
+// a failed write
+func TestWriterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad-lines.txt")
+	w, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Add("a", 1, "x", "r")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w.Add("a", 2, "y", "r")
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close = %v; want nil", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "a:1\tr\tx\n" {
+		t.Errorf("got %q; want the line added before Close", got)
+	}
+
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full")
+	}
+	w, err = Create("/dev/full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Add("a", 1, "x", "r")
+	if err := w.Close(); err == nil || !strings.HasPrefix(err.Error(), "quarantine: ") {
+		t.Errorf("Close on a full device = %v; want a quarantine error", err)
+	}
+}
+
+// TestWriterConcurrent keeps lines added at once whole
+func TestWriterConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad-lines.txt")
+	w, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Add("urls.txt", i+1, strings.Repeat("x", 100), "invalid URL")
+		}()
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("got %d lines; want 100", len(lines))
+	}
+	for _, l := range lines {
+		if fields := strings.Split(l, "\t"); len(fields) != 3 || len(fields[2]) != 100 {
+			t.Errorf("torn line %q", l)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+
+	"juicyurls/pkg/writer"
+)
+
+// Kinds of route targets
+const (
+	KindFile = "file" // A file path, or - for stdout
+	KindSink = "sink" // A sink registered with writer.RegisterSink
+	KindChat = "chat" // A Slack or Discord incoming webhook, as with -notify
+)
+
+// Route sends the findings of one category to a destination of its own
+// instead of the main output. Several routes may share a target, which is
+// then written once with all their findings.
+type Route struct {
+	Category string
+	Format   string // Output format of a file target; empty uses -format
+	Target   string
+	Kind     string
+}
+
+// Parse reads a route given as
+//
+//	category=[format:]target
+//
+// such as secrets=json:secrets.jsonl, extensions=ext.txt or
+// keywords=https://hooks.slack.com/services/... A format applies to file
+// targets only.
+func Parse(spec string) (Route, error) {
+	category, target, ok := strings.Cut(spec, "=")
+	category = strings.ToLower(strings.TrimSpace(category))
+	target = strings.TrimSpace(target)
+	if !ok || category == "" || target == "" {
+		return Route{}, fmt.Errorf("invalid route %q (want category=[format:]target)", spec)
+	}
+	r := Route{Category: category, Target: target, Kind: KindFile}
+	if format, rest, ok := strings.Cut(target, ":"); ok && writer.ValidFormat(format) {
+		r.Format, r.Target = format, rest
+	}
+	switch {
+	case r.Target == "":
+		return Route{}, fmt.Errorf("invalid route %q: no target", spec)
+	case strings.HasPrefix(r.Target, "http://") || strings.HasPrefix(r.Target, "https://"):
+		r.Kind = KindChat
+	case writer.IsSinkTarget(r.Target):
+		r.Kind = KindSink
+	}
+	if r.Format != "" && r.Kind != KindFile {
+		return Route{}, fmt.Errorf("invalid route %q: a format applies to files only", spec)
+	}
+	return r, nil
+}
+
+// Check rejects routes naming a category twice, and routes that share a
+// target but not its format
+func Check(routes []Route) error {
+	categories := make(map[string]bool)
+	formats := make(map[string]string)
+	for _, r := range routes {
+		if categories[r.Category] {
+			return fmt.Errorf("category %q is routed twice", r.Category)
+		}
+		categories[r.Category] = true
+		if f, ok := formats[r.Target]; ok && f != r.Format {
+			return fmt.Errorf("routes to %s ask for different formats", r.Target)
+		}
+		formats[r.Target] = r.Format
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package route
+
+import "testing"
+
+// TestParse reads the format only where one is named, and tells targets
+// apart by their form
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Route
+	}{
+		{"hidden=json:exposed.jsonl", Route{Category: "hidden", Format: "json", Target: "exposed.jsonl", Kind: KindFile}},
+		{" Extensions = ext.txt", Route{Category: "extensions", Target: "ext.txt", Kind: KindFile}},
+		{`paths=C:\out\paths.txt`, Route{Category: "paths", Target: `C:\out\paths.txt`, Kind: KindFile}},
+		{"keywords=https://hooks.slack.com/services/T0/B0/x", Route{Category: "keywords", Target: "https://hooks.slack.com/services/T0/B0/x", Kind: KindChat}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"hidden", "=x.txt", "hidden=json:", "keywords=json:https://hooks.slack.com/x"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted", bad)
+		}
+	}
+
+	shared := []Route{{Category: "hidden", Format: "json", Target: "a"}, {Category: "paths", Format: "json", Target: "a"}}
+	if err := Check(shared); err != nil {
+		t.Errorf("shared target rejected: %v", err)
+	}
+	if err := Check(append(shared, Route{Category: "keywords", Target: "a"})); err == nil {
+		t.Error("shared target with two formats accepted")
+	}
+	if err := Check(append(shared, Route{Category: "hidden", Format: "json", Target: "b"})); err == nil {
+		t.Error("category routed twice accepted")
+	}
+}
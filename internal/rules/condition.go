@@ -0,0 +1,293 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"juicyurls/internal/homograph"
+	"juicyurls/internal/urlnorm"
+)
+
+// Condition is a match expression. Exactly one field is set; All, Any and
+// Not combine nested conditions. Literal matching is case-insensitive
+// unless the rule sets CaseSensitive; regexes are used as written.
+type Condition struct {
+	Pattern   string      `yaml:"pattern"`   // Substring anywhere in the URL
+	Regex     string      `yaml:"regex"`     // Regular expression over the whole decoded URL
+	Extension string      `yaml:"extension"` // Suffix of the URL
+	Host      string      `yaml:"host"`      // Substring of the host
+	Path      string      `yaml:"path"`      // Substring of the path
+	Query     string      `yaml:"query"`     // Substring of the query string
+	Fragment  string      `yaml:"fragment"`  // Substring of the fragment
+	Encoding  string      `yaml:"encoding"`  // Encoding trick in the undecoded URL: double, mixed-case or overlong
+	Homograph string      `yaml:"homograph"` // IDN host trick: mixed-script or lookalike
+	All       []Condition `yaml:"all"`       // Every nested condition matches
+	Any       []Condition `yaml:"any"`       // At least one nested condition matches
+	Not       *Condition  `yaml:"not"`       // The nested condition does not match
+}
+
+// matchFunc reports whether a target satisfies a compiled condition
+type matchFunc func(t *Target) bool
+
+// Location describes where a rule matched
+type Location struct {
+	Pattern   string // Literal or regex that matched
+	Component string // One of Components
+	Offset    int    // Byte offset of the match within the decoded component
+}
+
+// locator finds where one leaf condition matches a target
+type locator func(t *Target) (Location, bool)
+
+// compiled collects what a condition tree needs besides its match function
+type compiled struct {
+	caseSensitive bool                               // Literals match case-sensitively
+	captures      []*regexp.Regexp                   // Regexes with named groups
+	notes         []func(t *Target) (string, string) // Extra name=value details, like captures
+	locators      []locator                          // Positive leaf conditions, in order
+}
+
+// Homograph checks
+const (
+	MixedScript = "mixed-script" // A host label mixes Latin with Cyrillic or Greek
+	Lookalike   = "lookalike"    // A host renders like one of homograph.Brands
+)
+
+// homographs lists the valid values of Condition.Homograph
+var homographs = []string{MixedScript, Lookalike}
+
+// locate returns a locator for a leaf matching re against one component
+func locate(re *regexp.Regexp, pattern, component string, text func(t *Target) string) locator {
+	return func(t *Target) (Location, bool) {
+		loc := re.FindStringIndex(text(t))
+		if loc == nil {
+			return Location{}, false
+		}
+		return Location{Pattern: pattern, Component: component, Offset: loc[0]}, true
+	}
+}
+
+// Components lists the URL parts a literal can be matched in
+var Components = []string{"url", "raw", "user", "host", "path", "query", "fragment"}
+
+// componentText returns the text of each of Components
+var componentText = map[string]func(t *Target) string{
+	"url":      (*Target).Text,
+	"raw":      func(t *Target) string { return t.Raw },
+	"user":     (*Target).User,
+	"host":     (*Target).Host,
+	"path":     (*Target).Path,
+	"query":    (*Target).Query,
+	"fragment": (*Target).Fragment,
+}
+
+// leaf compiles a literal matched in one component, optionally anchored
+// at its end
+func (out *compiled) leaf(s, component string, suffix bool) (matchFunc, error) {
+	lit, err := newLiteral(s, suffix, out.caseSensitive)
+	if err != nil {
+		return nil, err
+	}
+	text := componentText[component]
+	if text == nil {
+		return nil, fmt.Errorf("unknown component %q", component)
+	}
+	out.locators = append(out.locators, func(t *Target) (Location, bool) {
+		i := lit.index(text(t))
+		return Location{Pattern: s, Component: component, Offset: i}, i >= 0
+	})
+	return func(t *Target) bool { return lit.index(text(t)) >= 0 }, nil
+}
+
+// anyOf compiles a literal matched in any of the given components
+func (out *compiled) anyOf(s string, components []string, suffix bool) (matchFunc, error) {
+	if len(components) == 0 {
+		components = []string{"url"}
+	}
+	fns := make([]matchFunc, 0, len(components))
+	for _, comp := range components {
+		fn, err := out.leaf(s, comp, suffix)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+	if len(fns) == 1 {
+		return fns[0], nil
+	}
+	return func(t *Target) bool {
+		for _, fn := range fns {
+			if fn(t) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// IsCompound reports whether the condition combines nested conditions
+func (c *Condition) IsCompound() bool {
+	return len(c.All) > 0 || len(c.Any) > 0 || c.Not != nil
+}
+
+func (c *Condition) validate() error {
+	set := 0
+	for _, s := range []string{c.Pattern, c.Regex, c.Extension, c.Host, c.Path, c.Query, c.Fragment, c.Encoding, c.Homograph} {
+		if s != "" {
+			set++
+		}
+	}
+	if len(c.All) > 0 {
+		set++
+	}
+	if len(c.Any) > 0 {
+		set++
+	}
+	if c.Not != nil {
+		set++
+	}
+	switch {
+	case set == 0:
+		return errors.New("missing pattern or condition")
+	case set > 1:
+		return errors.New("a condition must set exactly one of pattern, regex, extension, host, path, query, fragment, encoding, homograph, all, any or not")
+	}
+	if c.Encoding != "" && !slices.Contains(urlnorm.Encodings, c.Encoding) {
+		return fmt.Errorf("unknown encoding %q (want one of %s)", c.Encoding, strings.Join(urlnorm.Encodings, ", "))
+	}
+	if c.Homograph != "" && !slices.Contains(homographs, c.Homograph) {
+		return fmt.Errorf("unknown homograph check %q (want one of %s)", c.Homograph, strings.Join(homographs, ", "))
+	}
+
+	for _, group := range [][]Condition{c.All, c.Any} {
+		for i := range group {
+			if err := group[i].validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Not != nil {
+		return c.Not.validate()
+	}
+	return nil
+}
+
+// compile builds the match function. Regexes with named groups are
+// collected into out.captures so matches can report the captured values,
+// and every positive leaf adds a locator to out.locators.
+func (c *Condition) compile(out *compiled) (matchFunc, error) {
+	switch {
+	case c.Regex != "":
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				out.captures = append(out.captures, re)
+				break
+			}
+		}
+		out.locators = append(out.locators, locate(re, c.Regex, "url", componentText["url"]))
+		return func(t *Target) bool { return re.MatchString(t.Text()) }, nil
+	case c.Pattern != "":
+		return out.leaf(c.Pattern, "url", false)
+	case c.Extension != "":
+		return out.leaf(c.Extension, "url", true)
+	case c.Host != "":
+		return out.leaf(c.Host, "host", false)
+	case c.Path != "":
+		return out.leaf(c.Path, "path", false)
+	case c.Query != "":
+		return out.leaf(c.Query, "query", false)
+	case c.Fragment != "":
+		return out.leaf(c.Fragment, "fragment", false)
+	case c.Encoding != "":
+		enc := c.Encoding
+		out.locators = append(out.locators, func(t *Target) (Location, bool) {
+			i := urlnorm.Obfuscated(t.Raw, enc)
+			return Location{Pattern: "encoding:" + enc, Component: "raw", Offset: i}, i >= 0
+		})
+		return func(t *Target) bool { return urlnorm.Obfuscated(t.Raw, enc) >= 0 }, nil
+	case c.Homograph == MixedScript:
+		out.locators = append(out.locators, homographLocator(MixedScript))
+		return func(t *Target) bool {
+			host, ok := t.UnicodeHost()
+			return ok && homograph.MixedScript(host)
+		}, nil
+	case c.Homograph == Lookalike:
+		lookalike := func(t *Target) (string, bool) {
+			if host, ok := t.UnicodeHost(); ok {
+				return homograph.Lookalike(host, homograph.Brands)
+			}
+			return "", false
+		}
+		out.locators = append(out.locators, homographLocator(Lookalike))
+		out.notes = append(out.notes, func(t *Target) (string, string) {
+			target, _ := lookalike(t)
+			return "lookalike", target
+		})
+		return func(t *Target) bool {
+			_, ok := lookalike(t)
+			return ok
+		}, nil
+	case len(c.All) > 0:
+		fns, err := compileAll(c.All, out)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *Target) bool {
+			for _, fn := range fns {
+				if !fn(t) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case len(c.Any) > 0:
+		fns, err := compileAll(c.Any, out)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *Target) bool {
+			for _, fn := range fns {
+				if fn(t) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case c.Not != nil:
+		// Captures, notes and locations from a negated condition never apply
+		ignored := compiled{caseSensitive: out.caseSensitive}
+		fn, err := c.Not.compile(&ignored)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *Target) bool { return !fn(t) }, nil
+	}
+	return nil, fmt.Errorf("empty condition")
+}
+
+// homographLocator reports a homograph match as covering the whole host
+func homographLocator(check string) locator {
+	return func(t *Target) (Location, bool) {
+		_, ok := t.UnicodeHost()
+		return Location{Pattern: "homograph:" + check, Component: "host"}, ok
+	}
+}
+
+func compileAll(conds []Condition, out *compiled) ([]matchFunc, error) {
+	fns := make([]matchFunc, 0, len(conds))
+	for i := range conds {
+		fn, err := conds[i].compile(out)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+	return fns, nil
+}
@@ -0,0 +1,95 @@
+package rules
+
+import "juicyurls/internal/urlnorm"
+
+// detectors holds the built-in categories whose rules are checks in code
+// rather than pattern lists
+var detectors = map[string][]Rule{
+	// Encoding tricks in the undecoded URL, used to slip payloads past
+	// filters; they are suspicious whatever they decode to
+	"obfuscation": obfuscation,
+	// Internationalized host names that pass for something else
+	"homograph": lookalikes,
+}
+
+var obfuscation = []Rule{
+	{
+		ID:         "obfuscation:double-encoding",
+		Condition:  Condition{Encoding: urlnorm.DoubleEncoded},
+		Reason:     "Double percent-encoding",
+		Severity:   "high",
+		Confidence: "likely",
+		References: []string{
+			"https://owasp.org/www-community/Double_Encoding",
+		},
+		Tests: Tests{
+			Match:   []string{"https://example.com/static/%252e%252e/etc/passwd"},
+			NoMatch: []string{"https://example.com/static/%2e%2e/", "https://example.com/100%25"},
+		},
+	},
+	{
+		ID:         "obfuscation:mixed-case-hex",
+		Condition:  Condition{Encoding: urlnorm.MixedCaseHex},
+		Reason:     "Percent-encoding with mixed-case hex digits",
+		Severity:   "low",
+		Confidence: "tentative",
+		Tests: Tests{
+			Match:   []string{"https://example.com/%2e%2E/admin"},
+			NoMatch: []string{"https://example.com/a%2Fb%3F", "https://example.com/a%2fb%3f"},
+		},
+	},
+	{
+		ID:         "obfuscation:overlong-utf8",
+		Condition:  Condition{Encoding: urlnorm.OverlongUTF8},
+		Reason:     "Overlong UTF-8 encoding",
+		Severity:   "high",
+		Confidence: "certain",
+		References: []string{
+			"https://capec.mitre.org/data/definitions/80.html",
+		},
+		Tests: Tests{
+			Match:   []string{"https://example.com/..%c0%af..%c0%afetc/passwd"},
+			NoMatch: []string{"https://example.com/caf%C3%A9"},
+		},
+	},
+}
+
+var lookalikes = []Rule{
+	{
+		ID:         "homograph:lookalike",
+		Condition:  Condition{Homograph: Lookalike},
+		Reason:     "IDN host imitates a well-known name",
+		Severity:   "critical",
+		Confidence: "likely",
+		References: []string{
+			"https://www.unicode.org/reports/tr39/#Confusable_Detection",
+		},
+		Tests: Tests{
+			Match:   []string{"https://xn--pypal-4ve.com/signin", "https://login.xn--80ak6aa92e.com/"},
+			NoMatch: []string{"https://paypal.com/signin", "https://xn--exmple-cua.com/"},
+		},
+	},
+	{
+		ID:         "homograph:mixed-script",
+		Condition:  Condition{Homograph: MixedScript},
+		Reason:     "IDN host mixes Latin with Cyrillic or Greek letters",
+		Severity:   "high",
+		Confidence: "likely",
+		Tests: Tests{
+			Match:   []string{"https://xn--exmple-4nf.org/"},
+			NoMatch: []string{"https://xn--80ak6aa92e.com/", "https://xn--exmple-cua.com/"},
+		},
+	},
+}
+
+// builtinDetectors returns the rules of a detector category ready to be
+// checked
+func builtinDetectors(category string) []Rule {
+	out := make([]Rule, len(detectors[category]))
+	for i, r := range detectors[category] {
+		r.Category = category
+		r.Source = SourceBuiltin
+		out[i] = r
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+)
+
+// literal finds a literal in text with plain string search. Matching in
+// any case folds ASCII letters byte by byte; text or a literal with other
+// letters goes to a regex instead, for Unicode's case folding, under which
+// the Kelvin sign matches k.
+type literal struct {
+	s             string // Lower-cased unless case-sensitive
+	suffix        bool   // Only matches at the end of the text
+	caseSensitive bool
+	ascii         bool           // s is ASCII
+	re            *regexp.Regexp // For case-insensitive matches beyond ASCII
+}
+
+// newLiteral prepares s for matching, case-insensitive unless
+// caseSensitive is set and optionally anchored at the end
+func newLiteral(s string, suffix, caseSensitive bool) (*literal, error) {
+	l := &literal{s: s, suffix: suffix, caseSensitive: caseSensitive, ascii: isASCII(s)}
+	if caseSensitive {
+		return l, nil
+	}
+	pattern := "(?i)" + regexp.QuoteMeta(s)
+	if suffix {
+		pattern += "$"
+	}
+	var err error
+	if l.re, err = regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	if l.ascii {
+		l.s = strings.ToLower(s)
+	}
+	return l, nil
+}
+
+// index returns where the literal first occurs in text, or -1
+func (l *literal) index(text string) int {
+	switch {
+	case l.caseSensitive && l.suffix:
+		if strings.HasSuffix(text, l.s) {
+			return len(text) - len(l.s)
+		}
+		return -1
+	case l.caseSensitive:
+		return strings.Index(text, l.s)
+	case !l.ascii || !isASCII(text):
+		if loc := l.re.FindStringIndex(text); loc != nil {
+			return loc[0]
+		}
+		return -1
+	case l.suffix:
+		if i := len(text) - len(l.s); i >= 0 && equalFoldASCII(text[i:], l.s) {
+			return i
+		}
+		return -1
+	}
+	return indexFoldASCII(text, l.s)
+}
+
+// indexFoldASCII returns where lower, which is lower case, first occurs in
+// the ASCII text s in any case, or -1
+func indexFoldASCII(s, lower string) int {
+	if lower == "" {
+		return 0
+	}
+	first, upper := lower[0], lower[0]
+	if 'a' <= first && first <= 'z' {
+		upper -= 'a' - 'A'
+	}
+	for i := 0; i+len(lower) <= len(s); i++ {
+		if c := s[i]; (c == first || c == upper) && equalFoldASCII(s[i:i+len(lower)], lower) {
+			return i
+		}
+	}
+	return -1
+}
+
+// equalFoldASCII reports whether the ASCII text s equals lower, which is
+// lower case, in any case
+func equalFoldASCII(s, lower string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+	return true
+}
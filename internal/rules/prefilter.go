@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"regexp"
+	"regexp/syntax"
+
+	"juicyurls/internal/ahocorasick"
+)
+
+// literalLeaf is the literal of a rule that is nothing but one: a pattern,
+// extension, host, path, query or fragment
+type literalLeaf struct {
+	text       string
+	components []string
+}
+
+// Prefilter spares a set of rules most of their evaluations. One
+// Aho-Corasick pass over each component finds every literal of the set
+// that occurs in a URL; a literal rule whose literal does not occur cannot
+// match. The regexes of the set are tried as one alternation, and only
+// when it matches are they evaluated one by one. Other rules are always
+// evaluated.
+type Prefilter struct {
+	rules      int
+	always     []int // Rules that are neither literals nor regexes
+	components []prefilterComponent
+	regex      *regexp.Regexp // Any of the regexes; nil when there are fewer than two
+	regexRules []int
+}
+
+// prefilterComponent finds the literals matched in one component
+type prefilterComponent struct {
+	text    func(t *Target) string
+	matcher *ahocorasick.Matcher
+	rules   []int // Rule of each of the matcher's patterns
+	folded  []int // Case-insensitive rules, evaluated whenever the text is not ASCII
+}
+
+// NewPrefilter returns a prefilter for rs, or nil when it would not rule
+// any of them out
+func NewPrefilter(rs []*Compiled) *Prefilter {
+	p := &Prefilter{rules: len(rs)}
+	byComponent := make(map[string]int)
+	var patterns [][]string
+	var regexes []string
+	for i, r := range rs {
+		if r.Rule.Regex != "" {
+			regexes = append(regexes, r.Rule.Regex)
+			p.regexRules = append(p.regexRules, i)
+			continue
+		}
+		if r.literal == nil {
+			p.always = append(p.always, i)
+			continue
+		}
+		for _, name := range r.literal.components {
+			j, ok := byComponent[name]
+			if !ok {
+				j = len(p.components)
+				byComponent[name] = j
+				p.components = append(p.components, prefilterComponent{text: componentText[name]})
+				patterns = append(patterns, nil)
+			}
+			comp := &p.components[j]
+			patterns[j] = append(patterns[j], r.literal.text)
+			comp.rules = append(comp.rules, i)
+			if !r.caseSensitive {
+				comp.folded = append(comp.folded, i)
+			}
+		}
+	}
+	if len(regexes) > 1 {
+		p.regex = alternation(regexes)
+	}
+	if p.regex == nil {
+		p.always = append(p.always, p.regexRules...)
+		p.regexRules = nil
+	}
+	if len(p.components) == 0 && p.regex == nil {
+		return nil
+	}
+	for j := range p.components {
+		p.components[j].matcher = ahocorasick.New(patterns[j])
+	}
+	return p
+}
+
+// alternation compiles a regex matching wherever any of exprs does, or
+// returns nil if one does not parse. Each keeps its flags; capture groups
+// are dropped, so names used twice do not clash.
+func alternation(exprs []string) *regexp.Regexp {
+	alt := &syntax.Regexp{Op: syntax.OpAlternate}
+	for _, expr := range exprs {
+		re, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return nil
+		}
+		alt.Sub = append(alt.Sub, uncapture(re))
+	}
+	re, err := regexp.Compile(alt.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// uncapture replaces the capture groups of re with their contents
+func uncapture(re *syntax.Regexp) *syntax.Regexp {
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	for i, sub := range re.Sub {
+		re.Sub[i] = uncapture(sub)
+	}
+	return re
+}
+
+// Candidates reports, for each rule, whether it may match t. A rule marked
+// false does not; one marked true still has to be evaluated. A nil
+// Prefilter returns nil, which marks nothing out. The result is kept in t
+// and is overwritten by the next call with t.
+func (p *Prefilter) Candidates(t *Target) []bool {
+	if p == nil {
+		return nil
+	}
+	if cap(t.candidates) < p.rules {
+		t.candidates = make([]bool, p.rules)
+	}
+	out := t.candidates[:p.rules]
+	clear(out)
+	for _, i := range p.always {
+		out[i] = true
+	}
+	if p.regex != nil && p.regex.MatchString(t.Text()) {
+		for _, i := range p.regexRules {
+			out[i] = true
+		}
+	}
+	for j := range p.components {
+		comp := &p.components[j]
+		text := comp.text(t)
+		comp.matcher.Each(text, func(pattern int) bool {
+			out[comp.rules[pattern]] = true
+			return true
+		})
+		// Case-insensitive regexes fold some non-ASCII letters to ASCII
+		// ones, as the Kelvin sign to k, which the matcher does not
+		if !isASCII(text) {
+			for _, i := range comp.folded {
+				out[i] = true
+			}
+		}
+	}
+	return out
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
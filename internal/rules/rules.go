@@ -0,0 +1,450 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"juicyurls/suspicious"
+)
+
+// SourceBuiltin marks rules that come from the suspicious package
+const SourceBuiltin = "builtin"
+
+// Rule types. Detect rules report findings; suppress and downgrade rules
+// are evaluated after a detection and veto it or lower its severity.
+const (
+	TypeDetect    = "detect"
+	TypeSuppress  = "suppress"
+	TypeDowngrade = "downgrade"
+)
+
+// Categories lists the built-in categories in match order
+var Categories = []string{"keywords", "extensions", "paths", "hidden", "shares", "obfuscation", "homograph"}
+
+// categoryName restricts user category names to what -m can select
+var categoryName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// schemeName matches a lower-cased URL scheme
+var schemeName = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+// Rule is a single detection pattern or compound condition
+type Rule struct {
+	ID             string   `yaml:"id"`
+	Type           string   `yaml:"type"` // TypeDetect (default), TypeSuppress or TypeDowngrade
+	Category       string   `yaml:"category"`
+	AppliesTo      []string `yaml:"applies-to"`       // Categories a suppress/downgrade rule affects; empty = all
+	AppliesToRules []string `yaml:"applies-to-rules"` // Rule IDs (path.Match globs) it affects; empty = all
+	Condition      `yaml:",inline"`
+	Reason         string   `yaml:"reason"` // Optional; reported in verbose output
+	Severity       string   `yaml:"severity"`
+	Confidence     string   `yaml:"confidence"`     // tentative, likely or certain; defaults from the category
+	Weight         int      `yaml:"weight"`         // Scoring weight; defaults from severity
+	In             []string `yaml:"in"`             // Components a plain pattern is matched in (default: url)
+	Schemes        []string `yaml:"schemes"`        // URL schemes the rule applies to; empty means all
+	CaseSensitive  bool     `yaml:"case-sensitive"` // Match literals case-sensitively
+	References     []string `yaml:"references"`
+	Tests          Tests    `yaml:"tests"`
+	Source         string   `yaml:"-"` // SourceBuiltin, the rules file path or "preset <name>"
+}
+
+// Tests holds example URLs a rule must and must not match
+type Tests struct {
+	Match   []string `yaml:"match"`
+	NoMatch []string `yaml:"nomatch"`
+}
+
+// CategoryDef declares a user category with its own pattern list
+type CategoryDef struct {
+	Name     string   `yaml:"name"`
+	Reason   string   `yaml:"reason"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// file is the on-disk rules file layout
+type file struct {
+	Categories []CategoryDef `yaml:"categories"`
+	Rules      []Rule        `yaml:"rules"`
+}
+
+// CategoryNames returns the built-in categories followed by any user
+// categories in rs, in first-seen order
+func CategoryNames(rs []Rule) []string {
+	names := append([]string(nil), Categories...)
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		if r.IsDetect() && !IsBuiltinCategory(r.Category) && !seen[r.Category] {
+			seen[r.Category] = true
+			names = append(names, r.Category)
+		}
+	}
+	return names
+}
+
+// IsDetect reports whether the rule reports findings itself
+func (r *Rule) IsDetect() bool {
+	return r.Type == "" || r.Type == TypeDetect
+}
+
+// AppliesToCategory reports whether a suppress/downgrade rule affects category
+func (r *Rule) AppliesToCategory(category string) bool {
+	if len(r.AppliesTo) == 0 {
+		return true
+	}
+	for _, c := range r.AppliesTo {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesToRule reports whether a suppress/downgrade rule affects findings
+// of the rule with the given ID
+func (r *Rule) AppliesToRule(id string) bool {
+	if len(r.AppliesToRules) == 0 {
+		return true
+	}
+	for _, pattern := range r.AppliesToRules {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBuiltinCategory reports whether name is one of the built-in categories
+func IsBuiltinCategory(name string) bool {
+	return slices.Contains(Categories, name)
+}
+
+// Compiled is a rule ready for matching
+type Compiled struct {
+	Rule    *Rule
+	match   matchFunc
+	literal *literalLeaf // Set when the rule is one literal, for Prefilter
+	compiled
+}
+
+// Match reports whether the rule matches t
+func (c *Compiled) Match(t *Target) bool {
+	return c.match(t)
+}
+
+// Captures formats the named groups captured from t, and details such as
+// the name a lookalike host imitates, as "name=value" pairs, or returns ""
+// when the rule has none
+func (c *Compiled) Captures(t *Target) string {
+	var parts []string
+	for _, re := range c.captures {
+		m := re.FindStringSubmatch(t.Text())
+		if m == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" && m[i] != "" {
+				parts = append(parts, name+"="+m[i])
+			}
+		}
+	}
+	for _, note := range c.notes {
+		if name, value := note(t); value != "" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Locate reports where the rule matched t: the first leaf condition that
+// matches, in rule order. It is only meaningful after Match returned true.
+func (c *Compiled) Locate(t *Target) (Location, bool) {
+	for _, loc := range c.locators {
+		if l, ok := loc(t); ok {
+			return l, true
+		}
+	}
+	return Location{}, false
+}
+
+// Compile prepares the rule for matching. A plain pattern is matched in
+// each component listed in In; in the extensions category it matches at
+// the end of the component, like the built-ins. Rules with Schemes only
+// match URLs of those schemes.
+func (r *Rule) Compile() (*Compiled, error) {
+	c := &Compiled{Rule: r}
+	c.caseSensitive = r.CaseSensitive
+	var fn matchFunc
+	var err error
+	if r.Pattern != "" && (len(r.In) > 0 || r.Category == "extensions") {
+		fn, err = c.anyOf(r.Pattern, r.In, r.Category == "extensions")
+	} else {
+		fn, err = r.Condition.compile(&c.compiled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Schemes) > 0 {
+		schemes, inner := r.Schemes, fn
+		fn = func(t *Target) bool {
+			scheme := t.Scheme()
+			for _, s := range schemes {
+				if s == scheme {
+					return inner(t)
+				}
+			}
+			return false
+		}
+	}
+	c.match = fn
+	c.literal = r.literalLeaf()
+	return c, nil
+}
+
+// literalLeaf returns the literal the rule consists of, or nil. Literals
+// with non-ASCII letters are left out when matched in any case, as the
+// prefilter only folds ASCII.
+func (r *Rule) literalLeaf() *literalLeaf {
+	if !r.CaseSensitive && !isASCII(r.Pattern+r.Extension+r.Host+r.Path+r.Query+r.Fragment) {
+		return nil
+	}
+	switch {
+	case r.Pattern != "" && len(r.In) > 0:
+		return &literalLeaf{text: r.Pattern, components: r.In}
+	case r.Pattern != "":
+		return &literalLeaf{text: r.Pattern, components: []string{"url"}}
+	case r.Extension != "":
+		return &literalLeaf{text: r.Extension, components: []string{"url"}}
+	case r.Host != "":
+		return &literalLeaf{text: r.Host, components: []string{"host"}}
+	case r.Path != "":
+		return &literalLeaf{text: r.Path, components: []string{"path"}}
+	case r.Query != "":
+		return &literalLeaf{text: r.Query, components: []string{"query"}}
+	case r.Fragment != "":
+		return &literalLeaf{text: r.Fragment, components: []string{"fragment"}}
+	}
+	return nil
+}
+
+// Builtin returns the active suspicious lists as rules, with metadata from
+// the embedded data files for patterns that have it, and the rules of the
+// detector categories
+func Builtin() []Rule {
+	var out []Rule
+	for _, cat := range Categories {
+		for _, p := range builtinList(cat) {
+			r := Rule{
+				ID:        cat + ":" + p,
+				Category:  cat,
+				Condition: Condition{Pattern: p},
+				In:        suspicious.DefaultIn(cat),
+				Source:    SourceBuiltin,
+			}
+			r.Severity = suspicious.DefaultSeverity(cat)
+			r.Confidence = suspicious.DefaultConfidence(cat)
+			if e, ok := suspicious.Lookup(cat, p); ok {
+				r.ID = e.ID
+				r.Severity = e.Severity
+				r.Confidence = e.Confidence
+				r.Weight = e.Weight
+				r.In = e.In
+				r.Schemes = e.Schemes
+				r.CaseSensitive = e.CaseSensitive
+				r.References = e.References
+				r.Tests = Tests{Match: e.Tests.Match, NoMatch: e.Tests.NoMatch}
+			}
+			out = append(out, r)
+		}
+		out = append(out, builtinDetectors(cat)...)
+	}
+	return out
+}
+
+func builtinList(category string) []string {
+	switch category {
+	case "keywords":
+		return suspicious.Keywords
+	case "extensions":
+		return suspicious.Extensions
+	case "paths":
+		return suspicious.Paths
+	case "hidden":
+		return suspicious.Hidden
+	case "shares":
+		return suspicious.Shares
+	}
+	return nil
+}
+
+// LoadFile reads and validates a YAML rules file
+func LoadFile(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rf file
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&rf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Expand category pattern lists into individual rules
+	var expanded []Rule
+	for _, def := range rf.Categories {
+		for _, p := range def.Patterns {
+			expanded = append(expanded, Rule{
+				ID:        def.Name + ":" + p,
+				Category:  def.Name,
+				Condition: Condition{Pattern: p},
+				Reason:    def.Reason,
+			})
+		}
+	}
+	rf.Rules = append(expanded, rf.Rules...)
+
+	for i := range rf.Rules {
+		rf.Rules[i].Source = path
+	}
+	if err := Prepare(rf.Rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rf.Rules, nil
+}
+
+// Prepare normalizes and validates rules, filling in default severities,
+// as LoadFile does for the rules of a file. Rules built in code should
+// go through it before use.
+func Prepare(rs []Rule) error {
+	seen := make(map[string]bool)
+	for i := range rs {
+		r := &rs[i]
+		r.Type = strings.ToLower(strings.TrimSpace(r.Type))
+		r.Category = strings.ToLower(strings.TrimSpace(r.Category))
+		for j, c := range r.AppliesTo {
+			r.AppliesTo[j] = strings.ToLower(strings.TrimSpace(c))
+		}
+		for j, c := range r.In {
+			r.In[j] = strings.ToLower(strings.TrimSpace(c))
+		}
+		for j, s := range r.Schemes {
+			r.Schemes[j] = strings.ToLower(strings.TrimSpace(s))
+		}
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if _, err := r.Compile(); err != nil {
+			return fmt.Errorf("rule %q: %w", r.ID, err)
+		}
+		if r.IsDetect() && r.Severity == "" {
+			r.Severity = suspicious.DefaultSeverity(r.Category)
+		}
+		if r.IsDetect() && r.Confidence == "" {
+			r.Confidence = suspicious.DefaultConfidence(r.Category)
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate rule id %q", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	return nil
+}
+
+// LoadFiles loads several rules files in order. Rule IDs must be unique
+// across the files and the built-in rules.
+func LoadFiles(paths []string) ([]Rule, error) {
+	var out []Rule
+	for _, p := range paths {
+		rs, err := LoadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rs...)
+	}
+	if err := UniqueIDs(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UniqueIDs checks that no rule in rs reuses the ID of another or of a
+// built-in rule, naming where both come from. Prepare only checks within
+// one file.
+func UniqueIDs(rs []Rule) error {
+	seen := make(map[string]string)
+	for _, r := range append(Builtin(), rs...) {
+		source := r.Source
+		if source == "" {
+			source = "rules built in code"
+		}
+		if first, ok := seen[r.ID]; ok {
+			return fmt.Errorf("duplicate rule id %q in %s and %s", r.ID, first, source)
+		}
+		seen[r.ID] = source
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	if r.ID == "" {
+		return errors.New("missing id")
+	}
+	if err := r.Condition.validate(); err != nil {
+		return fmt.Errorf("rule %q: %w", r.ID, err)
+	}
+	if r.Severity != "" && !suspicious.ValidSeverity(r.Severity) {
+		return fmt.Errorf("rule %q: invalid severity %q", r.ID, r.Severity)
+	}
+	if r.Confidence != "" && !suspicious.ValidConfidence(r.Confidence) {
+		return fmt.Errorf("rule %q: invalid confidence %q", r.ID, r.Confidence)
+	}
+	if r.Weight < 0 {
+		return fmt.Errorf("rule %q: negative weight", r.ID)
+	}
+	if len(r.In) > 0 && r.Pattern == "" {
+		return fmt.Errorf("rule %q: in only applies to pattern", r.ID)
+	}
+	for _, c := range r.In {
+		if componentText[c] == nil {
+			return fmt.Errorf("rule %q: unknown component %q (want one of %s)", r.ID, c, strings.Join(Components, ", "))
+		}
+	}
+	for _, s := range r.Schemes {
+		if !schemeName.MatchString(s) {
+			return fmt.Errorf("rule %q: invalid scheme %q", r.ID, s)
+		}
+	}
+
+	switch r.Type {
+	case "", TypeDetect:
+		if !categoryName.MatchString(r.Category) {
+			return fmt.Errorf("rule %q: invalid category %q", r.ID, r.Category)
+		}
+		if len(r.AppliesTo) > 0 || len(r.AppliesToRules) > 0 {
+			return fmt.Errorf("rule %q: applies-to is only valid for suppress and downgrade rules", r.ID)
+		}
+	case TypeSuppress, TypeDowngrade:
+		if r.Category != "" {
+			return fmt.Errorf("rule %q: %s rules take applies-to, not category", r.ID, r.Type)
+		}
+		if r.Type == TypeDowngrade && r.Severity == "" && r.Confidence == "" {
+			return fmt.Errorf("rule %q: downgrade rules need a severity or confidence", r.ID)
+		}
+		for _, pattern := range r.AppliesToRules {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %q: invalid applies-to-rules pattern %q", r.ID, pattern)
+			}
+		}
+	default:
+		return fmt.Errorf("rule %q: unknown type %q", r.ID, r.Type)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package rules
+
+import (
+	"net/url"
+	"strings"
+
+	"juicyurls/internal/homograph"
+	"juicyurls/internal/urlnorm"
+)
+
+// Target is a URL prepared for matching. Components are parsed on first
+// use, so rules that only look at the raw URL never pay for parsing.
+// Rules see percent-decoded text, so %2e%65nv is matched as .env.
+//
+// A Target can be Reset and used again, keeping its buffers, so matching
+// a stream of URLs need not allocate for each.
+type Target struct {
+	Raw string // Windows and UNC paths are rewritten as file URLs
+
+	text   string // Raw, percent-decoded once
+	parsed bool
+	valid  bool // Raw parses as a URL
+
+	// Components, decoded but for the scheme and user
+	scheme, user, host, path, query, fragment string
+
+	candidates []bool // Scratch for Prefilter.Candidates
+}
+
+// NewTarget wraps a raw URL for matching
+func NewTarget(raw string) *Target {
+	t := new(Target)
+	t.Reset(raw)
+	return t
+}
+
+// Reset makes t a target for raw, as NewTarget(raw) would return
+func (t *Target) Reset(raw string) {
+	raw, _ = urlnorm.FromWindows(raw)
+	candidates := t.candidates
+	*t = Target{Raw: raw, text: urlnorm.Unescape(raw), candidates: candidates}
+}
+
+// Text returns the whole URL, percent-decoded once
+func (t *Target) Text() string {
+	return t.text
+}
+
+// parse splits Raw into its components the first time one is needed, and
+// reports whether it is a URL. Plain http(s) URLs are split in place;
+// anything else goes through url.Parse.
+func (t *Target) parse() bool {
+	if t.parsed {
+		return t.valid
+	}
+	t.parsed = true
+	var rawPath, rawQuery, rawFragment string
+	if scheme, host, path, query, fragment, ok := split(t.Raw); ok {
+		t.scheme, t.host = scheme, host
+		rawPath, rawQuery, rawFragment = path, query, fragment
+	} else {
+		u, err := url.Parse(t.Raw)
+		if err != nil {
+			return false
+		}
+		t.scheme, t.host = u.Scheme, u.Hostname()
+		if u.User != nil {
+			t.user = u.User.Username()
+		}
+		rawPath, rawQuery, rawFragment = u.EscapedPath(), u.RawQuery, u.EscapedFragment()
+	}
+	t.valid = true
+	t.path = urlnorm.Unescape(rawPath)
+	t.query = urlnorm.Unescape(rawQuery)
+	t.fragment = urlnorm.Unescape(rawFragment)
+	return true
+}
+
+// split cuts a plain http or https URL into its raw components as
+// url.Parse would, without allocating. It declines anything unusual, such
+// as userinfo, IP literals in brackets, non-ASCII text or bytes url.Parse
+// would escape, and leaves it to url.Parse.
+func split(raw string) (scheme, host, path, query, fragment string, ok bool) {
+	rest, found := cutPrefixFold(raw, "https://")
+	scheme = "https"
+	if !found {
+		if rest, found = cutPrefixFold(raw, "http://"); !found {
+			return
+		}
+		scheme = "http"
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	host, rest = rest[:end], rest[end:]
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		for _, c := range []byte(host[i+1:]) {
+			if c < '0' || c > '9' {
+				return
+			}
+		}
+		host = host[:i]
+	}
+	if host == "" || !inSet(host, hostBytes) {
+		return
+	}
+	rest, fragment, _ = strings.Cut(rest, "#")
+	path, query, _ = strings.Cut(rest, "?")
+	if !inSet(path, pathBytes) || !inSet(fragment, fragmentBytes) || !inSet(query, queryBytes) {
+		return
+	}
+	return scheme, host, path, query, fragment, true
+}
+
+// cutPrefixFold is strings.CutPrefix, ignoring the case of ASCII letters
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// Bytes split leaves as they are in each component. A '%' must start a
+// valid escape.
+var (
+	hostBytes     = byteSet("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_")
+	pathBytes     = byteSet("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_~/:@!$&'()*+,;=%")
+	fragmentBytes = byteSet("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_~/:@!$&'()*+,;=%?")
+	queryBytes    = func() (set [256]bool) {
+		for c := '!'; c <= '~'; c++ {
+			set[c] = c != '#'
+		}
+		return set
+	}()
+)
+
+func byteSet(chars string) (set [256]bool) {
+	for i := 0; i < len(chars); i++ {
+		set[chars[i]] = true
+	}
+	return set
+}
+
+// inSet reports whether every byte of s is in set, with each '%' in a
+// valid escape when set allows '%'
+func inSet(s string, set [256]bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !set[c] {
+			return false
+		}
+		if c == '%' && (i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2])) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// UnicodeHost returns the host with punycode labels decoded, and whether
+// it has non-ASCII letters
+func (t *Target) UnicodeHost() (string, bool) {
+	return homograph.Decode(t.Host())
+}
+
+// Host returns the host without port, or "" if the URL does not parse
+func (t *Target) Host() string {
+	t.parse()
+	return t.host
+}
+
+// Path returns the decoded path, or "" if the URL does not parse
+func (t *Target) Path() string {
+	t.parse()
+	return t.path
+}
+
+// Query returns the decoded query without "?", or "" if the URL does not
+// parse
+func (t *Target) Query() string {
+	t.parse()
+	return t.query
+}
+
+// Fragment returns the decoded fragment without "#", or "" if the URL
+// does not parse
+func (t *Target) Fragment() string {
+	t.parse()
+	return t.fragment
+}
+
+// Scheme returns the lower-cased scheme, or "" if the URL has none
+func (t *Target) Scheme() string {
+	t.parse()
+	return t.scheme // url.Parse lower-cases it
+}
+
+// User returns the user name from the userinfo, or ""
+func (t *Target) User() string {
+	t.parse()
+	return t.user
+}
@@ -0,0 +1,52 @@
+package rules
+
+import "fmt"
+
+// Failure describes one example URL that did not behave as declared
+type Failure struct {
+	Rule   Rule
+	URL    string
+	Reason string
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("%s (%s): %s: %s", f.Rule.ID, f.Rule.Source, f.Reason, f.URL)
+}
+
+// TestSummary aggregates the results of running rule tests
+type TestSummary struct {
+	Rules    int // Rules checked
+	Tested   int // Rules with at least one example
+	Examples int // Example URLs evaluated
+	Failures []Failure
+}
+
+// RunTests checks every rule against its own match/nomatch examples
+func RunTests(rs []Rule) TestSummary {
+	var sum TestSummary
+	for _, r := range rs {
+		sum.Rules++
+		c, err := r.Compile()
+		if err != nil {
+			sum.Failures = append(sum.Failures, Failure{Rule: r, Reason: "invalid pattern: " + err.Error()})
+			continue
+		}
+		if len(r.Tests.Match)+len(r.Tests.NoMatch) == 0 {
+			continue
+		}
+		sum.Tested++
+		for _, u := range r.Tests.Match {
+			sum.Examples++
+			if !c.Match(NewTarget(u)) {
+				sum.Failures = append(sum.Failures, Failure{Rule: r, URL: u, Reason: "expected match"})
+			}
+		}
+		for _, u := range r.Tests.NoMatch {
+			sum.Examples++
+			if c.Match(NewTarget(u)) {
+				sum.Failures = append(sum.Failures, Failure{Rule: r, URL: u, Reason: "unexpected match"})
+			}
+		}
+	}
+	return sum
+}
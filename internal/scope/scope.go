@@ -0,0 +1,86 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+
+	"juicyurls/internal/rules"
+	"juicyurls/suspicious"
+)
+
+// Scope is an allowlist of domains. A URL is in scope when its host is
+// one of the domains or a subdomain of one, so listing a registrable
+// domain (example.com) covers all of its hosts while listing a subdomain
+// (api.example.com) covers only that branch. IP addresses match exactly.
+type Scope struct {
+	domains []string
+	ips     map[string]bool
+}
+
+// New builds a scope from domains. A leading "*." is ignored. A public
+// suffix such as "com" or "co.uk" is rejected, since it would put
+// everyone's hosts in scope.
+func New(domains []string) (*Scope, error) {
+	s := &Scope{ips: map[string]bool{}}
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*."), ".")
+		if d == "" {
+			continue
+		}
+		if ip := net.ParseIP(d); ip != nil {
+			s.ips[ip.String()] = true
+			continue
+		}
+		ascii, err := idna.ToASCII(d)
+		if err != nil {
+			return nil, fmt.Errorf("scope domain %q: %w", d, err)
+		}
+		if suffix, _ := publicsuffix.PublicSuffix(ascii); suffix == ascii {
+			return nil, fmt.Errorf("scope domain %q is a public suffix", d)
+		}
+		s.domains = append(s.domains, ascii)
+	}
+	if len(s.domains) == 0 && len(s.ips) == 0 {
+		return nil, fmt.Errorf("scope has no domains")
+	}
+	return s, nil
+}
+
+// Load builds a scope from a file with one domain per line. Blank lines
+// and lines starting with '#' are ignored.
+func Load(path string) (*Scope, error) {
+	domains, err := suspicious.LoadList(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(domains)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Contains reports whether a URL's host is in scope. URLs without a host
+// are not.
+func (s *Scope) Contains(rawURL string) bool {
+	host := strings.TrimSuffix(strings.ToLower(rules.NewTarget(rawURL).Host()), ".")
+	if host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return s.ips[ip.String()]
+	}
+	if ascii, err := idna.ToASCII(host); err == nil {
+		host = ascii
+	}
+	for _, d := range s.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
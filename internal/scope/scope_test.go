@@ -0,0 +1,36 @@
+package scope
+
+import "testing"
+
+// TestContains matches hosts on label boundaries under each scope domain
+func TestContains(t *testing.T) {
+	s, err := New([]string{"example.com", "*.api.example.org", "Bücher.example", "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for url, want := range map[string]bool{
+		"https://example.com/.env":        true,
+		"https://a.b.EXAMPLE.com:8443/x":  true,
+		"https://example.com./x":          true,
+		"https://notexample.com/x":        false,
+		"https://example.com.evil.net/x":  false,
+		"https://v1.api.example.org/x":    true,
+		"https://www.example.org/x":       false,
+		"https://xn--bcher-kva.example/x": true,
+		"https://bücher.example/x":        true,
+		"http://10.0.0.1/admin":           true,
+		"http://10.0.0.2/admin":           false,
+		"example.com/no-scheme":           false,
+	} {
+		if got := s.Contains(url); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", url, got, want)
+		}
+	}
+
+	for _, bad := range [][]string{{"com"}, {"co.uk"}, {"*.github.io"}, {""}} {
+		if _, err := New(bad); err == nil {
+			t.Errorf("New(%q): expected error", bad)
+		}
+	}
+}
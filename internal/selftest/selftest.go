@@ -0,0 +1,130 @@
+package selftest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"juicyurls/config"
+	"juicyurls/internal/checker"
+	"juicyurls/internal/processor"
+	"juicyurls/internal/types"
+	"juicyurls/pkg/writer"
+)
+
+//go:embed fixtures/urls.txt
+var corpus []byte
+
+//go:embed fixtures/expected.txt
+var expected []byte
+
+// Report describes how the pipeline output differed from the expected findings
+type Report struct {
+	Expected   int
+	Found      int
+	Missing    []string // Expected findings that were not produced
+	Unexpected []string // Findings produced but not expected
+}
+
+// OK reports whether the output matched exactly
+func (r *Report) OK() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0
+}
+
+// Run scans the embedded corpus through the full processing pipeline with
+// the given checker and compares the findings, in the verbose text format,
+// to the expected ones. The pipeline runs quietly, printing nothing.
+func Run(ctx context.Context, uc *checker.URLChecker, workers int) (*Report, error) {
+	dir, err := os.MkdirTemp("", "juicyurls-selftest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "urls.txt")
+	output := filepath.Join(dir, "findings.txt")
+	if err := os.WriteFile(input, corpus, 0o600); err != nil {
+		return nil, err
+	}
+
+	// Verbose text output would also print the scan's progress, so the
+	// findings are written as JSON and rendered afterwards
+	cfg := &config.Config{
+		FilePath:   input,
+		OutputPath: output,
+		Format:     writer.FormatJSON,
+		Workers:    workers,
+		URLChecker: uc,
+	}
+	if err := processor.ProcessFile(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	got, err := render(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+	want := lines(expected)
+	have := lines(got)
+
+	report := &Report{Expected: len(want), Found: len(have)}
+	for line := range want {
+		if !have[line] {
+			report.Missing = append(report.Missing, line)
+		}
+	}
+	for line := range have {
+		if !want[line] {
+			report.Unexpected = append(report.Unexpected, line)
+		}
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.Unexpected)
+	return report, nil
+}
+
+// render rewrites the JSON findings in path in the verbose text format
+func render(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var results []types.Result
+	for dec := json.NewDecoder(f); dec.More(); {
+		var r types.Result
+		if err := dec.Decode(&r); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	in := make(chan types.Result, len(results))
+	for _, r := range results {
+		in <- r
+	}
+	close(in)
+	var out bytes.Buffer
+	err = writer.WriteStreamTo(ctx, in, &out, writer.FormatText, true, 0, nil)
+	return out.Bytes(), err
+}
+
+// lines returns the set of non-empty, non-comment lines in data
+func lines(data []byte) map[string]bool {
+	set := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		set[line] = true
+	}
+	return set
+}
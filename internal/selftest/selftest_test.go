@@ -0,0 +1,36 @@
+package selftest
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/checker"
+)
+
+// TestRun keeps the embedded golden files in sync with the built-in rules
+func TestRun(t *testing.T) {
+	// Only the report is printed, by the caller
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	report, err := Run(context.Background(), checker.NewURLChecker("", ""), 2)
+	os.Stdout = stdout
+	w.Close()
+	if printed, _ := io.ReadAll(r); len(printed) > 0 {
+		t.Errorf("Run printed %q; want nothing", printed)
+	}
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	for _, line := range report.Missing {
+		t.Errorf("missing finding: %s", line)
+	}
+	for _, line := range report.Unexpected {
+		t.Errorf("unexpected finding: %s", line)
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"juicyurls/pkg/juicyurls"
+	juicyurlsv1 "juicyurls/proto/juicyurls/v1"
+)
+
+// GRPC returns a gRPC server for the Scanner service of
+// proto/juicyurls/v1/scanner.proto. It is also an http.Handler for gRPC
+// requests, so it can share a port with other handlers; wrap it with h2c
+// to serve it without TLS. No compressors are registered, so compressed
+// messages are refused.
+func GRPC(s *juicyurls.Scanner) *grpc.Server {
+	srv := grpc.NewServer()
+	juicyurlsv1.RegisterScannerServer(srv, scanner{s: s})
+	return srv
+}
+
+// scanner implements the Scanner service
+type scanner struct {
+	juicyurlsv1.UnimplementedScannerServer
+	s *juicyurls.Scanner
+}
+
+// Scan answers every request on the stream with its finding, empty for a
+// clean URL, until the client closes the stream
+func (sc scanner) Scan(stream juicyurlsv1.Scanner_ScanServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reply := &juicyurlsv1.Finding{Id: req.GetId(), Url: req.GetUrl()}
+		if found, ok := sc.s.Check(req.GetUrl()); ok {
+			reply = finding(req.GetId(), found)
+		}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// finding converts a result to a Finding message
+func finding(id string, r juicyurls.Result) *juicyurlsv1.Finding {
+	return &juicyurlsv1.Finding{
+		Id:          id,
+		Url:         r.URL,
+		Category:    r.Category,
+		Reason:      r.Reason,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		RuleId:      r.RuleID,
+		RuleSource:  r.RuleSource,
+		RulesHash:   r.RulesHash,
+		Fingerprint: r.Fingerprint,
+		Score:       int32(r.Score),
+		Pattern:     r.Pattern,
+		Component:   r.Component,
+		Offset:      int32(r.Offset),
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"juicyurls/pkg/juicyurls"
+)
+
+// maxScanBody bounds the body of POST /scan
+const maxScanBody = 10 << 20
+
+// scanRequestBody is the JSON body of POST /scan
+type scanRequestBody struct {
+	URLs []string `json:"urls"`
+}
+
+// scanResponse is the body of a successful scan
+type scanResponse struct {
+	Scanned   int                `json:"scanned"`
+	RulesHash string             `json:"rules_hash"`
+	Findings  []juicyurls.Result `json:"findings"`
+}
+
+// HTTP serves a JSON API over s:
+//
+//	POST /scan       {"urls": [...]}, or text/plain with one URL per line
+//	POST /scan-file  multipart upload of a URL list in the "file" field
+//	GET  /healthz    liveness, with the rules hash
+//
+// Findings come back in input order. Uploads larger than maxUpload bytes
+// are refused.
+func HTTP(s *juicyurls.Scanner, maxUpload int64) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scan", func(w http.ResponseWriter, r *http.Request) {
+		urls, err := readURLs(http.MaxBytesReader(w, r.Body, maxScanBody), r.Header.Get("Content-Type"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp := scanResponse{Scanned: len(urls), RulesHash: s.RulesHash(), Findings: []juicyurls.Result{}}
+		for _, u := range urls {
+			if found, ok := s.Check(u); ok {
+				resp.Findings = append(resp.Findings, found)
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("POST /scan-file", func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		defer file.Close()
+
+		counted := &lineCounter{r: file}
+		found, err := s.Scan(r.Context(), counted)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp := scanResponse{RulesHash: s.RulesHash(), Findings: []juicyurls.Result{}}
+		for f := range found {
+			resp.Findings = append(resp.Findings, f)
+		}
+		if err := r.Context().Err(); err != nil {
+			return // Client gone
+		}
+		if counted.err != nil {
+			writeError(w, counted.err)
+			return
+		}
+		slices.SortFunc(resp.Findings, func(a, b juicyurls.Result) int { return a.Line - b.Line })
+		resp.Scanned = counted.lines
+		writeJSON(w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "rules_hash": s.RulesHash()})
+	})
+	return mux
+}
+
+// readURLs decodes the URLs of a POST /scan body
+func readURLs(body io.Reader, contentType string) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/plain" {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		var urls []string
+		for _, line := range strings.Split(string(raw), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				urls = append(urls, line)
+			}
+		}
+		return urls, nil
+	}
+
+	var req scanRequestBody
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, err
+	}
+	return req.URLs, nil
+}
+
+// lineCounter counts the non-empty lines read through it, so uploads can
+// report how many URLs they held. It also keeps the first read error, which
+// the scan itself does not surface.
+type lineCounter struct {
+	r     io.Reader
+	lines int
+	mid   bool // Inside a non-empty line
+	err   error
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for _, b := range p[:n] {
+		switch {
+		case b == '\n':
+			c.mid = false
+		case !c.mid && b != '\r' && b != ' ' && b != '\t':
+			c.mid = true
+			c.lines++
+		}
+	}
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// writeError reports a bad request, or an oversized one
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
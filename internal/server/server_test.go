@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/alwalxed/juicyurls/v2/pkg/juicyurls"
+	juicyurlsv1 "github.com/alwalxed/juicyurls/v2/proto/juicyurls/v1"
+)
+
+// TestGRPC streams requests and reads each reply before sending the next,
+// so replies must not wait for the request stream to end
+func TestGRPC(t *testing.T) {
+	s, err := juicyurls.New(juicyurls.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h2c.NewHandler(GRPC(s), &http2.Server{}))
+	defer srv.Close()
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := juicyurlsv1.NewScannerClient(conn)
+
+	stream, err := client.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan := func(url, id string) *juicyurlsv1.Finding {
+		t.Helper()
+		if err := stream.Send(&juicyurlsv1.ScanRequest{Url: url, Id: id}); err != nil {
+			t.Fatal(err)
+		}
+		f, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	if f := scan("https://example.com/", "clean"); f.Id != "clean" || f.Url != "https://example.com/" || f.Category != "" {
+		t.Errorf("clean URL: got %v; want an empty finding", f)
+	}
+	if f := scan("https://example.com/.env", "a"); f.Id != "a" || f.Url != "https://example.com/.env" || f.RuleId != "hidden:dotenv" || f.RulesHash != s.RulesHash() {
+		t.Errorf("got %v; want hidden:dotenv finding", f)
+	}
+	if f := scan("https://example.com/backup.sql", "b"); f.Id != "b" || f.Category == "" {
+		t.Errorf("got %v; want finding with id b", f)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := stream.Recv(); err != io.EOF {
+		t.Errorf("after close: got %v, %v; want EOF", f, err)
+	}
+
+	// Unknown methods are unimplemented
+	err = conn.Invoke(context.Background(), "/juicyurls.v1.Scanner/Nope", &juicyurlsv1.ScanRequest{}, &juicyurlsv1.Finding{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("unknown method: err = %v; want Unimplemented", err)
+	}
+}
+
+// TestHTTP scans a JSON batch and a multipart upload, both answered in
+// input order
+func TestHTTP(t *testing.T) {
+	s, err := juicyurls.New(juicyurls.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(HTTP(s, 1<<10))
+	defer srv.Close()
+
+	decode := func(resp *http.Response) scanResponse {
+		t.Helper()
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+		var got scanResponse
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	urls := []string{"https://example.com/backup.sql", "https://example.com/", "https://example.com/.env"}
+
+	body, _ := json.Marshal(map[string][]string{"urls": urls})
+	resp, err := http.Post(srv.URL+"/scan", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decode(resp)
+	if got.Scanned != 3 || got.RulesHash != s.RulesHash() || len(got.Findings) != 2 ||
+		got.Findings[0].URL != urls[0] || got.Findings[1].URL != urls[2] {
+		t.Errorf("/scan = %+v", got)
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	part, _ := mw.CreateFormFile("file", "urls.txt")
+	io.WriteString(part, strings.Join(urls, "\n")+"\n\n")
+	mw.Close()
+	resp, err = http.Post(srv.URL+"/scan-file", mw.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = decode(resp)
+	if got.Scanned != 3 || len(got.Findings) != 2 || got.Findings[0].Line != 1 || got.Findings[1].Line != 3 {
+		t.Errorf("/scan-file = %+v", got)
+	}
+
+	// Uploads over the limit are refused
+	mw = multipart.NewWriter(&form)
+	part, _ = mw.CreateFormFile("file", "urls.txt")
+	io.WriteString(part, strings.Repeat("https://example.com/\n", 100))
+	mw.Close()
+	resp, err = http.Post(srv.URL+"/scan-file", mw.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: status = %d; want 413", resp.StatusCode)
+	}
+}
@@ -0,0 +1,53 @@
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+
+	"juicyurls/internal/rules"
+)
+
+// Shard is one of Count slices of the URL space, split by host, so that
+// machines scanning the same corpus with different shards share no URLs
+type Shard struct {
+	Index int // 1-based
+	Count int
+}
+
+// Parse reads a shard written as "index/count", such as "2/8"
+func Parse(s string) (Shard, error) {
+	index, count, ok := strings.Cut(s, "/")
+	i, err1 := strconv.Atoi(strings.TrimSpace(index))
+	n, err2 := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q (want index/count, such as 2/8, with 1 <= index <= count)", s)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Contains reports whether a URL belongs to the shard. The decision hashes
+// only the host, case-folded and in punycode, so it is the same on every
+// machine and keeps a host's URLs together. URLs without a host are
+// sharded by their whole text.
+func (s Shard) Contains(rawURL string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	key := strings.TrimSuffix(strings.ToLower(rules.NewTarget(rawURL).Host()), ".")
+	if key == "" {
+		key = rawURL
+	} else if ascii, err := idna.ToASCII(key); err == nil {
+		key = ascii
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64()%uint64(s.Count)) == s.Index-1
+}
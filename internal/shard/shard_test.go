@@ -0,0 +1,50 @@
+package shard
+
+import "testing"
+
+// TestContains puts every URL in exactly one shard, by host
+func TestContains(t *testing.T) {
+	const count = 4
+	shards := make([]Shard, count)
+	for i := range shards {
+		s, err := Parse(Shard{Index: i + 1, Count: count}.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards[i] = s
+	}
+	owner := func(u string) int {
+		found := -1
+		for i, s := range shards {
+			if s.Contains(u) {
+				if found >= 0 {
+					t.Errorf("%s is in shards %d and %d", u, found+1, i+1)
+				}
+				found = i
+			}
+		}
+		if found < 0 {
+			t.Errorf("%s is in no shard", u)
+		}
+		return found
+	}
+
+	for _, group := range [][]string{
+		{"https://example.com/.env", "http://EXAMPLE.com:8080/x", "ftp://example.com./backup.zip"},
+		{"https://bücher.example/a", "https://xn--bcher-kva.example/b"},
+		{"not a url"},
+	} {
+		first := owner(group[0])
+		for _, u := range group[1:] {
+			if got := owner(u); got != first {
+				t.Errorf("%s is in shard %d, %s in %d", u, got+1, group[0], first+1)
+			}
+		}
+	}
+
+	for _, bad := range []string{"0/4", "5/4", "1", "a/b", "1/0"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+
+package sqlsink
+
+import _ "github.com/jackc/pgx/v5/stdlib" // Registers "pgx"
+
+func init() { postgresDriver = "pgx" }
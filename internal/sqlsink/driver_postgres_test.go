@@ -0,0 +1,50 @@
+//go:build postgres
+
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestPostgresServer upserts findings into the database
+// JUICYURLS_TEST_POSTGRES names, twice, and finds one row per URL and
+// category
+func TestPostgresServer(t *testing.T) {
+	dsn := os.Getenv("JUICYURLS_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("JUICYURLS_TEST_POSTGRES is not set")
+	}
+	ctx := context.Background()
+	db, err := sql.Open(postgresDriver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS `+PostgresTable); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		p, err := OpenPostgres(ctx, dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := make(chan types.Result, 2)
+		in <- types.Result{URL: "https://a.example/x.sql", Category: "extensions", Severity: "high"}
+		in <- types.Result{URL: "https://a.example/x.sql", Category: "keywords", Severity: "low"}
+		close(in)
+		if err := p.Write(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+		p.Close()
+	}
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM `+PostgresTable).Scan(&rows); err != nil || rows != 2 {
+		t.Errorf("%d rows (%v); want 2 after rescanning", rows, err)
+	}
+}
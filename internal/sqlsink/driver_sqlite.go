@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package sqlsink
+
+import _ "modernc.org/sqlite" // Pure Go, so cgo is not needed
+
+func init() { sqliteDriver = "sqlite" }
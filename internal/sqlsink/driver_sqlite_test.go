@@ -0,0 +1,55 @@
+//go:build sqlite
+
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestSQLite writes two scans to a real database and reads them back
+// through the finding_details view
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.db")
+	for scan, urls := range [][]string{
+		{"https://a.example/x.sql", "https://b.example/.env"},
+		{"https://a.example/x.sql"},
+	} {
+		s, err := Open(context.Background(), SQLiteScheme+path, "h1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := make(chan types.Result, len(urls))
+		for i, u := range urls {
+			in <- types.Result{URL: u, Category: "extensions", RuleID: "extensions:.sql", Pattern: ".sql",
+				Severity: "high", Confidence: "likely", Fingerprint: u, Source: "urls.txt", Line: i + 1}
+		}
+		close(in)
+		if err := s.Write(context.Background(), in); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(types.Stats{Total: 10, Suspicious: uint64(len(urls))}); err != nil {
+			t.Fatalf("scan %d: %v", scan+1, err)
+		}
+	}
+
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var findings, urls, scans, suspicious int
+	if err := db.QueryRow(`SELECT count(*), count(DISTINCT url), count(DISTINCT scan_id) FROM finding_details`).Scan(&findings, &urls, &scans); err != nil {
+		t.Fatal(err)
+	}
+	if findings != 3 || urls != 2 || scans != 2 {
+		t.Errorf("%d findings of %d URLs in %d scans; want 3 of 2 in 2", findings, urls, scans)
+	}
+	if err := db.QueryRow(`SELECT suspicious FROM scans WHERE finished_at IS NOT NULL ORDER BY id LIMIT 1`).Scan(&suspicious); err != nil || suspicious != 2 {
+		t.Errorf("first scan recorded %d findings (%v); want 2", suspicious, err)
+	}
+}
@@ -0,0 +1,180 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"juicyurls/internal/types"
+)
+
+// postgresDriver names the database/sql driver for PostgreSQL. It is set
+// by builds with the postgres tag, which link one in.
+var postgresDriver string
+
+// PostgresEnabled reports whether this build links a PostgreSQL driver
+func PostgresEnabled() bool {
+	return postgresDriver != ""
+}
+
+// PostgresTable holds the findings written to PostgreSQL
+const PostgresTable = "juicyurls_findings"
+
+// postgresBatch is how many findings go in one INSERT; each takes
+// len(postgresColumns) of the 65535 parameters a statement may have
+const postgresBatch = 500
+
+// postgresFlush is how long findings wait for a batch to fill, so live
+// scans reach the database promptly
+const postgresFlush = time.Second
+
+var postgresColumns = []string{
+	"url", "category", "host", "reason", "severity", "confidence", "rule_id", "rule_source",
+	"rules_hash", "fingerprint", "pattern", "component", "match_offset", "score", "source", "line",
+}
+
+// postgresSchema keeps one row per URL and category, so rescans update
+// what earlier ones found instead of duplicating it
+var postgresSchema = `CREATE TABLE IF NOT EXISTS ` + PostgresTable + ` (
+	url TEXT NOT NULL,
+	category TEXT NOT NULL,
+	host TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	severity TEXT NOT NULL,
+	confidence TEXT NOT NULL,
+	rule_id TEXT NOT NULL,
+	rule_source TEXT NOT NULL,
+	rules_hash TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	component TEXT NOT NULL,
+	match_offset INTEGER NOT NULL,
+	score INTEGER NOT NULL,
+	source TEXT NOT NULL,
+	line INTEGER NOT NULL,
+	first_seen TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_seen TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (url, category)
+)`
+
+// Postgres writes findings to a PostgreSQL table with batched upserts
+type Postgres struct {
+	db *sql.DB
+}
+
+// OpenPostgres connects to the database dsn names, as a URL or key=value
+// pairs, and creates the findings table if needed
+func OpenPostgres(ctx context.Context, dsn string) (*Postgres, error) {
+	if postgresDriver == "" {
+		return nil, errors.New("PostgreSQL output is not in this build; rebuild with -tags postgres")
+	}
+	db, err := sql.Open(postgresDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	return &Postgres{db: db}, nil
+}
+
+// Write upserts results until in is closed or ctx ends. Findings are sent
+// postgresBatch at a time, or after postgresFlush when fewer arrive. Those
+// received before ctx ends are still sent.
+func (p *Postgres) Write(ctx context.Context, in <-chan types.Result) error {
+	var pending []types.Result
+	at := make(map[[2]string]int) // Index in pending by URL and category
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		err := p.upsert(context.WithoutCancel(ctx), pending)
+		pending = pending[:0]
+		clear(at)
+		return err
+	}
+
+	ticker := time.NewTicker(postgresFlush)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case r, ok := <-in:
+			if !ok {
+				return flush()
+			}
+			// A row may only be upserted once per statement; the later
+			// finding wins, as it would across statements
+			key := [2]string{r.URL, r.Category}
+			if i, dup := at[key]; dup {
+				pending[i] = r
+				continue
+			}
+			at[key] = len(pending)
+			pending = append(pending, r)
+			if len(pending) == postgresBatch {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// upsert writes one batch in a single statement
+func (p *Postgres) upsert(ctx context.Context, batch []types.Result) error {
+	var q strings.Builder
+	q.WriteString("INSERT INTO " + PostgresTable + " (" + strings.Join(postgresColumns, ", ") + ") VALUES ")
+	args := make([]any, 0, len(batch)*len(postgresColumns))
+	for i, r := range batch {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteByte('(')
+		for j := range postgresColumns {
+			if j > 0 {
+				q.WriteString(", ")
+			}
+			fmt.Fprintf(&q, "$%d", len(args)+j+1)
+		}
+		q.WriteByte(')')
+		host := ""
+		if u, err := url.Parse(r.URL); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+		args = append(args, r.URL, r.Category, host, r.Reason, r.Severity, r.Confidence, r.RuleID,
+			r.RuleSource, r.RulesHash, r.Fingerprint, r.Pattern, r.Component, r.Offset, r.Score,
+			r.Source, r.Line)
+	}
+	q.WriteString(" ON CONFLICT (url, category) DO UPDATE SET ")
+	for i, c := range postgresColumns[2:] {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString(c + " = EXCLUDED." + c)
+	}
+	q.WriteString(", last_seen = now()")
+
+	if _, err := p.db.ExecContext(ctx, q.String(), args...); err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
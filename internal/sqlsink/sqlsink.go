@@ -0,0 +1,271 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"juicyurls/internal/types"
+)
+
+// SQLiteScheme prefixes an -o target that is an SQLite database
+const SQLiteScheme = "sqlite://"
+
+// batchSize is how many findings are written per transaction
+const batchSize = 1000
+
+// sqliteDriver names the database/sql driver for SQLite. It is set by
+// builds with the sqlite tag, which link one in.
+var sqliteDriver string
+
+// SQLiteEnabled reports whether this build links an SQLite driver
+func SQLiteEnabled() bool {
+	return sqliteDriver != ""
+}
+
+// IsTarget reports whether an -o value names a database rather than a file
+func IsTarget(output string) bool {
+	return strings.HasPrefix(output, SQLiteScheme)
+}
+
+// schema is created on open, so findings from several scans accumulate in
+// one database. Each finding links the scan, URL and pattern it came from.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS scans (
+		id INTEGER PRIMARY KEY,
+		started_at TEXT NOT NULL,
+		finished_at TEXT,
+		rules_hash TEXT NOT NULL,
+		total INTEGER,
+		processed INTEGER,
+		suspicious INTEGER,
+		out_of_scope INTEGER,
+		seconds REAL
+	)`,
+	`CREATE TABLE IF NOT EXISTS urls (
+		id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL UNIQUE,
+		host TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS patterns (
+		id INTEGER PRIMARY KEY,
+		rule_id TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		category_id INTEGER NOT NULL REFERENCES categories (id),
+		reason TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		confidence TEXT NOT NULL,
+		rule_source TEXT NOT NULL,
+		UNIQUE (rule_id, pattern)
+	)`,
+	`CREATE TABLE IF NOT EXISTS findings (
+		id INTEGER PRIMARY KEY,
+		scan_id INTEGER NOT NULL REFERENCES scans (id),
+		url_id INTEGER NOT NULL REFERENCES urls (id),
+		pattern_id INTEGER NOT NULL REFERENCES patterns (id),
+		fingerprint TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		component TEXT NOT NULL,
+		match_offset INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		line INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS findings_fingerprint ON findings (fingerprint)`,
+	`CREATE INDEX IF NOT EXISTS urls_host ON urls (host)`,
+	`CREATE VIEW IF NOT EXISTS finding_details AS
+		SELECT f.scan_id, u.url, u.host, c.name AS category, p.rule_id, p.pattern, p.reason,
+			p.severity, p.confidence, f.fingerprint, f.score, f.component, f.match_offset,
+			f.source, f.line
+		FROM findings f
+		JOIN urls u ON u.id = f.url_id
+		JOIN patterns p ON p.id = f.pattern_id
+		JOIN categories c ON c.id = p.category_id`,
+}
+
+// statements are prepared once per sink
+const (
+	selectURL      = `SELECT id FROM urls WHERE url = ?`
+	insertURL      = `INSERT INTO urls (url, host) VALUES (?, ?)`
+	selectCategory = `SELECT id FROM categories WHERE name = ?`
+	insertCategory = `INSERT INTO categories (name) VALUES (?)`
+	selectPattern  = `SELECT id FROM patterns WHERE rule_id = ? AND pattern = ?`
+	insertPattern  = `INSERT INTO patterns (rule_id, pattern, category_id, reason, severity, confidence, rule_source)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	insertFinding = `INSERT INTO findings (scan_id, url_id, pattern_id, fingerprint, score, component, match_offset, source, line)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+)
+
+// Sink writes a scan's findings to a database
+type Sink struct {
+	db     *sql.DB
+	scanID int64
+	stmts  map[string]*sql.Stmt
+
+	// IDs already looked up; URLs are not kept, as most appear once
+	categories map[string]int64
+	patterns   map[[2]string]int64
+}
+
+// Open opens the database an sqlite:// target names, creating it and its
+// schema if needed, and records the start of a scan with the rule set
+// identified by rulesHash
+func Open(ctx context.Context, target, rulesHash string) (*Sink, error) {
+	if sqliteDriver == "" {
+		return nil, errors.New("SQLite output is not in this build; rebuild with -tags sqlite")
+	}
+	path := strings.TrimPrefix(target, SQLiteScheme)
+	if path == "" {
+		return nil, fmt.Errorf("%s: no database path", target)
+	}
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sink{db: db, stmts: make(map[string]*sql.Stmt),
+		categories: make(map[string]int64), patterns: make(map[[2]string]int64)}
+	if err := s.init(ctx, rulesHash); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *Sink) init(ctx context.Context, rulesHash string) error {
+	for _, q := range schema {
+		if _, err := s.db.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+	for _, q := range []string{selectURL, insertURL, selectCategory, insertCategory,
+		selectPattern, insertPattern, insertFinding} {
+		stmt, err := s.db.PrepareContext(ctx, q)
+		if err != nil {
+			return err
+		}
+		s.stmts[q] = stmt
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO scans (started_at, rules_hash) VALUES (?, ?)`,
+		time.Now().UTC().Format(time.RFC3339), rulesHash)
+	if err != nil {
+		return err
+	}
+	s.scanID, err = res.LastInsertId()
+	return err
+}
+
+// Write stores results until in is closed or ctx ends, committing every
+// batchSize findings. Findings received before ctx ends are committed, as
+// partial results of a timed out scan are.
+func (s *Sink) Write(ctx context.Context, in <-chan types.Result) error {
+	dbCtx := context.WithoutCancel(ctx)
+	for {
+		tx, err := s.db.BeginTx(dbCtx, nil)
+		if err != nil {
+			return err
+		}
+		n, err := s.batch(ctx, dbCtx, tx, in)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		if n < batchSize {
+			return ctx.Err()
+		}
+	}
+}
+
+// batch writes up to batchSize results in tx and returns how many; fewer
+// means the input is done or ctx ended
+func (s *Sink) batch(ctx, dbCtx context.Context, tx *sql.Tx, in <-chan types.Result) (int, error) {
+	for n := 0; n < batchSize; n++ {
+		var r types.Result
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return n, nil
+		case r, ok = <-in:
+			if !ok {
+				return n, nil
+			}
+		}
+		if err := s.insert(dbCtx, tx, r); err != nil {
+			return n, err
+		}
+	}
+	return batchSize, nil
+}
+
+func (s *Sink) insert(ctx context.Context, tx *sql.Tx, r types.Result) error {
+	host := ""
+	if u, err := url.Parse(r.URL); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	urlID, err := s.id(ctx, tx, selectURL, []any{r.URL}, insertURL, []any{r.URL, host})
+	if err != nil {
+		return err
+	}
+
+	categoryID, ok := s.categories[r.Category]
+	if !ok {
+		categoryID, err = s.id(ctx, tx, selectCategory, []any{r.Category}, insertCategory, []any{r.Category})
+		if err != nil {
+			return err
+		}
+		s.categories[r.Category] = categoryID
+	}
+
+	key := [2]string{r.RuleID, r.Pattern}
+	patternID, ok := s.patterns[key]
+	if !ok {
+		patternID, err = s.id(ctx, tx, selectPattern, []any{r.RuleID, r.Pattern}, insertPattern,
+			[]any{r.RuleID, r.Pattern, categoryID, r.Reason, r.Severity, r.Confidence, r.RuleSource})
+		if err != nil {
+			return err
+		}
+		s.patterns[key] = patternID
+	}
+
+	_, err = tx.StmtContext(ctx, s.stmts[insertFinding]).ExecContext(ctx,
+		s.scanID, urlID, patternID, r.Fingerprint, r.Score, r.Component, r.Offset, r.Source, r.Line)
+	return err
+}
+
+// id returns the ID of the row sel finds, inserting it with ins if missing
+func (s *Sink) id(ctx context.Context, tx *sql.Tx, sel string, selArgs []any, ins string, insArgs []any) (int64, error) {
+	var id int64
+	err := tx.StmtContext(ctx, s.stmts[sel]).QueryRowContext(ctx, selArgs...).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	res, err := tx.StmtContext(ctx, s.stmts[ins]).ExecContext(ctx, insArgs...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Close records the scan's totals and closes the database
+func (s *Sink) Close(stats types.Stats) error {
+	_, err := s.db.Exec(`UPDATE scans SET finished_at = ?, total = ?, processed = ?, suspicious = ?,
+		out_of_scope = ?, seconds = ? WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339), stats.Total, stats.Processed, stats.Suspicious,
+		stats.OutOfScope, stats.Seconds, s.scanID)
+	for _, stmt := range s.stmts {
+		stmt.Close()
+	}
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
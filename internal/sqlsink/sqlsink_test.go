@@ -0,0 +1,132 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// recorder is a database/sql driver that finds no rows and counts the
+// statements executed, by their first words
+type recorder struct {
+	mu     sync.Mutex
+	execs  map[string]int
+	args   []int // Arguments of each statement executed
+	lastID int64
+}
+
+func (d *recorder) Open(string) (driver.Conn, error) { return conn{d}, nil }
+
+type conn struct{ d *recorder }
+
+func (c conn) Prepare(q string) (driver.Stmt, error) { return stmt{c.d, q}, nil }
+func (c conn) Close() error                          { return nil }
+func (c conn) Begin() (driver.Tx, error)             { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	d *recorder
+	q string
+}
+
+func (s stmt) Close() error  { return nil }
+func (s stmt) NumInput() int { return -1 }
+
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	f := strings.Fields(s.q)
+	s.d.execs[strings.Join(f[:3], " ")]++
+	s.d.args = append(s.d.args, len(args))
+	s.d.lastID++
+	return result(s.d.lastID), nil
+}
+
+type result int64
+
+func (r result) LastInsertId() (int64, error) { return int64(r), nil }
+func (r result) RowsAffected() (int64, error) { return 1, nil }
+
+func (s stmt) Query([]driver.Value) (driver.Rows, error) { return rows{}, nil }
+
+type rows struct{}
+
+func (rows) Columns() []string         { return []string{"id"} }
+func (rows) Close() error              { return nil }
+func (rows) Next([]driver.Value) error { return io.EOF }
+
+// TestWrite stores each finding, looking up categories and patterns once
+func TestWrite(t *testing.T) {
+	rec := &recorder{execs: make(map[string]int)}
+	sql.Register("recorder", rec)
+	defer func(linked string) { sqliteDriver = linked }(sqliteDriver)
+	sqliteDriver = "recorder"
+
+	s, err := Open(context.Background(), "sqlite://findings.db", "h1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := make(chan types.Result, 3)
+	for _, u := range []string{"https://a.example/x.sql", "https://b.example/y.sql", "https://b.example/z.sql"} {
+		in <- types.Result{URL: u, Category: "extensions", RuleID: "extensions:.sql", Pattern: ".sql"}
+	}
+	close(in)
+	if err := s.Write(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(types.Stats{Total: 10, Suspicious: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	for q, want := range map[string]int{
+		"INSERT INTO scans":      1,
+		"INSERT INTO urls":       3,
+		"INSERT INTO categories": 1,
+		"INSERT INTO patterns":   1,
+		"INSERT INTO findings":   3,
+		"UPDATE scans SET":       1,
+	} {
+		if got := rec.execs[q]; got != want {
+			t.Errorf("%s ran %d times, want %d", q, got, want)
+		}
+	}
+}
+
+// TestPostgres upserts a batch in one statement, once per URL and category
+func TestPostgres(t *testing.T) {
+	rec := &recorder{execs: make(map[string]int)}
+	sql.Register("pg-recorder", rec)
+	defer func(linked string) { postgresDriver = linked }(postgresDriver)
+	postgresDriver = "pg-recorder"
+
+	p, err := OpenPostgres(context.Background(), "postgres://localhost/recon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	in := make(chan types.Result, 3)
+	in <- types.Result{URL: "https://a.example/x.sql", Category: "extensions"}
+	in <- types.Result{URL: "https://a.example/x.sql", Category: "keywords"}
+	in <- types.Result{URL: "https://a.example/x.sql", Category: "extensions"}
+	close(in)
+	if err := p.Write(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rec.execs["INSERT INTO "+PostgresTable]; got != 1 {
+		t.Fatalf("%d upserts, want 1", got)
+	}
+	if got, want := rec.args[len(rec.args)-1], 2*len(postgresColumns); got != want {
+		t.Errorf("upsert had %d arguments, want %d", got, want)
+	}
+}
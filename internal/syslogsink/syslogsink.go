@@ -0,0 +1,139 @@
+package syslogsink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"juicyurls/internal/types"
+	"juicyurls/pkg/writer"
+)
+
+// facility is local0, the facility of every message
+const facility = 16
+
+// appName and msgID identify juicyurls findings among other messages
+const (
+	appName = "juicyurls"
+	msgID   = "finding"
+)
+
+// levels maps finding severities to syslog severities
+var levels = map[string]int{
+	"critical": 2, // crit
+	"high":     3, // err
+	"medium":   4, // warning
+	"low":      5, // notice
+	"info":     6, // info
+}
+
+// Sink sends findings as RFC 5424 messages to a syslog collector
+type Sink struct {
+	conn     net.Conn
+	w        *bufio.Writer
+	tcp      bool
+	hostname string
+	procID   string
+
+	// MaxFieldLength cuts longer URLs, patterns and contexts, in bytes, and
+	// marks the finding truncated; zero sends them whole
+	MaxFieldLength int
+}
+
+// Dial connects to target, udp://host:port (the default when no scheme is
+// given) or tcp://host:port, through dial
+func Dial(ctx context.Context, target string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (*Sink, error) {
+	network, hostPort, ok := strings.Cut(target, "://")
+	if !ok {
+		network, hostPort = "udp", target
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("unknown syslog network %q (want udp or tcp)", network)
+	}
+	conn, err := dial(ctx, network, hostPort)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &Sink{
+		conn:     conn,
+		w:        bufio.NewWriter(conn),
+		tcp:      network == "tcp",
+		hostname: hostname,
+		procID:   fmt.Sprint(os.Getpid()),
+	}, nil
+}
+
+// Write sends results until in is closed or ctx ends, one message each.
+// Over TCP, messages are octet-counted (RFC 6587) and flushed whenever no
+// more findings are waiting.
+func (s *Sink) Write(ctx context.Context, in <-chan types.Result) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return s.w.Flush()
+		case r, ok := <-in:
+			if !ok {
+				return s.w.Flush()
+			}
+			if err := s.send(r, time.Now()); err != nil {
+				return fmt.Errorf("syslog: %w", err)
+			}
+			if s.tcp && len(in) == 0 {
+				if err := s.w.Flush(); err != nil {
+					return fmt.Errorf("syslog: %w", err)
+				}
+			}
+		}
+	}
+}
+
+// send writes one message; a UDP datagram goes out at once
+func (s *Sink) send(r types.Result, now time.Time) error {
+	msg, err := s.format(r, now)
+	if err != nil {
+		return err
+	}
+	if !s.tcp {
+		_, err := s.conn.Write(msg)
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "%d %s", len(msg), msg)
+	return err
+}
+
+// format builds the RFC 5424 message for a finding. The structured data
+// is left empty and the message is the finding's JSON, as with -format
+// json, which collectors parse more readily than SD parameters.
+func (s *Sink) format(r types.Result, now time.Time) ([]byte, error) {
+	if s.MaxFieldLength > 0 {
+		r = writer.Truncate(r, s.MaxFieldLength)
+	}
+	level, ok := levels[r.Severity]
+	if !ok {
+		level = levels["medium"]
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "<%d>1 %s %s %s %s %s - ", facility*8+level,
+		now.UTC().Format("2006-01-02T15:04:05.000000Z"), s.hostname, appName, s.procID, msgID)
+	enc := json.NewEncoder(&msg)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(msg.Bytes(), []byte("\n")), nil
+}
+
+// Close closes the connection
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,103 @@
+package syslogsink
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+var dialer net.Dialer
+
+func write(t *testing.T, s *Sink, results ...types.Result) {
+	t.Helper()
+	in := make(chan types.Result, len(results))
+	for _, r := range results {
+		in <- r
+	}
+	close(in)
+	if err := s.Write(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var header = regexp.MustCompile(`^<(\d+)>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z \S+ juicyurls \d+ finding - \{"url":"https://example.com/a&b.sql"`)
+
+// TestUDP sends one datagram per finding, at the severity's level
+func TestUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s, err := Dial(context.Background(), conn.LocalAddr().String(), dialer.DialContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	write(t, s, types.Result{URL: "https://example.com/a&b.sql", Severity: "critical"},
+		types.Result{URL: "https://example.com/a&b.sql", Severity: "low"})
+
+	for _, pri := range []string{"130", "133"} {
+		buf := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := header.FindStringSubmatch(string(buf[:n]))
+		if m == nil || m[1] != pri {
+			t.Errorf("message = %q; want PRI %s", buf[:n], pri)
+		}
+	}
+}
+
+// TestTCP frames messages by octet count
+func TestTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		defer conn.Close()
+		var n int
+		r := bufio.NewReader(conn)
+		if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+			got <- err.Error()
+			return
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			got <- err.Error()
+			return
+		}
+		got <- string(msg)
+	}()
+
+	s, err := Dial(context.Background(), "tcp://"+ln.Addr().String(), dialer.DialContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	write(t, s, types.Result{URL: "https://example.com/a&b.sql", Severity: "high"})
+	msg := <-got
+	if m := header.FindStringSubmatch(msg); m == nil || m[1] != "131" || !strings.HasSuffix(msg, "}") {
+		t.Errorf("message = %q", msg)
+	}
+
+	if _, err := Dial(context.Background(), "tls://"+ln.Addr().String(), dialer.DialContext); err == nil {
+		t.Error("tls:// accepted")
+	}
+}
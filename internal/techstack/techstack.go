@@ -0,0 +1,152 @@
+package techstack
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// signal infers a technology from a URL path: a file extension, or a
+// directory only that stack lays out
+type signal struct {
+	tech string
+	ext  string // Path suffix
+	dir  string // Path substring
+}
+
+// signals are checked against the lower-cased path. Extensions identify
+// the server side language; directories identify frameworks and CMSes.
+var signals = []signal{
+	{tech: "PHP", ext: ".php"},
+	{tech: "PHP", ext: ".phtml"},
+	{tech: "ASP.NET", ext: ".aspx"},
+	{tech: "ASP.NET", ext: ".ashx"},
+	{tech: "ASP.NET", ext: ".asmx"},
+	{tech: "ASP.NET", ext: ".axd"},
+	{tech: "Classic ASP", ext: ".asp"},
+	{tech: "Java", ext: ".jsp"},
+	{tech: "Java", ext: ".jspx"},
+	{tech: "Java", ext: ".do"},
+	{tech: "Java", ext: ".action"},
+	{tech: "Java", ext: ".jsf"},
+	{tech: "ColdFusion", ext: ".cfm"},
+	{tech: "ColdFusion", ext: ".cfc"},
+	{tech: "Perl", ext: ".pl"},
+	{tech: "CGI", ext: ".cgi"},
+	{tech: "WordPress", dir: "/wp-content/"},
+	{tech: "WordPress", dir: "/wp-includes/"},
+	{tech: "WordPress", dir: "/wp-admin/"},
+	{tech: "WordPress", dir: "/wp-json/"},
+	{tech: "Drupal", dir: "/sites/default/files/"},
+	{tech: "Joomla", dir: "/components/com_"},
+	{tech: "Magento", dir: "/skin/frontend/"},
+	{tech: "Next.js", dir: "/_next/"},
+	{tech: "Nuxt", dir: "/_nuxt/"},
+	{tech: "Django", dir: "/static/django/"},
+	{tech: "Django", dir: "/static/admin/"},
+	{tech: "Rails", dir: "/rails/active_storage/"},
+	{tech: "Laravel", dir: "/livewire/"},
+	{tech: "Spring Boot", dir: "/actuator/"},
+	{tech: "SharePoint", dir: "/_layouts/"},
+	{tech: "SharePoint", dir: "/_vti_bin/"},
+	{tech: "Node.js", dir: "/node_modules/"},
+}
+
+// Detect returns the technologies a URL path points to, each once, in
+// the order of signals
+func Detect(path string) []string {
+	path = strings.ToLower(path)
+	var techs []string
+	for _, s := range signals {
+		if (s.ext != "" && strings.HasSuffix(path, s.ext)) || (s.dir != "" && strings.Contains(path, s.dir)) {
+			if len(techs) == 0 || techs[len(techs)-1] != s.tech {
+				techs = append(techs, s.tech)
+			}
+		}
+	}
+	return techs
+}
+
+// Profile counts, per host, the URLs pointing to each technology. It is
+// safe for concurrent use.
+type Profile struct {
+	mu    sync.Mutex
+	hosts map[string]map[string]uint64
+}
+
+// NewProfile returns an empty profile
+func NewProfile() *Profile {
+	return &Profile{hosts: make(map[string]map[string]uint64)}
+}
+
+// Add counts the technologies rawURL points to under its host. URLs that
+// do not parse or have no host are ignored.
+func (p *Profile) Add(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return
+	}
+	techs := Detect(u.Path)
+	if len(techs) == 0 {
+		return
+	}
+	host := strings.ToLower(u.Hostname())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts := p.hosts[host]
+	if counts == nil {
+		counts = make(map[string]uint64)
+		p.hosts[strings.Clone(host)] = counts // host may share the memory of much more input
+	}
+	for _, t := range techs {
+		counts[t]++
+	}
+}
+
+// Hosts returns a copy of the counts: URLs per technology, per host
+func (p *Profile) Hosts() map[string]map[string]uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hosts := make(map[string]map[string]uint64, len(p.hosts))
+	for host, counts := range p.hosts {
+		c := make(map[string]uint64, len(counts))
+		for t, n := range counts {
+			c[t] = n
+		}
+		hosts[host] = c
+	}
+	return hosts
+}
+
+// Write prints one line per host, sorted by host, listing its
+// technologies with the most URLs first
+func Write(out io.Writer, hosts map[string]map[string]uint64) {
+	names := make([]string, 0, len(hosts))
+	width := 0
+	for host := range hosts {
+		names = append(names, host)
+		width = max(width, len(host))
+	}
+	sort.Strings(names)
+	for _, host := range names {
+		counts := hosts[host]
+		techs := make([]string, 0, len(counts))
+		for t := range counts {
+			techs = append(techs, t)
+		}
+		sort.Slice(techs, func(i, j int) bool {
+			if counts[techs[i]] != counts[techs[j]] {
+				return counts[techs[i]] > counts[techs[j]]
+			}
+			return techs[i] < techs[j]
+		})
+		parts := make([]string, len(techs))
+		for i, t := range techs {
+			parts[i] = fmt.Sprintf("%s (%d)", t, counts[t])
+		}
+		fmt.Fprintf(out, "  %-*s  %s\n", width, host, strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,48 @@
+package techstack
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// TestDetect infers technologies from extensions and directories
+func TestDetect(t *testing.T) {
+	for path, want := range map[string][]string{
+		"/index.PHP":                       {"PHP"},
+		"/login.aspx":                      {"ASP.NET"},
+		"/default.asp":                     {"Classic ASP"},
+		"/wp-content/plugins/x/readme.php": {"PHP", "WordPress"},
+		"/_next/static/chunks/main.js":     {"Next.js"},
+		"/static/django/css/base.css":      {"Django"},
+		"/actuator/health":                 {"Spring Boot"},
+		"/about":                           nil,
+		"/php/":                            nil,
+		"/docs/page.aspx.bak":              nil,
+	} {
+		if got := Detect(path); !slices.Equal(got, want) {
+			t.Errorf("Detect(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// TestProfile counts per host and prints the busiest technology first
+func TestProfile(t *testing.T) {
+	p := NewProfile()
+	for _, u := range []string{
+		"https://Blog.example.com/wp-content/a.php",
+		"https://blog.example.com:8443/wp-json/wp/v2/posts",
+		"https://blog.example.com/about",
+		"https://shop.example.com/cart.jsp",
+		"not a url",
+	} {
+		p.Add(u)
+	}
+
+	var out bytes.Buffer
+	Write(&out, p.Hosts())
+	want := "  blog.example.com  WordPress (2), PHP (1)\n  shop.example.com  Java (1)\n"
+	if out.String() != want {
+		t.Errorf("Write() = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,223 @@
+package triage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// MinKeySize is the shortest key exports are signed with, in bytes
+const MinKeySize = 16
+
+// ErrBadSignature is returned when an export was not signed with the key
+// given, or was changed after it was signed
+var ErrBadSignature = errors.New("signature does not match: wrong key or modified file")
+
+// Entry is one triage decision: a finding, or a kind of finding, that an
+// analyst judged a false positive. An entry with a fingerprint covers that
+// finding only. Otherwise it allows Host, with its subdomains, RuleID, or
+// RuleID on Host.
+type Entry struct {
+	Fingerprint string    `json:"fingerprint,omitempty"` // One finding, as computed by package fingerprint
+	URL         string    `json:"url,omitempty"`         // The finding's URL, for readers; not matched
+	Host        string    `json:"host,omitempty"`        // Allowed host, with its subdomains
+	RuleID      string    `json:"rule_id,omitempty"`     // Allowed rule; with a fingerprint, for readers only
+	Reason      string    `json:"reason,omitempty"`
+	By          string    `json:"by,omitempty"`
+	Added       time.Time `json:"added"`
+}
+
+// key identifies the findings an entry covers; entries with the same key
+// are the same decision
+func (e Entry) key() string {
+	if e.Fingerprint != "" {
+		return e.Fingerprint
+	}
+	return strings.ToLower(e.Host) + "\x00" + e.RuleID
+}
+
+// Check reports entries that cover nothing
+func (e Entry) Check() error {
+	if e.Fingerprint == "" && e.Host == "" && e.RuleID == "" {
+		return errors.New("entry has no fingerprint, host or rule_id")
+	}
+	return nil
+}
+
+// Store holds a team's triage decisions, read before a scan so the
+// findings they cover are not reported again
+type Store struct {
+	path    string
+	Entries []Entry `json:"entries"`
+
+	keys  map[string]bool
+	hosts map[string][]string // Rule IDs allowed on each host; "" for all
+	rules map[string]bool     // Rules allowed everywhere
+}
+
+// Load reads a store from path. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.index()
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, e := range s.Entries {
+		if err := e.Check(); err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", path, i+1, err)
+		}
+	}
+	s.index()
+	return s, nil
+}
+
+func (s *Store) index() {
+	s.keys = make(map[string]bool, len(s.Entries))
+	s.hosts = make(map[string][]string)
+	s.rules = make(map[string]bool)
+	for _, e := range s.Entries {
+		s.indexEntry(e)
+	}
+}
+
+func (s *Store) indexEntry(e Entry) {
+	s.keys[e.key()] = true
+	switch {
+	case e.Fingerprint != "":
+	case e.Host != "":
+		host := strings.ToLower(e.Host)
+		s.hosts[host] = append(s.hosts[host], e.RuleID)
+	default:
+		s.rules[e.RuleID] = true
+	}
+}
+
+// Add records e and reports whether it is new; a decision already in the
+// store is kept as it was
+func (s *Store) Add(e Entry) bool {
+	if s.keys[e.key()] {
+		return false
+	}
+	s.Entries = append(s.Entries, e)
+	s.indexEntry(e)
+	return true
+}
+
+// Save writes the store back to its file, replacing it atomically
+func (s *Store) Save() error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Contains reports whether a decision in the store covers the finding of
+// ruleID on rawURL, with fingerprint fp
+func (s *Store) Contains(rawURL, ruleID, fp string) bool {
+	if s.keys[fp] || s.rules[ruleID] {
+		return true
+	}
+	if len(s.hosts) == 0 {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	// The host and each domain it is under, as a.b.example.com,
+	// b.example.com, example.com, com
+	host := strings.ToLower(u.Hostname())
+	for host != "" {
+		for _, id := range s.hosts[host] {
+			if id == "" || id == ruleID {
+				return true
+			}
+		}
+		_, host, _ = strings.Cut(host, ".")
+	}
+	return false
+}
+
+// export is the shareable form of a store: its entries and an HMAC-SHA256
+// of their compact JSON under the team's key
+type export struct {
+	Entries   json.RawMessage `json:"entries"`
+	Signature string          `json:"signature"`
+}
+
+// Export writes the store's entries to w, signed with key
+func (s *Store) Export(w io.Writer, key []byte) error {
+	if len(key) < MinKeySize {
+		return fmt.Errorf("key must be at least %d bytes", MinKeySize)
+	}
+	entries, err := json.Marshal(s.Entries)
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(export{Entries: entries, Signature: sign(entries, key)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(raw, '\n'))
+	return err
+}
+
+// Import reads the entries of an export from r, checking that it was
+// signed with key. Reformatting the file does not break the signature;
+// changing an entry does.
+func Import(r io.Reader, key []byte) ([]Entry, error) {
+	var x export
+	if err := json.NewDecoder(r).Decode(&x); err != nil {
+		return nil, err
+	}
+	var entries bytes.Buffer
+	if err := json.Compact(&entries, x.Entries); err != nil {
+		return nil, err
+	}
+	got, err := hex.DecodeString(x.Signature)
+	if err != nil || !hmac.Equal(got, mac(entries.Bytes(), key)) {
+		return nil, ErrBadSignature
+	}
+	var out []Entry
+	if err := json.Unmarshal(entries.Bytes(), &out); err != nil {
+		return nil, err
+	}
+	for i, e := range out {
+		if err := e.Check(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+	return out, nil
+}
+
+func mac(data, key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sign(data, key []byte) string {
+	return hex.EncodeToString(mac(data, key))
+}
@@ -0,0 +1,87 @@
+package triage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fp.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []Entry{
+		{Fingerprint: "ff1468fa7a492e13", URL: "https://example.com/.env", Reason: "honeypot"},
+		{Host: "Static.Example.com"},
+		{Host: "example.org", RuleID: "extensions:.php"},
+		{RuleID: "keywords:admin"},
+	} {
+		if !s.Add(e) {
+			t.Errorf("Add(%+v) was not new", e)
+		}
+	}
+	if s.Add(Entry{Host: "static.example.com", Reason: "again"}) {
+		t.Error("Add took the same decision twice")
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url, rule, fp string
+		want          bool
+	}{
+		{"https://example.com/.env", "extensions:.env", "ff1468fa7a492e13", true},
+		{"https://example.com/.git/config", "paths:.git", "0000000000000001", false},
+		{"https://cdn.static.example.com/db.sql", "extensions:.sql", "0000000000000002", true},
+		{"https://notstatic.example.com/db.sql", "extensions:.sql", "0000000000000003", false},
+		{"https://www.example.org/index.php", "extensions:.php", "0000000000000004", true},
+		{"https://www.example.org/db.sql", "extensions:.sql", "0000000000000005", false},
+		{"https://anywhere.net/admin", "keywords:admin", "0000000000000006", true},
+	}
+	for _, tt := range tests {
+		if got := s.Contains(tt.url, tt.rule, tt.fp); got != tt.want {
+			t.Errorf("Contains(%q, %q) = %v; want %v", tt.url, tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	s, _ := Load(filepath.Join(t.TempDir(), "fp.json"))
+	s.Add(Entry{Fingerprint: "ff1468fa7a492e13", Reason: "<test> & co"})
+	s.Add(Entry{Host: "static.example.com"})
+	var buf bytes.Buffer
+	if err := s.Export(&buf, key); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Import(bytes.NewReader(buf.Bytes()), key)
+	if err != nil || len(entries) != 2 || entries[0].Reason != "<test> & co" {
+		t.Fatalf("Import = %+v, %v", entries, err)
+	}
+	// Reformatted, it still verifies
+	var compact bytes.Buffer
+	json.Compact(&compact, buf.Bytes())
+	if _, err := Import(&compact, key); err != nil {
+		t.Errorf("Import of the compacted export: %v", err)
+	}
+	if _, err := Import(bytes.NewReader(buf.Bytes()), []byte("another key of 16+ bytes")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Import with the wrong key = %v; want ErrBadSignature", err)
+	}
+	tampered := strings.Replace(buf.String(), "static.example.com", "example.com", 1)
+	if _, err := Import(strings.NewReader(tampered), key); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Import of a modified export = %v; want ErrBadSignature", err)
+	}
+	if err := s.Export(&buf, []byte("short")); err == nil {
+		t.Error("Export accepted a short key")
+	}
+}
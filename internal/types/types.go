@@ -1,8 +1,69 @@
 package types
 
+import "time"
+
 // Result represents a scan result
 type Result struct {
-	URL      string
-	Category string
-	Reason   string
+	URL          string   `json:"url"`
+	Category     string   `json:"category"`
+	Reason       string   `json:"reason"`
+	Severity     string   `json:"severity"`
+	Confidence   string   `json:"confidence,omitempty"`    // tentative, likely or certain
+	RuleID       string   `json:"rule_id"`                 // ID of the rule that matched
+	RuleSource   string   `json:"rule_source,omitempty"`   // builtin, the rules file, preset or plugin it came from
+	RulesHash    string   `json:"rules_hash,omitempty"`    // Identifies the whole rule set the scan used
+	Fingerprint  string   `json:"fingerprint"`             // Stable hash of normalized URL + RuleID
+	Score        int      `json:"score,omitempty"`         // Sum of matched rule weights; zero unless scoring
+	Pattern      string   `json:"pattern,omitempty"`       // Literal or regex that matched
+	Component    string   `json:"component,omitempty"`     // URL component the pattern matched in
+	Offset       int      `json:"offset"`                  // Byte offset of the match within Component
+	Source       string   `json:"source,omitempty"`        // Input file the URL was read from
+	Line         int      `json:"line,omitempty"`          // Line of the URL in Source
+	SourceOffset int64    `json:"source_offset,omitempty"` // Byte offset of the URL in Source, with Context
+	Context      string   `json:"context,omitempty"`       // Text around the URL, with -extract
+	Runs         []string `json:"runs,omitempty"`          // Results files of the runs that reported it, set by merge
+	Truncated    bool     `json:"truncated,omitempty"`     // URL or Pattern was cut to the output's size limit
+}
+
+// Stats summarizes a scan
+type Stats struct {
+	Total          uint64                       `json:"total"`                     // URLs read
+	Processed      uint64                       `json:"processed"`                 // URLs checked
+	Suspicious     uint64                       `json:"suspicious"`                // Findings
+	OutOfScope     uint64                       `json:"out_of_scope"`              // URLs outside the scope, not checked
+	OtherShards    uint64                       `json:"other_shards,omitempty"`    // URLs of other -shard slices, not checked
+	Known          uint64                       `json:"known,omitempty"`           // Findings already in the baseline, not written
+	Quarantined    uint64                       `json:"quarantined,omitempty"`     // Input lines written to -errors-out instead of checked
+	Duplicates     uint64                       `json:"duplicates,omitempty"`      // URLs read before, with -unique; not checked
+	FalsePositives uint64                       `json:"false_positives,omitempty"` // Findings covered by -fp decisions; not written or counted as suspicious
+	Repeated       uint64                       `json:"repeated,omitempty"`        // Findings written before, with -dedup; not written again
+	Unresolved     uint64                       `json:"unresolved,omitempty"`      // Findings on hosts -verify found dead; not written or counted as suspicious
+	Seconds        float64                      `json:"seconds"`                   // Time the scan took
+	Rate           float64                      `json:"rate"`                      // URLs checked per second
+	Sources        []SourceStats                `json:"sources"`                   // Per input source, in reading order
+	Severities     map[string]uint64            `json:"severities,omitempty"`      // Findings reported, by severity
+	Capped         map[string]uint64            `json:"capped,omitempty"`          // Findings over the per-rule limit, not written, by rule ID
+	Tech           map[string]map[string]uint64 `json:"tech,omitempty"`            // URLs per inferred technology, per host
+	Failed         uint64                       `json:"failed,omitempty"`          // Sources that could not be read to the end
+}
+
+// SourceStats is one input source's share of a scan
+type SourceStats struct {
+	Source     string `json:"source"` // Input file, "args", "-" or a live stream's name
+	Total      uint64 `json:"total"`
+	Processed  uint64 `json:"processed"`
+	Suspicious uint64 `json:"suspicious"`
+	OutOfScope uint64 `json:"out_of_scope"`
+	Error      string `json:"error,omitempty"` // Why the source could not be read to the end
+}
+
+// Progress is a point-in-time view of a running scan
+type Progress struct {
+	Processed uint64        // URLs checked so far
+	Matched   uint64        // Findings so far
+	Skipped   uint64        // URLs outside the scope, not checked
+	BytesRead uint64        // Input bytes consumed, including newlines
+	Elapsed   time.Duration // Time since the scan started
+	Rate      float64       // URLs checked per second
+	Done      bool          // Set on the final report
 }
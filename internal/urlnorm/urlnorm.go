@@ -0,0 +1,219 @@
+package urlnorm
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// FromWindows rewrites Windows-style locations as file URLs so they parse
+// and match like any other URL:
+//
+//	\\server\share\backup.zip        -> file://server/share/backup.zip
+//	C:\inetpub\wwwroot\web.config    -> file:///C:/inetpub/wwwroot/web.config
+//	file:///C:\inetpub\web.config    -> file:///C:/inetpub/web.config
+//
+// It reports whether the input was rewritten; other input is returned as is.
+func FromWindows(raw string) (string, bool) {
+	switch {
+	case strings.HasPrefix(raw, `\\`):
+		return "file://" + slashes(raw[2:]), true
+	case isDrivePath(raw):
+		return "file:///" + slashes(raw), true
+	case hasFileScheme(raw) && strings.Contains(raw, `\`):
+		return raw[:len("file:")] + slashes(raw[len("file:"):]), true
+	}
+	return raw, false
+}
+
+// isDrivePath reports whether s starts with a drive letter such as C:\ or C:/
+func isDrivePath(s string) bool {
+	if len(s) < 3 || s[1] != ':' || (s[2] != '\\' && s[2] != '/') {
+		return false
+	}
+	c := s[0] | 0x20 // Lower-case ASCII letters
+	return c >= 'a' && c <= 'z'
+}
+
+func hasFileScheme(s string) bool {
+	return len(s) >= 5 && strings.EqualFold(s[:5], "file:")
+}
+
+func slashes(s string) string {
+	return strings.ReplaceAll(s, `\`, "/")
+}
+
+// Unescape decodes %XX escapes once. Unlike url.PathUnescape it never
+// fails: malformed escapes are kept as they are, and "+" is left alone.
+func Unescape(s string) string {
+	i := strings.IndexByte(s, '%')
+	if i < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	b = append(b, s[:i]...)
+	for ; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			b = append(b, unhex(s[i+1])<<4|unhex(s[i+2]))
+			i += 2
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	}
+	return c - 'a' + 10
+}
+
+// Encoding tricks reported by Obfuscated
+const (
+	DoubleEncoded = "double"     // An escaped "%", as in %252e
+	MixedCaseHex  = "mixed-case" // Escapes disagreeing on hex case, as in %2e%2F
+	OverlongUTF8  = "overlong"   // Overlong UTF-8 sequences, as in %c0%ae
+)
+
+// Encodings lists the tricks Obfuscated can look for
+var Encodings = []string{DoubleEncoded, MixedCaseHex, OverlongUTF8}
+
+// Obfuscated returns the offset in raw of the first escape using the
+// given encoding trick, or -1 if there is none
+func Obfuscated(raw, encoding string) int {
+	switch encoding {
+	case DoubleEncoded:
+		return doubleEncoded(raw)
+	case MixedCaseHex:
+		return mixedCaseHex(raw)
+	case OverlongUTF8:
+		return overlong(raw)
+	}
+	return -1
+}
+
+// escapeAt reports whether a valid %XX escape starts at s[i]
+func escapeAt(s string, i int) bool {
+	return s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2])
+}
+
+func doubleEncoded(s string) int {
+	for i := 0; i+4 < len(s); i++ {
+		if escapeAt(s, i) && s[i+1] == '2' && s[i+2] == '5' && isHex(s[i+3]) && isHex(s[i+4]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hexCase returns 'a' or 'A' for an escape with letter digits, or 0
+func hexCase(c1, c2 byte) (byte, bool) {
+	var found byte
+	for _, c := range []byte{c1, c2} {
+		var k byte
+		switch {
+		case 'a' <= c && c <= 'f':
+			k = 'a'
+		case 'A' <= c && c <= 'F':
+			k = 'A'
+		default:
+			continue
+		}
+		if found != 0 && found != k {
+			return 0, false
+		}
+		found = k
+	}
+	return found, true
+}
+
+func mixedCaseHex(s string) int {
+	var first byte
+	for i := 0; i < len(s); i++ {
+		if !escapeAt(s, i) {
+			continue
+		}
+		k, ok := hexCase(s[i+1], s[i+2])
+		if !ok || (k != 0 && first != 0 && k != first) {
+			return i
+		}
+		if first == 0 {
+			first = k
+		}
+		i += 2
+	}
+	return -1
+}
+
+// overlong looks for a lead byte that encodes a character in more bytes
+// than needed: C0 and C1 always, E0 followed by 80-9F, F0 followed by 80-8F
+func overlong(s string) int {
+	for i := 0; i+5 < len(s); i++ {
+		if !escapeAt(s, i) || !escapeAt(s, i+3) {
+			continue
+		}
+		lead := unhex(s[i+1])<<4 | unhex(s[i+2])
+		next := unhex(s[i+4])<<4 | unhex(s[i+5])
+		if next < 0x80 || next > 0xBF {
+			continue
+		}
+		switch {
+		case lead == 0xC0 || lead == 0xC1,
+			lead == 0xE0 && next < 0xA0,
+			lead == 0xF0 && next < 0x90:
+			return i
+		}
+	}
+	return -1
+}
+
+// Display returns a URL in the form findings are written in: valid UTF-8
+// in NFC, with invalid bytes, control characters, and the bidi and line
+// separator characters that rearrange a line on screen percent-encoded.
+// Printable URLs, which is nearly all of them, are returned as is.
+func Display(raw string) string {
+	if printable(raw) {
+		return raw
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		if (r == utf8.RuneError && size == 1) || Hidden(r) {
+			for _, c := range []byte(raw[i : i+size]) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		} else {
+			b.WriteString(raw[i : i+size])
+		}
+		i += size
+	}
+	return norm.NFC.String(b.String())
+}
+
+// printable reports whether s is ASCII without control characters
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// Hidden reports whether r is escaped for display: C0 and C1 controls,
+// DEL, bidi controls, and the line and paragraph separators
+func Hidden(r rune) bool {
+	return unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) || r == '\u2028' || r == '\u2029'
+}
@@ -0,0 +1,84 @@
+package urlnorm
+
+import "testing"
+
+// TestFromWindows rewrites UNC and drive paths and leaves URLs alone
+func TestFromWindows(t *testing.T) {
+	tests := []struct {
+		in, want string
+		changed  bool
+	}{
+		{`\\server\share\backup.zip`, "file://server/share/backup.zip", true},
+		{`C:\inetpub\wwwroot\web.config`, "file:///C:/inetpub/wwwroot/web.config", true},
+		{`d:/logs/app.log`, "file:///d:/logs/app.log", true},
+		{`file:///C:\inetpub\web.config`, "file:///C:/inetpub/web.config", true},
+		{"file:///C:/inetpub/wwwroot/web.config", "file:///C:/inetpub/wwwroot/web.config", false},
+		{"https://example.com/a\\b", "https://example.com/a\\b", false},
+		{"//cdn.example.com/app.js", "//cdn.example.com/app.js", false},
+	}
+	for _, tt := range tests {
+		got, changed := FromWindows(tt.in)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("FromWindows(%q) = %q, %v; want %q, %v", tt.in, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+// TestUnescape decodes valid escapes once and keeps malformed ones
+func TestUnescape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"/%2e%65nv", "/.env"},
+		{"/a%2Fb?q=%41", "/a/b?q=A"},
+		{"/%252e", "/%2e"},
+		{"/100%", "/100%"},
+		{"/%zz%4", "/%zz%4"},
+		{"a+b", "a+b"},
+	}
+	for _, tt := range tests {
+		if got := Unescape(tt.in); got != tt.want {
+			t.Errorf("Unescape(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestObfuscated finds each encoding trick and its offset
+func TestObfuscated(t *testing.T) {
+	tests := []struct {
+		raw, encoding string
+		want          int
+	}{
+		{"/a/%252e%252e/etc", DoubleEncoded, 3},
+		{"/a/%2e%2e/etc", DoubleEncoded, -1},
+		{"/%2e%2F", MixedCaseHex, 4},
+		{"/%aF", MixedCaseHex, 1},
+		{"/%2e%2f%20", MixedCaseHex, -1},
+		{"/%2E%2F", MixedCaseHex, -1},
+		{"/..%c0%af..", OverlongUTF8, 3},
+		{"/%e0%80%ae", OverlongUTF8, 1},
+		{"/%c3%a9t%c3%a9", OverlongUTF8, -1},
+		{"/x", "unknown", -1},
+	}
+	for _, tt := range tests {
+		if got := Obfuscated(tt.raw, tt.encoding); got != tt.want {
+			t.Errorf("Obfuscated(%q, %q) = %d; want %d", tt.raw, tt.encoding, got, tt.want)
+		}
+	}
+}
+
+// TestDisplay escapes what would break or disguise an output line and
+// leaves printable URLs alone
+func TestDisplay(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/backup.zip", "https://example.com/backup.zip"},
+		{"https://example.com/a\x00b\x1b[31m.sql", "https://example.com/a%00b%1B[31m.sql"},
+		{"https://example.com/\xff\xfe.env", "https://example.com/%FF%FE.env"},
+		{"https://example.com/‮gpj.exe", "https://example.com/%E2%80%AEgpj.exe"},
+		{"https://example.com/café.bak", "https://example.com/café.bak"},
+		{"https://example.com/été/\u0085.git", "https://example.com/été/%C2%85.git"},
+	}
+	for _, tt := range tests {
+		if got := Display(tt.in); got != tt.want {
+			t.Errorf("Display(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
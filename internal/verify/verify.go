@@ -0,0 +1,113 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"juicyurls/internal/netclient"
+)
+
+// Verifier checks that what a finding points at still exists, so findings
+// on long-dead targets can be dropped
+type Verifier interface {
+	// Live reports whether rawURL may still be reachable. It errs towards
+	// true: only a definite answer that the target is gone is false.
+	Live(ctx context.Context, rawURL string) bool
+}
+
+// Tiers lists the -verify tiers, cheapest first
+var Tiers = []string{"dns"}
+
+// New returns the verifier for tier, with the network settings applied
+func New(tier string, o netclient.Options) (Verifier, error) {
+	switch tier {
+	case "dns":
+		return NewDNS(o)
+	}
+	return nil, errors.New("unknown tier " + tier + "; use " + strings.Join(Tiers, " or "))
+}
+
+// DNS is the DNS-only tier: a URL is dead when its host has no address,
+// because the name does not exist (NXDOMAIN) or has no A or AAAA records.
+// No HTTP request is sent. Each host is looked up once per
+// scan, however many URLs share it; lookups that fail for other reasons,
+// such as a timeout, leave the host live.
+type DNS struct {
+	lookup  func(ctx context.Context, host string) error
+	timeout time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*answer
+}
+
+// answer is the outcome of looking up one host, shared by every URL on it
+type answer struct {
+	done chan struct{}
+	live bool
+}
+
+// NewDNS returns a DNS verifier using the system resolver. Offline, it
+// fails with netclient.ErrOffline.
+func NewDNS(o netclient.Options) (*DNS, error) {
+	if o.Offline {
+		return nil, netclient.ErrOffline
+	}
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = netclient.DefaultTimeout
+	}
+	return &DNS{
+		lookup: func(ctx context.Context, host string) error {
+			_, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+			return err
+		},
+		timeout: timeout,
+		hosts:   make(map[string]*answer),
+	}, nil
+}
+
+// Live reports whether the host of rawURL resolves. URLs without a host
+// name, such as IP addresses and file paths, are live.
+func (d *DNS) Live(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return true
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+
+	d.mu.Lock()
+	a, ok := d.hosts[host]
+	if !ok {
+		a = &answer{done: make(chan struct{})}
+		d.hosts[strings.Clone(host)] = a
+	}
+	d.mu.Unlock()
+	if !ok {
+		// The answer is shared, so it must not depend on this caller
+		// giving up early
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.timeout)
+		err := d.lookup(lctx, host)
+		cancel()
+		var dnsErr *net.DNSError
+		a.live = !errors.As(err, &dnsErr) || !dnsErr.IsNotFound
+		close(a.done)
+	}
+	select {
+	case <-a.done:
+		return a.live
+	case <-ctx.Done():
+		return true
+	}
+}
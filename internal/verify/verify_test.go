@@ -0,0 +1,84 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alwalxed/juicyurls/v2/internal/netclient"
+)
+
+func TestDNS(t *testing.T) {
+	var lookups atomic.Int32
+	d := &DNS{
+		lookup: func(ctx context.Context, host string) error {
+			lookups.Add(1)
+			switch host {
+			case "gone.example.com":
+				return &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			case "flaky.example.com":
+				return &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+			}
+			return nil
+		},
+		timeout: time.Second,
+		hosts:   make(map[string]*answer),
+	}
+	ctx := context.Background()
+	for url, want := range map[string]bool{
+		"https://example.com/.env":             true,
+		"https://gone.example.com/db.sql":      false,
+		"https://GONE.example.com./backup.zip": false,
+		"https://flaky.example.com/.git/":      true,
+		"http://10.0.0.1/admin":                true,
+		"http://[::1]:8080/admin":              true,
+		"file:///etc/passwd":                   true,
+		"%":                                    true,
+	} {
+		if got := d.Live(ctx, url); got != want {
+			t.Errorf("Live(%q) = %v; want %v", url, got, want)
+		}
+	}
+	if n := lookups.Load(); n != 3 {
+		t.Errorf("%d lookups; want one per host name, 3", n)
+	}
+
+	// URLs on a host being looked up wait for its answer
+	release := make(chan struct{})
+	d.lookup = func(ctx context.Context, host string) error {
+		lookups.Add(1)
+		<-release
+		return &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	lookups.Store(0)
+	var wg sync.WaitGroup
+	var live atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d.Live(ctx, "https://slow.example.com/.env") {
+				live.Add(1)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if lookups.Load() != 1 || live.Load() != 0 {
+		t.Errorf("%d lookups, %d live; want 1, 0", lookups.Load(), live.Load())
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("http", netclient.Options{}); err == nil {
+		t.Error("New accepted an unknown tier")
+	}
+	if _, err := New("dns", netclient.Options{Offline: true}); !errors.Is(err, netclient.ErrOffline) {
+		t.Errorf("New offline = %v; want ErrOffline", err)
+	}
+}
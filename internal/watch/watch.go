@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResultsInfix marks results files, which are never scanned themselves
+const ResultsInfix = ".results"
+
+// Interval is how often the directory is polled
+const Interval = 2 * time.Second
+
+// ResultsPath returns where the results of a dropped list are written:
+// next to it, with ResultsInfix and ext appended
+func ResultsPath(path, ext string) string {
+	return path + ResultsInfix + ext
+}
+
+// stamp identifies a version of a file
+type stamp struct {
+	size int64
+	mod  time.Time
+}
+
+// Dir polls dir every interval and calls scan for each new URL list once
+// its size and modification time have held for one poll, so files still
+// being copied in are left alone. Hidden files, results files and lists
+// whose results file with ext already exists are skipped; a restarted
+// watcher therefore picks up where it left off. A list that changes after
+// its scan is scanned again. Scan errors are logged and do not stop the
+// watch, which runs until ctx ends.
+func Dir(ctx context.Context, dir string, interval time.Duration, ext string,
+	scan func(path string) error, logger *slog.Logger) error {
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return errors.New(dir + " is not a directory")
+	}
+
+	pending := make(map[string]stamp) // Seen once, waiting to settle
+	done := make(map[string]stamp)    // Scanned, or failed, at this version
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || strings.HasPrefix(name, ".") || strings.Contains(name, ResultsInfix) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue // Removed since the listing
+			}
+			path := filepath.Join(dir, name)
+			now := stamp{info.Size(), info.ModTime()}
+			if done[path] == now {
+				continue
+			}
+			if _, ok := done[path]; !ok {
+				if _, err := os.Stat(ResultsPath(path, ext)); err == nil {
+					done[path] = now
+					continue
+				}
+			}
+			if pending[path] != now {
+				pending[path] = now
+				continue
+			}
+
+			delete(pending, path)
+			done[path] = now
+			if err := scan(path); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.Error("scan failed", "file", path, "err", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
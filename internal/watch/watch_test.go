@@ -0,0 +1,59 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDir scans settled lists once, and skips results files and lists
+// that already have results
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old.txt", "https://example.com/\n")
+	write(ResultsPath("old.txt", ".txt"), "")
+	write(".partial.txt", "https://example.com/\n")
+
+	var mu sync.Mutex
+	var scanned []string
+	scan := func(path string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		scanned = append(scanned, filepath.Base(path))
+		os.WriteFile(ResultsPath(path, ".txt"), nil, 0o644)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- Dir(ctx, dir, 10*time.Millisecond, ".txt", scan, nil) }()
+	write("new.txt", "https://example.com/.env\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(scanned)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond) // Give a second scan a chance to show up
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Dir = %v; want context.Canceled", err)
+	}
+	if !slices.Equal(scanned, []string{"new.txt"}) {
+		t.Errorf("scanned %v; want [new.txt]", scanned)
+	}
+}
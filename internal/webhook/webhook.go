@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"juicyurls/internal/types"
+	"juicyurls/pkg/writer"
+)
+
+// batchSize is how many findings go in one POST
+const batchSize = 100
+
+// flushInterval is how long findings wait for a batch to fill, so alerts
+// are not held back on quiet scans
+const flushInterval = time.Second
+
+// attempts is how many times a batch is sent before the scan fails
+const attempts = 5
+
+// retryWait is the wait before the first retry, doubled for each
+// following one up to maxRetryWait
+var retryWait = time.Second
+
+const maxRetryWait = 30 * time.Second
+
+// payload is the JSON body of each POST
+type payload struct {
+	Findings []types.Result `json:"findings"`
+}
+
+// Sink POSTs findings in batches to a webhook endpoint
+type Sink struct {
+	client *http.Client
+	url    string
+
+	// MaxFieldLength cuts longer URLs, patterns and contexts, in bytes, and
+	// marks the finding truncated; zero sends them whole
+	MaxFieldLength int
+}
+
+// New returns a sink posting to endpoint, an http or https URL
+func New(client *http.Client, endpoint string) (*Sink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q (want http(s)://host/path)", endpoint)
+	}
+	return &Sink{client: client, url: endpoint}, nil
+}
+
+// Write posts results until in is closed or ctx ends. Findings are sent
+// batchSize at a time, or after flushInterval when fewer arrive. Those
+// received before ctx ends are still sent.
+func (s *Sink) Write(ctx context.Context, in <-chan types.Result) error {
+	var pending []types.Result
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		err := s.post(context.WithoutCancel(ctx), pending)
+		pending = pending[:0]
+		return err
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case r, ok := <-in:
+			if !ok {
+				return flush()
+			}
+			if s.MaxFieldLength > 0 {
+				r = writer.Truncate(r, s.MaxFieldLength)
+			}
+			pending = append(pending, r)
+			if len(pending) == batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// post sends one batch, retrying with exponential backoff on connection
+// errors, 429 and 5xx responses. Other responses fail at once, as sending
+// the same batch again would not help.
+func (s *Sink) post(ctx context.Context, batch []types.Result) error {
+	body, err := json.Marshal(payload{Findings: batch})
+	if err != nil {
+		return err
+	}
+	wait := retryWait
+	for attempt := 1; ; attempt++ {
+		retry, after, err := s.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == attempts {
+			return fmt.Errorf("webhook: %d findings not delivered after %d attempts: %w", len(batch), attempt, err)
+		}
+		if after > 0 {
+			wait = after
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait = min(wait*2, maxRetryWait)
+	}
+}
+
+// send makes one attempt, reporting whether a failure is worth retrying
+// and how long the endpoint asked to wait first
+func (s *Sink) send(ctx context.Context, body []byte) (retry bool, after time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 == 2 {
+		return false, 0, nil
+	}
+	err = fmt.Errorf("endpoint answered %s", resp.Status)
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return false, 0, err
+	}
+	if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs > 0 {
+		after = min(time.Duration(secs)*time.Second, maxRetryWait)
+	}
+	return true, after, err
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+func write(t *testing.T, s *Sink, n int) error {
+	t.Helper()
+	in := make(chan types.Result, n)
+	for i := range n {
+		in <- types.Result{URL: "https://example.com/.env", RuleID: "extensions:.env", Line: i + 1}
+	}
+	close(in)
+	return s.Write(context.Background(), in)
+}
+
+// TestWrite batches findings and retries deliveries the endpoint fails
+// for now, but not those it rejects
+func TestWrite(t *testing.T) {
+	retryWait = time.Millisecond
+	var calls, received, truncated atomic.Int32
+	status := atomic.Int32{}
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		var p payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("bad request: %v", err)
+		}
+		received.Add(int32(len(p.Findings)))
+		for _, f := range p.Findings {
+			if f.Truncated && len(f.URL) <= 20 {
+				truncated.Add(1)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	s, err := New(srv.Client(), srv.URL+"/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := write(t, s, batchSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || received.Load() != batchSize+1 {
+		t.Errorf("%d calls, %d findings received; want a retry and 2 batches of %d", calls.Load(), received.Load(), batchSize+1)
+	}
+
+	// Each sink has a field limit of its own
+	s.MaxFieldLength = 20
+	if err := write(t, s, 1); err != nil || truncated.Load() != 1 {
+		t.Errorf("err = %v, %d findings truncated; want the URL cut to 20 bytes", err, truncated.Load())
+	}
+
+	calls.Store(0)
+	status.Store(http.StatusBadRequest)
+	if err := write(t, s, 1); err == nil || !strings.Contains(err.Error(), "after 1 attempts") {
+		t.Errorf("err = %v; want a rejected batch to fail at once", err)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	s, _ = New(down.Client(), down.URL)
+	if err := write(t, s, 1); err == nil || !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Errorf("err = %v; want 5 attempts", err)
+	}
+	if _, err := New(http.DefaultClient, "hooks.example.com/x"); err == nil {
+		t.Error("URL without a scheme accepted")
+	}
+}
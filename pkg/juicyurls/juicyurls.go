@@ -0,0 +1,269 @@
+package juicyurls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"juicyurls/config"
+	"juicyurls/internal/checker"
+	"juicyurls/internal/fingerprint"
+	"juicyurls/internal/metrics"
+	"juicyurls/internal/processor"
+	"juicyurls/internal/rules"
+	"juicyurls/internal/scope"
+	"juicyurls/internal/types"
+	"juicyurls/internal/urlnorm"
+)
+
+// Rule is a detection, suppress or downgrade rule, with the fields of a
+// rules file entry. Rules built in code are validated by New.
+type Rule = rules.Rule
+
+// Condition is what a Rule matches; see the rules file documentation
+type Condition = rules.Condition
+
+// Matcher is a check written in Go, run after the rule categories. It
+// returns findings in priority order; empty severity, confidence and
+// weight get the defaults of the finding's category.
+type Matcher = checker.Matcher
+
+// ParsedURL is the URL a Matcher inspects, with accessors for its
+// components (Host, Path, Query, ...)
+type ParsedURL = rules.Target
+
+// Finding is what a Matcher reports
+type Finding = checker.Finding
+
+// Location is where in the URL a finding matched
+type Location = rules.Location
+
+// Rule types
+const (
+	TypeDetect    = rules.TypeDetect
+	TypeSuppress  = rules.TypeSuppress
+	TypeDowngrade = rules.TypeDowngrade
+)
+
+// Result is a finding
+type Result struct {
+	URL         string `json:"url"`
+	Category    string `json:"category"`
+	Reason      string `json:"reason"`
+	Severity    string `json:"severity"`
+	Confidence  string `json:"confidence"` // tentative, likely or certain
+	RuleID      string `json:"rule_id"`
+	RuleSource  string `json:"rule_source"`         // "builtin", a rules file path or "plugin <name>"
+	RulesHash   string `json:"rules_hash"`          // Identifies the rule set; see Scanner.RulesHash
+	Fingerprint string `json:"fingerprint"`         // Stable hash of normalized URL + RuleID
+	Score       int    `json:"score,omitempty"`     // Sum of matched rule weights; zero unless scoring
+	Pattern     string `json:"pattern,omitempty"`   // Literal or regex that matched
+	Component   string `json:"component,omitempty"` // URL component the pattern matched in
+	Offset      int    `json:"offset"`              // Byte offset of the match within Component
+	Line        int    `json:"line,omitempty"`      // Line of the URL in the scanned input
+}
+
+// Options configure a Scanner. The zero value checks every built-in
+// category with one worker per CPU.
+type Options struct {
+	Categories    []string  // Categories to check; empty checks all
+	Excludes      []string  // URLs matching one are skipped; "re:" marks a regex
+	Rules         []Rule    // Extra rules, as from LoadRules
+	MinSeverity   string    // Lowest severity reported; empty reports all
+	MinConfidence string    // Lowest confidence reported; empty reports all
+	Scoring       bool      // Evaluate every rule and report the summed weights
+	MinScore      int       // Lowest score reported; implies Scoring
+	Scope         []string  // In-scope domains; URLs outside are skipped. Empty scans all
+	Matchers      []Matcher // Checked in order after the rule categories
+	Workers       int       // Concurrent checks in Scan; zero uses one per CPU
+	Metrics       *Metrics  // Counts the scanner's work for Prometheus; nil counts nothing
+}
+
+// Metrics counts URLs checked, findings per category, the processing rate
+// and worker use, and serves them for Prometheus through its Handler. One
+// Metrics can be shared by several scanners.
+type Metrics = metrics.Metrics
+
+// NewMetrics returns Metrics counting from now
+func NewMetrics() *Metrics {
+	return metrics.New()
+}
+
+// Scanner checks URLs against the built-in and configured rules. It is
+// safe for concurrent use.
+type Scanner struct {
+	opts    Options
+	checker *checker.URLChecker
+	scope   *scope.Scope
+}
+
+// LoadRules reads rules files
+func LoadRules(paths ...string) ([]Rule, error) {
+	return rules.LoadFiles(paths)
+}
+
+// New builds a Scanner
+func New(opts Options) (*Scanner, error) {
+	extra := append([]Rule(nil), opts.Rules...)
+	if err := rules.Prepare(extra); err != nil {
+		return nil, err
+	}
+	if err := rules.UniqueIDs(extra); err != nil {
+		return nil, err
+	}
+	known := rules.CategoryNames(extra)
+	for _, c := range opts.Categories {
+		if !slices.Contains(known, strings.ToLower(strings.TrimSpace(c))) {
+			return nil, fmt.Errorf("unknown category %q (want one of %s)", c, strings.Join(known, ", "))
+		}
+	}
+	uc := checker.NewURLChecker(strings.Join(opts.Categories, ","), "", extra...)
+	if err := uc.AddExcludes(opts.Excludes...); err != nil {
+		return nil, err
+	}
+	if err := uc.SetMinSeverity(opts.MinSeverity); err != nil {
+		return nil, err
+	}
+	if err := uc.SetMinConfidence(opts.MinConfidence); err != nil {
+		return nil, err
+	}
+	for _, m := range opts.Matchers {
+		uc.Register(m)
+	}
+	s := &Scanner{opts: opts, checker: uc}
+	if len(opts.Scope) > 0 {
+		var err error
+		if s.scope, err = scope.New(opts.Scope); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// RulesHash identifies the scanner's rule set. Two scanners with the same
+// hash apply the same rules in the same order; Matchers are not covered.
+func (s *Scanner) RulesHash() string {
+	return s.checker.RulesHash()
+}
+
+// Categories returns the rule categories the scanner checks, in the order
+// it checks them
+func (s *Scanner) Categories() []string {
+	var names []string
+	for _, cat := range s.checker.Taxonomy().Categories {
+		names = append(names, cat.Name)
+	}
+	return names
+}
+
+// Check reports whether a single URL is suspicious
+func (s *Scanner) Check(url string) (Result, bool) {
+	if s.scope != nil && !s.scope.Contains(url) {
+		return Result{}, false
+	}
+	var f checker.Finding
+	var score int
+	var ok bool
+	if s.opts.Scoring || s.opts.MinScore > 0 {
+		f, score, ok = s.checker.Score(url)
+		ok = ok && score >= s.opts.MinScore
+	} else {
+		f, ok = s.checker.Check(url)
+	}
+	s.opts.Metrics.Processed()
+	if !ok {
+		return Result{}, false
+	}
+	s.opts.Metrics.Finding(f.Category)
+	found := s.finding(url, f)
+	found.Score = score
+	return found, true
+}
+
+// Explain returns every rule that fires on a URL, in rule order, where
+// Check reports the most severe. Each result's Score is its rule's weight.
+func (s *Scanner) Explain(url string) []Result {
+	if s.scope != nil && !s.scope.Contains(url) {
+		return nil
+	}
+	var out []Result
+	for _, f := range s.checker.Matches(url) {
+		found := s.finding(url, f)
+		found.Score = f.Weight
+		out = append(out, found)
+	}
+	return out
+}
+
+// finding describes a checker finding on url
+func (s *Scanner) finding(url string, f checker.Finding) Result {
+	return Result{
+		URL:         urlnorm.Display(url),
+		Category:    f.Category,
+		Reason:      f.Reason,
+		Severity:    f.Severity,
+		Confidence:  f.Confidence,
+		RuleID:      f.RuleID,
+		RuleSource:  f.RuleSource,
+		RulesHash:   s.checker.RulesHash(),
+		Fingerprint: fingerprint.Compute(url, f.RuleID),
+		Pattern:     f.Match.Pattern,
+		Component:   f.Match.Component,
+		Offset:      f.Match.Offset,
+	}
+}
+
+// Scan checks the URLs in r, one per line, and delivers findings on the
+// returned channel in no particular order. The channel is closed once r
+// is exhausted or ctx ends; drain it or cancel ctx to release the scan.
+func (s *Scanner) Scan(ctx context.Context, r io.Reader) (<-chan Result, error) {
+	if r == nil {
+		return nil, errors.New("juicyurls: nil reader")
+	}
+	found, err := processor.Stream(ctx, &config.Config{
+		Reader:     r,
+		Workers:    s.opts.Workers,
+		Scoring:    s.opts.Scoring,
+		MinScore:   s.opts.MinScore,
+		Scope:      s.scope,
+		URLChecker: s.checker,
+		Metrics:    s.opts.Metrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		for f := range found {
+			select {
+			case out <- result(f):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func result(r types.Result) Result {
+	return Result{
+		URL:         r.URL,
+		Category:    r.Category,
+		Reason:      r.Reason,
+		Severity:    r.Severity,
+		Confidence:  r.Confidence,
+		RuleID:      r.RuleID,
+		RuleSource:  r.RuleSource,
+		RulesHash:   r.RulesHash,
+		Fingerprint: r.Fingerprint,
+		Score:       r.Score,
+		Pattern:     r.Pattern,
+		Component:   r.Component,
+		Offset:      r.Offset,
+		Line:        r.Line,
+	}
+}
@@ -0,0 +1,100 @@
+package juicyurls
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestScan streams findings with their input lines
+func TestScan(t *testing.T) {
+	s, err := New(Options{
+		Rules: []Rule{{ID: "tools:/jenkinsx", Category: "internal-tools", Condition: Condition{Pattern: "/jenkinsx"}}},
+		Scope: []string{"example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "https://example.com/.env\nhttps://example.com/\nhttps://other.net/.env\nhttps://ci.example.com/jenkinsx\n"
+	found, err := s.Scan(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for r := range found {
+		got = append(got, r.Category+"@"+string(rune('0'+r.Line)))
+	}
+	sort.Strings(got)
+	if want := "hidden@1 internal-tools@4"; strings.Join(got, " ") != want {
+		t.Errorf("Scan = %v; want %s", got, want)
+	}
+
+	if r, ok := s.Check("https://example.com/backup.sql"); !ok || r.Fingerprint == "" {
+		t.Errorf("Check = %+v, %v; want a finding", r, ok)
+	}
+	if _, ok := s.Check("https://other.net/backup.sql"); ok {
+		t.Error("Check reported an out-of-scope URL")
+	}
+}
+
+// stagingMatcher flags one host from code
+type stagingMatcher struct{}
+
+func (stagingMatcher) Match(u *ParsedURL) []Finding {
+	if u.Host() != "staging.example.com" {
+		return nil
+	}
+	return []Finding{{Category: "inventory", RuleID: "inventory:staging", Reason: "Staging host", Confidence: "certain"}}
+}
+
+// TestMatchers reports findings from matchers passed in Options
+func TestMatchers(t *testing.T) {
+	s, err := New(Options{Matchers: []Matcher{stagingMatcher{}}, MinConfidence: "certain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := s.Check("https://staging.example.com/"); !ok || r.RuleID != "inventory:staging" || r.Severity != "medium" {
+		t.Errorf("Check = %+v, %v; want inventory:staging at medium", r, ok)
+	}
+}
+
+// TestExplain lists every matching rule where Check reports the most severe
+func TestExplain(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := s.Explain("https://example.com/dump.sql")
+	var ids []string
+	for _, r := range found {
+		ids = append(ids, r.RuleID)
+	}
+	if len(found) < 2 || !slices.Contains(ids, "extensions:sql-dump") || found[0].Score == 0 {
+		t.Errorf("Explain = %v; want several rules including extensions:sql-dump", ids)
+	}
+	if r, _ := s.Check("https://example.com/dump.sql"); r.RuleID != "extensions:sql-dump" || ids[0] == r.RuleID {
+		t.Errorf("Check = %s; want the high extensions:sql-dump rather than the first explained rule %s", r.RuleID, ids[0])
+	}
+	if found := s.Explain("https://example.com/"); len(found) != 0 {
+		t.Errorf("Explain clean URL = %+v", found)
+	}
+}
+
+// TestNewInvalid rejects rules and options that would not work
+func TestNewInvalid(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"rule without id":  {Rules: []Rule{{Category: "x", Condition: Condition{Pattern: "x"}}}},
+		"bad severity":     {MinSeverity: "severe"},
+		"bad confidence":   {MinConfidence: "sure"},
+		"bad exclude":      {Excludes: []string{"re:("}},
+		"unknown category": {Categories: []string{"nope"}},
+		"public suffix":    {Scope: []string{"com"}},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
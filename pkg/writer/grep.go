@@ -0,0 +1,41 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"juicyurls/internal/types"
+)
+
+const highlight = "\x1b[1;31m" // Bold red, as grep --color
+
+// writeGrep prints r as source:line:url:category:pattern, which vim's
+// quickfix and VS Code's problem matchers read as file, line and message.
+// When color is set the matched pattern is highlighted in the URL.
+func writeGrep(out io.Writer, r types.Result, color bool) error {
+	u := r.URL
+	if color {
+		if i, n := matchSpan(r); n > 0 {
+			u = u[:i] + highlight + u[i:i+n] + colorReset + u[i+n:]
+		}
+	}
+	_, err := fmt.Fprintf(out, "%s:%d:%s:%s:%s\n", r.Source, r.Line, u, r.Category, r.Pattern)
+	return err
+}
+
+// matchSpan locates a literal pattern in the URL, preferring the reported
+// offset; regex and detector patterns are not highlighted
+func matchSpan(r types.Result) (int, int) {
+	n := len(r.Pattern)
+	if n == 0 {
+		return 0, 0
+	}
+	if r.Component == "url" && r.Offset+n <= len(r.URL) && strings.EqualFold(r.URL[r.Offset:r.Offset+n], r.Pattern) {
+		return r.Offset, n
+	}
+	if i := strings.Index(strings.ToLower(r.URL), strings.ToLower(r.Pattern)); i >= 0 {
+		return i, n
+	}
+	return 0, 0
+}
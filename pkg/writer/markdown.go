@@ -0,0 +1,96 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"juicyurls/internal/rules"
+	"juicyurls/internal/types"
+	"juicyurls/suspicious"
+)
+
+// report collects findings for FormatMarkdown
+type report []types.Result
+
+// write prints a findings section for an engagement report: a count per
+// severity, then one table per severity and category, most severe first
+func (rep report) write(out io.Writer) error {
+	sort.SliceStable(rep, func(i, j int) bool {
+		a, b := rep[i], rep[j]
+		if ra, rb := suspicious.SeverityRank(a.Severity), suspicious.SeverityRank(b.Severity); ra != rb {
+			return ra > rb
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.URL < b.URL
+	})
+
+	var b strings.Builder
+	b.WriteString("## Findings\n\n")
+	if len(rep) == 0 {
+		b.WriteString("No findings.\n")
+		_, err := io.WriteString(out, b.String())
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, r := range rep {
+		counts[r.Severity]++
+	}
+	b.WriteString("| Severity | Findings |\n|---|---|\n")
+	for i := len(suspicious.Severities) - 1; i >= 0; i-- {
+		if s := suspicious.Severities[i]; counts[s] > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", title(s), counts[s])
+		}
+	}
+	if hash := rep[0].RulesHash; hash != "" {
+		fmt.Fprintf(&b, "\nRule set `%s`.\n", hash)
+	}
+
+	for i := 0; i < len(rep); {
+		sev, cat := rep[i].Severity, rep[i].Category
+		if i == 0 || rep[i-1].Severity != sev {
+			fmt.Fprintf(&b, "\n### %s\n", title(sev))
+		}
+		j := i
+		for j < len(rep) && rep[j].Severity == sev && rep[j].Category == cat {
+			j++
+		}
+		fmt.Fprintf(&b, "\n#### %s (%d)\n\n", cat, j-i)
+		b.WriteString("| URL | Reason | Confidence | Rule | Evidence | Fingerprint |\n|---|---|---|---|---|---|\n")
+		for _, r := range rep[i:j] {
+			evidence := ""
+			if r.Pattern != "" {
+				evidence = fmt.Sprintf("`%s` in %s at %d", strings.ReplaceAll(r.Pattern, "`", "'"), r.Component, r.Offset)
+			}
+			rule := "`" + r.RuleID + "`"
+			if r.RuleSource != "" && r.RuleSource != rules.SourceBuiltin {
+				rule += " (" + cell(r.RuleSource) + ")"
+			}
+			fmt.Fprintf(&b, "| [%s](<%s>) | %s | %s | %s | %s | `%s` |\n",
+				cell(r.URL), linkTarget.Replace(r.URL), cell(r.Reason), r.Confidence, rule, cell(evidence), r.Fingerprint)
+		}
+		i = j
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// linkTarget escapes the characters that would end a link or a table cell
+var linkTarget = strings.NewReplacer(">", "%3E", "|", "%7C", " ", "%20")
+
+// cell escapes text for a Markdown table cell
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "[", `\[`, "]", `\]`).Replace(s)
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
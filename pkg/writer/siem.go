@@ -0,0 +1,240 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"juicyurls/internal/types"
+)
+
+// SIEM header values
+const (
+	siemVendor  = "juicyurls"
+	siemProduct = "juicyurls"
+	siemVersion = "2"
+)
+
+// resultFields lists the result fields a SIEM field map can name, in the
+// order they are written
+var resultFields = []string{
+	"url", "category", "reason", "severity", "confidence", "rule_id", "rule_source",
+	"rules_hash", "fingerprint", "score", "pattern", "component", "offset", "source", "line",
+	"source_offset", "context", "truncated",
+}
+
+// CEFFields maps result fields to CEF extension keys. Fields mapped to ""
+// are left out; rule_id and severity are in the header already, and
+// rule_source, source_offset and context have no key unless mapped with
+// ApplyFieldMap. Custom
+// keys (cs1, cn1, ...) get a matching Label key naming the field.
+var CEFFields = map[string]string{
+	"url":         "request",
+	"category":    "cat",
+	"reason":      "msg",
+	"fingerprint": "cs1",
+	"pattern":     "cs2",
+	"component":   "cs3",
+	"offset":      "cn1",
+	"score":       "cn2",
+	"source":      "fname",
+	"line":        "cn3",
+	"confidence":  "cs5",
+	"rules_hash":  "cs6",
+	"truncated":   "flexString1",
+}
+
+// LEEFFields maps result fields to LEEF attribute keys, as CEFFields does
+// for CEF
+var LEEFFields = map[string]string{
+	"url":           "url",
+	"category":      "cat",
+	"reason":        "msg",
+	"severity":      "sev",
+	"confidence":    "confidence",
+	"rule_id":       "ruleId",
+	"rule_source":   "ruleSource",
+	"rules_hash":    "rulesHash",
+	"fingerprint":   "fingerprint",
+	"pattern":       "pattern",
+	"component":     "component",
+	"offset":        "offset",
+	"score":         "score",
+	"source":        "fileName",
+	"line":          "line",
+	"source_offset": "sourceOffset",
+	"context":       "context",
+	"truncated":     "truncated",
+}
+
+// siemSeverities converts severities to the 0-10 scale of CEF and LEEF
+var siemSeverities = map[string]int{"info": 1, "low": 3, "medium": 5, "high": 8, "critical": 10}
+
+// siemKey restricts mapped keys to what CEF and LEEF parsers accept
+var siemKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.]*$`)
+
+// customKey matches CEF's numbered custom fields, which need labels
+var customKey = regexp.MustCompile(`^(cs|cn|cfp|flexString|deviceCustomDate)[0-9]$`)
+
+// fieldMap is the on-disk SIEM field map layout
+type fieldMap struct {
+	CEF  map[string]string `yaml:"cef"`
+	LEEF map[string]string `yaml:"leef"`
+}
+
+// ApplyFieldMap loads a YAML field map from path and applies it over
+// CEFFields and LEEFFields. A field mapped to "" or "-" is dropped.
+func ApplyFieldMap(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m fieldMap
+	if err := yaml.Unmarshal(raw, &m); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, set := range []struct {
+		name string
+		from map[string]string
+		to   map[string]string
+	}{{"cef", m.CEF, CEFFields}, {"leef", m.LEEF, LEEFFields}} {
+		for field, key := range set.from {
+			if !validField(field) {
+				return fmt.Errorf("%s: %s: unknown result field %q (want one of %s)",
+					path, set.name, field, strings.Join(resultFields, ", "))
+			}
+			if key == "-" {
+				key = ""
+			}
+			if key != "" && !siemKey.MatchString(key) {
+				return fmt.Errorf("%s: %s: invalid key %q for %s", path, set.name, key, field)
+			}
+			set.to[field] = key
+		}
+		seen := map[string]string{}
+		for _, field := range resultFields {
+			key := set.to[field]
+			if other, dup := seen[key]; dup && key != "" {
+				return fmt.Errorf("%s: %s: %s and %s both map to %q", path, set.name, other, field, key)
+			}
+			seen[key] = field
+		}
+	}
+	return nil
+}
+
+func validField(name string) bool {
+	for _, f := range resultFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// siemValues returns a result's non-empty fields by name
+func siemValues(r types.Result) map[string]string {
+	v := map[string]string{
+		"url":         r.URL,
+		"category":    r.Category,
+		"reason":      r.Reason,
+		"severity":    r.Severity,
+		"confidence":  r.Confidence,
+		"rule_id":     r.RuleID,
+		"rule_source": r.RuleSource,
+		"rules_hash":  r.RulesHash,
+		"fingerprint": r.Fingerprint,
+		"pattern":     r.Pattern,
+		"component":   r.Component,
+		"source":      r.Source,
+	}
+	if r.Score > 0 {
+		v["score"] = strconv.Itoa(r.Score)
+	}
+	if r.Pattern != "" {
+		v["offset"] = strconv.Itoa(r.Offset)
+	}
+	if r.Line > 0 {
+		v["line"] = strconv.Itoa(r.Line)
+	}
+	if r.Context != "" {
+		v["source_offset"] = strconv.FormatInt(r.SourceOffset, 10)
+		v["context"] = r.Context
+	}
+	if r.Truncated {
+		v["truncated"] = "true"
+	}
+	return v
+}
+
+// mapped pairs each mapped key with its value, in resultFields order
+func mapped(r types.Result, fields map[string]string) [][2]string {
+	values := siemValues(r)
+	var out [][2]string
+	for _, f := range resultFields {
+		if key := fields[f]; key != "" && values[f] != "" {
+			out = append(out, [2]string{key, values[f]})
+		}
+	}
+	return out
+}
+
+var (
+	cefHeader    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtension = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeader   = strings.NewReplacer(`|`, `\|`)
+	leefValue    = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// writeCEF prints r as an ArcSight Common Event Format line
+func writeCEF(out io.Writer, r types.Result) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|", siemVendor, siemProduct, siemVersion,
+		cefHeader.Replace(r.RuleID), cefHeader.Replace(r.Reason), siemSeverities[r.Severity])
+
+	pairs := mapped(r, CEFFields)
+	fieldOf := make(map[string]string, len(CEFFields))
+	for f, key := range CEFFields {
+		fieldOf[key] = f
+	}
+	var labels []string
+	for i, kv := range pairs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(kv[0] + "=" + cefExtension.Replace(kv[1]))
+		if customKey.MatchString(kv[0]) {
+			labels = append(labels, kv[0]+"Label="+fieldOf[kv[0]])
+		}
+	}
+	for _, l := range labels {
+		b.WriteString(" " + l)
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// writeLEEF prints r as an IBM QRadar Log Event Extended Format 2.0 line
+// with tab-separated attributes
+func writeLEEF(out io.Writer, r types.Result) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:2.0|%s|%s|%s|%s|x09|", siemVendor, siemProduct, siemVersion, leefHeader.Replace(r.RuleID))
+	for i, kv := range mapped(r, LEEFFields) {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		if kv[0] == "sev" {
+			kv[1] = strconv.Itoa(siemSeverities[r.Severity]) // LEEF's sev is 1-10
+		}
+		b.WriteString(kv[0] + "=" + leefValue.Replace(kv[1]))
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,125 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"juicyurls/internal/types"
+)
+
+// Result is a finding as handed to sinks
+type Result = types.Result
+
+// Sink is an output destination added from outside this package, such as
+// a message bus or a database juicyurls has no built-in support for.
+// Write is called for each finding, one at a time; Flush delivers what
+// Write has buffered, and is called every second while findings arrive
+// and once more when the scan ends. A sink that also implements io.Closer
+// is closed after the final Flush.
+type Sink interface {
+	Write(ctx context.Context, r Result) error
+	Flush() error
+}
+
+// SinkOpener opens a sink for an -o value such as kafka://broker/topic;
+// the whole value is passed, scheme included
+type SinkOpener func(ctx context.Context, target string) (Sink, error)
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = make(map[string]SinkOpener)
+)
+
+// reservedSinks are the -o schemes juicyurls handles itself
+var reservedSinks = map[string]bool{"sqlite": true}
+
+// RegisterSink makes a sink available as -o name://target. It is meant to
+// be called from the init function of the package that implements the
+// sink, and panics if name is taken or open is nil, as database/sql's
+// Register does.
+func RegisterSink(name string, open SinkOpener) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if open == nil {
+		panic("writer: RegisterSink opener is nil")
+	}
+	if name == "" || strings.ContainsAny(name, ":/") || reservedSinks[name] {
+		panic(fmt.Sprintf("writer: invalid sink name %q", name))
+	}
+	if _, dup := sinks[name]; dup {
+		panic(fmt.Sprintf("writer: RegisterSink called twice for %q", name))
+	}
+	sinks[name] = open
+}
+
+// Sinks returns the names of the registered sinks, sorted
+func Sinks() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupSink returns the opener of the registered sink an -o value names
+// by its scheme
+func LookupSink(output string) (SinkOpener, bool) {
+	name, _, ok := strings.Cut(output, "://")
+	if !ok {
+		return nil, false
+	}
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	open, ok := sinks[name]
+	return open, ok
+}
+
+// IsSinkTarget reports whether an -o value names a registered sink rather
+// than a file
+func IsSinkTarget(output string) bool {
+	_, ok := LookupSink(output)
+	return ok
+}
+
+// WriteSink hands results to s until in is closed or ctx ends, flushing
+// every second and at the end. Results are cut to maxField bytes, as in
+// the structured formats; zero hands them over whole.
+func WriteSink(ctx context.Context, in <-chan types.Result, s Sink, maxField int) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if pending {
+				if err := s.Flush(); err != nil {
+					return err
+				}
+				pending = false
+			}
+		case r, ok := <-in:
+			if !ok {
+				return s.Flush()
+			}
+			if maxField > 0 {
+				r = Truncate(r, maxField)
+			}
+			if err := s.Write(ctx, r); err != nil {
+				return err
+			}
+			pending = true
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"juicyurls/internal/types"
+	"juicyurls/suspicious"
+)
+
+// severityColors are the ANSI colors of each severity in summary output
+var severityColors = map[string]string{
+	"info":     "\x1b[2m",    // Dim
+	"low":      "\x1b[36m",   // Cyan
+	"medium":   "\x1b[33m",   // Yellow
+	"high":     "\x1b[31m",   // Red
+	"critical": "\x1b[1;35m", // Bold magenta
+}
+
+const colorReset = "\x1b[0m"
+
+// hostSummary counts one host's findings per category
+type hostSummary struct {
+	host   string
+	worst  int            // Highest severity rank seen
+	counts map[string]int // Findings per category
+	levels map[string]int // Highest severity rank per category
+}
+
+// summary groups findings by host for FormatSummary
+type summary map[string]*hostSummary
+
+func (s summary) add(r types.Result) {
+	host := r.URL
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	h, ok := s[host]
+	if !ok {
+		h = &hostSummary{host: host, worst: -1, counts: map[string]int{}, levels: map[string]int{}}
+		s[host] = h
+	}
+	rank := suspicious.SeverityRank(r.Severity)
+	h.counts[r.Category]++
+	if n, seen := h.levels[r.Category]; !seen || rank > n {
+		h.levels[r.Category] = rank
+	}
+	h.worst = max(h.worst, rank)
+}
+
+// write prints one line per host, most severe hosts first, with each
+// category count colored by its highest severity when color is set
+func (s summary) write(out io.Writer, color bool) error {
+	hosts := make([]*hostSummary, 0, len(s))
+	width := 0
+	for _, h := range s {
+		hosts = append(hosts, h)
+		width = max(width, len(h.host))
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].worst != hosts[j].worst {
+			return hosts[i].worst > hosts[j].worst
+		}
+		return hosts[i].host < hosts[j].host
+	})
+
+	for _, h := range hosts {
+		cats := make([]string, 0, len(h.counts))
+		for c := range h.counts {
+			cats = append(cats, c)
+		}
+		sort.Slice(cats, func(i, j int) bool {
+			if h.levels[cats[i]] != h.levels[cats[j]] {
+				return h.levels[cats[i]] > h.levels[cats[j]]
+			}
+			return cats[i] < cats[j]
+		})
+
+		parts := make([]string, len(cats))
+		for i, c := range cats {
+			parts[i] = fmt.Sprintf("%s:%d", c, h.counts[c])
+			if color && h.levels[c] >= 0 {
+				parts[i] = Colorize(suspicious.Severities[h.levels[c]], parts[i])
+			}
+		}
+		if _, err := fmt.Fprintf(out, "%-*s  %s\n", width, h.host, strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Colorize wraps s in the ANSI color of a severity, as in summary output.
+// Unknown severities are left plain.
+func Colorize(severity, s string) string {
+	if c, ok := severityColors[severity]; ok {
+		return c + s + colorReset
+	}
+	return s
+}
+
+// IsTerminal reports whether w is a terminal that accepts colors
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
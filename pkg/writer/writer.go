@@ -2,16 +2,77 @@ package writer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"unicode/utf8"
 
 	"juicyurls/internal/types" // <--- NEW IMPORT
 )
 
-// WriteResults writes results to output file or stdout
+// Output formats
+const (
+	FormatText     = "text"     // One URL per line; details when verbose
+	FormatJSON     = "json"     // One JSON object per line
+	FormatSummary  = "summary"  // One line per host with category counts
+	FormatMarkdown = "markdown" // Findings tables for pentest reports
+	FormatGrep     = "grep"     // source:line:url:category:pattern for quickfix lists
+	FormatCEF      = "cef"      // ArcSight Common Event Format, fields per CEFFields
+	FormatLEEF     = "leef"     // QRadar Log Event Extended Format, fields per LEEFFields
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatText, FormatJSON, FormatSummary, FormatMarkdown, FormatGrep, FormatCEF, FormatLEEF}
+
+// ValidFormat reports whether f is one of Formats
+func ValidFormat(f string) bool {
+	for _, v := range Formats {
+		if f == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFormat returns an error naming the supported formats if f is not one
+func CheckFormat(f string) error {
+	if f == "" || ValidFormat(f) {
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (want one of %s)", f, strings.Join(Formats, ", "))
+}
+
+// Truncate cuts r's URL, pattern and context to max bytes, on a UTF-8
+// boundary, and marks r Truncated if any was longer
+func Truncate(r types.Result, max int) types.Result {
+	if len(r.URL) > max {
+		r.URL, r.Truncated = cut(r.URL, max), true
+	}
+	if len(r.Pattern) > max {
+		r.Pattern, r.Truncated = cut(r.Pattern, max), true
+	}
+	if len(r.Context) > max {
+		r.Context, r.Truncated = cut(r.Context, max), true
+	}
+	return r
+}
+
+func cut(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// WriteStream writes results to output file or stdout. maxField caps the
+// URL, pattern and context written in the structured formats (json, cef,
+// leef), in bytes, so one huge data: URI cannot break a downstream
+// ingestion limit; longer values are cut and the result is marked
+// Truncated. Zero writes them whole.
 func WriteStream(ctx context.Context, in <-chan types.Result,
-	outputPath string, verbose bool) error {
+	outputPath, format string, verbose bool, maxField int) error {
 
 	var out io.Writer = os.Stdout
 	if outputPath != "" {
@@ -22,20 +83,86 @@ func WriteStream(ctx context.Context, in <-chan types.Result,
 		defer f.Close()
 		out = f
 	}
+	return WriteStreamTo(ctx, in, out, format, verbose, maxField, nil)
+}
+
+// WriteStreamTo writes results to out, with maxField as for WriteStream.
+// If written is set, it is called with each result once it has been
+// written; formats that hold results until the end (summary, markdown)
+// never call it.
+func WriteStreamTo(ctx context.Context, in <-chan types.Result, out io.Writer,
+	format string, verbose bool, maxField int, written func(types.Result)) error {
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	hosts := summary{}
+	color := IsTerminal(out)
+	var rep report
+
+	// flush writes the formats that need every finding first
+	flush := func() error {
+		switch format {
+		case FormatSummary:
+			return hosts.write(out, color)
+		case FormatMarkdown:
+			return rep.write(out)
+		}
+		return nil
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
+			if err := flush(); err != nil {
+				return err
+			}
 			return ctx.Err()
 		case r, ok := <-in:
 			if !ok {
-				return nil
+				return flush()
+			}
+			if maxField > 0 && (format == FormatJSON || format == FormatCEF || format == FormatLEEF) {
+				r = Truncate(r, maxField)
 			}
-			if verbose {
-				fmt.Fprintf(out, "%s [%s: %s]\n", r.URL, r.Category, r.Reason)
-			} else {
+			switch {
+			case format == FormatSummary:
+				hosts.add(r)
+			case format == FormatMarkdown:
+				rep = append(rep, r)
+			case format == FormatGrep:
+				if err := writeGrep(out, r, color); err != nil {
+					return err
+				}
+			case format == FormatCEF:
+				if err := writeCEF(out, r); err != nil {
+					return err
+				}
+			case format == FormatLEEF:
+				if err := writeLEEF(out, r); err != nil {
+					return err
+				}
+			case format == FormatJSON:
+				if err := enc.Encode(r); err != nil {
+					return err
+				}
+			case verbose:
+				fmt.Fprintf(out, "%s [%s: %s] [%s] [%s] [%s]", r.URL, r.Category, r.Reason, r.Severity, r.Confidence, r.Fingerprint)
+				if r.Pattern != "" {
+					fmt.Fprintf(out, " [%q in %s at %d]", r.Pattern, r.Component, r.Offset)
+				}
+				if r.Score > 0 {
+					fmt.Fprintf(out, " [score %d]", r.Score)
+				}
+				if r.Context != "" {
+					fmt.Fprintf(out, " [%s at byte %d: %q]", r.Source, r.SourceOffset, r.Context)
+				}
+				fmt.Fprintln(out)
+			default:
 				fmt.Fprintln(out, r.URL)
 			}
+			if written != nil && format != FormatSummary && format != FormatMarkdown {
+				written(r)
+			}
 		}
 	}
 }
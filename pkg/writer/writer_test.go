@@ -0,0 +1,232 @@
+package writer
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alwalxed/juicyurls/v2/internal/types"
+)
+
+// TestSummary groups findings per host, most severe host first
+func TestSummary(t *testing.T) {
+	in := make(chan types.Result, 4)
+	in <- types.Result{URL: "https://a.example.com/x.sql", Category: "extensions", Severity: "medium"}
+	in <- types.Result{URL: "https://b.example.com/.env", Category: "hidden", Severity: "critical"}
+	in <- types.Result{URL: "https://a.example.com/y.sql", Category: "extensions", Severity: "medium"}
+	in <- types.Result{URL: "https://a.example.com/?token=1", Category: "keywords", Severity: "low"}
+	close(in)
+
+	out := filepath.Join(t.TempDir(), "summary.txt")
+	if err := WriteStream(context.Background(), in, out, FormatSummary, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "b.example.com  hidden:1\n" +
+		"a.example.com  extensions:2 keywords:1\n"
+	if string(got) != want {
+		t.Errorf("summary =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestMarkdown groups findings by severity, then category
+func TestMarkdown(t *testing.T) {
+	in := make(chan types.Result, 3)
+	in <- types.Result{URL: "https://a.example.com/x|y.sql", Category: "extensions", Reason: "Suspicious file extension",
+		Severity: "medium", Confidence: "likely", RuleID: "extensions:.sql", RuleSource: "builtin", RulesHash: "h1", Fingerprint: "f1", Pattern: ".sql", Component: "url", Offset: 25}
+	in <- types.Result{URL: "https://b.example.com/.env", Category: "hidden", Reason: "Hidden file or directory",
+		Severity: "critical", Confidence: "certain", RuleID: "hidden:dotenv", RuleSource: "team.yaml", RulesHash: "h1", Fingerprint: "f2", Pattern: ".env", Component: "url", Offset: 22}
+	close(in)
+
+	out := filepath.Join(t.TempDir(), "report.md")
+	if err := WriteStream(context.Background(), in, out, FormatMarkdown, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `## Findings
+
+| Severity | Findings |
+|---|---|
+| Critical | 1 |
+| Medium | 1 |
+
+Rule set ` + "`h1`" + `.
+
+### Critical
+
+#### hidden (1)
+
+| URL | Reason | Confidence | Rule | Evidence | Fingerprint |
+|---|---|---|---|---|---|
+| [https://b.example.com/.env](<https://b.example.com/.env>) | Hidden file or directory | certain | ` + "`hidden:dotenv` (team.yaml) | `.env` in url at 22 | `f2`" + ` |
+
+### Medium
+
+#### extensions (1)
+
+| URL | Reason | Confidence | Rule | Evidence | Fingerprint |
+|---|---|---|---|---|---|
+| [https://a.example.com/x\|y.sql](<https://a.example.com/x%7Cy.sql>) | Suspicious file extension | likely | ` + "`extensions:.sql` | `.sql` in url at 25 | `f1`" + ` |
+`
+	if string(got) != want {
+		t.Errorf("markdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestGrep writes file:line prefixes and highlights the matched literal
+func TestGrep(t *testing.T) {
+	r := types.Result{URL: "https://example.com/Backup.SQL", Category: "extensions", Pattern: ".sql",
+		Component: "url", Offset: 26, Source: "urls.txt", Line: 7}
+	var b strings.Builder
+	if err := writeGrep(&b, r, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "urls.txt:7:https://example.com/Backup.SQL:extensions:.sql\n"; b.String() != want {
+		t.Errorf("plain = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	r.Offset = 0 // Stale offset falls back to searching the URL
+	if err := writeGrep(&b, r, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := "urls.txt:7:https://example.com/Backup" + highlight + ".SQL" + colorReset + ":extensions:.sql\n"; b.String() != want {
+		t.Errorf("color = %q, want %q", b.String(), want)
+	}
+}
+
+// TestSIEM escapes CEF and LEEF values and applies a field map
+func TestSIEM(t *testing.T) {
+	defer func(cef, leef map[string]string) { CEFFields, LEEFFields = cef, leef }(maps.Clone(CEFFields), maps.Clone(LEEFFields))
+
+	r := types.Result{URL: "https://a.example/x=1|y", Category: "keywords", Reason: "Contains suspicious keyword",
+		Severity: "high", RuleID: "keywords:x", Fingerprint: "f1", Pattern: "x", Component: "path", Offset: 1}
+	path := filepath.Join(t.TempDir(), "fields.yaml")
+	if err := os.WriteFile(path, []byte("cef:\n  url: requestUrl\n  rule_id: cs4\n  pattern: \"-\"\n  component: \"-\"\n  offset: \"-\"\nleef:\n  pattern: \"-\"\n  component: \"-\"\n  offset: \"-\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyFieldMap(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := writeCEF(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `CEF:0|juicyurls|juicyurls|2|keywords:x|Contains suspicious keyword|8|requestUrl=https://a.example/x\=1|y cat=keywords msg=Contains suspicious keyword cs4=keywords:x cs1=f1 cs4Label=rule_id cs1Label=fingerprint` + "\n"
+	if b.String() != want {
+		t.Errorf("CEF =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := writeLEEF(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	want = "LEEF:2.0|juicyurls|juicyurls|2|keywords:x|x09|url=https://a.example/x=1|y\tcat=keywords\tmsg=Contains suspicious keyword\tsev=8\truleId=keywords:x\tfingerprint=f1\n"
+	if b.String() != want {
+		t.Errorf("LEEF =\n%q\nwant\n%q", b.String(), want)
+	}
+
+	for _, bad := range []string{"cef:\n  uri: request\n", "cef:\n  url: \"bad key\"\n", "leef:\n  url: cat\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ApplyFieldMap(path); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// TestTruncate cuts long URLs in structured formats only, on a UTF-8
+// boundary, and marks them
+func TestTruncate(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("é", 10)
+
+	for format, want := range map[string]string{
+		FormatJSON: `{"url":"https://example.com/éé",`,
+		FormatLEEF: "url=https://example.com/éé\t",
+		FormatText: long + "\n",
+	} {
+		in := make(chan types.Result, 1)
+		in <- types.Result{URL: long, Category: "keywords", Severity: "low"}
+		close(in)
+		out := filepath.Join(t.TempDir(), "out")
+		if err := WriteStream(context.Background(), in, out, format, false, 24); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := os.ReadFile(out)
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s output = %q, want it to contain %q", format, got, want)
+		}
+		if marked := strings.Contains(string(got), "truncated"); marked != (format != FormatText) {
+			t.Errorf("%s output = %q, truncated marker %v", format, got, marked)
+		}
+	}
+}
+
+// memSink keeps what it is given, moving findings to flushed on Flush
+type memSink struct {
+	buffered, flushed []Result
+}
+
+func (s *memSink) Write(ctx context.Context, r Result) error {
+	s.buffered = append(s.buffered, r)
+	return nil
+}
+
+func (s *memSink) Flush() error {
+	s.flushed = append(s.flushed, s.buffered...)
+	s.buffered = nil
+	return nil
+}
+
+// TestSink registers a sink by name, finds it by the -o scheme and hands
+// it every finding, flushed by the end
+func TestSink(t *testing.T) {
+	s := &memSink{}
+	RegisterSink("mem", func(ctx context.Context, target string) (Sink, error) {
+		return s, nil
+	})
+	if names := Sinks(); len(names) != 1 || names[0] != "mem" {
+		t.Errorf("Sinks() = %v; want [mem]", names)
+	}
+	if IsSinkTarget("mem.txt") || IsSinkTarget("sqlite://mem") {
+		t.Error("file or sqlite output taken for a registered sink")
+	}
+	open, ok := LookupSink("mem://findings")
+	if !ok {
+		t.Fatal("mem:// not found")
+	}
+	sink, _ := open(context.Background(), "mem://findings")
+
+	in := make(chan types.Result, 2)
+	in <- types.Result{URL: "https://example.com/.env"}
+	in <- types.Result{URL: "https://example.com/.git/config"}
+	close(in)
+	if err := WriteSink(context.Background(), in, sink, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.flushed) != 2 || len(s.buffered) != 0 {
+		t.Errorf("flushed %d, buffered %d; want 2 flushed", len(s.flushed), len(s.buffered))
+	}
+
+	for _, name := range []string{"mem", "sqlite", "a:b"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterSink(%q) did not panic", name)
+				}
+			}()
+			RegisterSink(name, func(context.Context, string) (Sink, error) { return nil, nil })
+		}()
+	}
+}
@@ -0,0 +1,238 @@
+package suspicious
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/*.yaml
+var dataFS embed.FS
+
+// Built-in pattern lists, loaded from the embedded data files at init.
+// They can be replaced or extended at runtime with ApplyFile.
+var (
+	Keywords   []string
+	Extensions []string
+	Paths      []string
+	Hidden     []string
+	Shares     []string
+)
+
+// Severities lists the valid severity levels from lowest to highest
+var Severities = []string{"info", "low", "medium", "high", "critical"}
+
+// Confidences lists the valid confidence levels from lowest to highest.
+// Confidence says how likely a match is to be a real finding, independent
+// of how bad it would be: a bare keyword is tentative, .git/config certain.
+var Confidences = []string{"tentative", "likely", "certain"}
+
+// severityWeights are the default scoring weights per severity
+var severityWeights = map[string]int{"info": 1, "low": 2, "medium": 5, "high": 10, "critical": 20}
+
+// Entry is a built-in pattern with its metadata
+type Entry struct {
+	ID            string   `yaml:"id"`
+	Pattern       string   `yaml:"pattern"`
+	Severity      string   `yaml:"severity"`
+	Confidence    string   `yaml:"confidence"`
+	Weight        int      `yaml:"weight"`         // Scoring weight; defaults from severity
+	In            []string `yaml:"in"`             // URL components the pattern is matched in; defaults to the file's
+	Schemes       []string `yaml:"schemes"`        // URL schemes the pattern applies to; empty means all
+	CaseSensitive bool     `yaml:"case-sensitive"` // Match the pattern case-sensitively
+	References    []string `yaml:"references"`
+	Tests         Tests    `yaml:"tests"`
+}
+
+// Tests holds example URLs an entry must and must not match
+type Tests struct {
+	Match   []string `yaml:"match"`
+	NoMatch []string `yaml:"nomatch"`
+}
+
+// UnmarshalYAML accepts either a bare pattern or a full mapping
+func (e *Entry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Pattern = node.Value
+		return nil
+	}
+	type plain Entry
+	return node.Decode((*plain)(e))
+}
+
+// dataFile is the layout of an embedded data file
+type dataFile struct {
+	Category   string   `yaml:"category"`
+	Severity   string   `yaml:"severity"`
+	Confidence string   `yaml:"confidence"`
+	In         []string `yaml:"in"`
+	Patterns   []Entry  `yaml:"patterns"`
+}
+
+// entries indexes built-in metadata by category and pattern
+var entries = map[string]map[string]Entry{}
+
+// defaultSeverity holds the file-level severity of each category
+var defaultSeverity = map[string]string{}
+
+// defaultConfidence holds the file-level confidence of each category
+var defaultConfidence = map[string]string{}
+
+// defaultIn holds the file-level components of each category
+var defaultIn = map[string][]string{}
+
+func init() {
+	for _, spec := range []struct {
+		category string
+		list     *[]string
+	}{
+		{"keywords", &Keywords},
+		{"extensions", &Extensions},
+		{"paths", &Paths},
+		{"hidden", &Hidden},
+		{"shares", &Shares},
+	} {
+		list, err := loadData(spec.category)
+		if err != nil {
+			panic(fmt.Sprintf("suspicious: embedded %s data: %v", spec.category, err))
+		}
+		*spec.list = list
+	}
+}
+
+// loadData parses data/<category>.yaml, fills in defaults and returns its patterns
+func loadData(category string) ([]string, error) {
+	raw, err := dataFS.ReadFile("data/" + category + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var df dataFile
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&df); err != nil {
+		return nil, err
+	}
+	if df.Category != category {
+		return nil, fmt.Errorf("category %q does not match file name", df.Category)
+	}
+	if !ValidSeverity(df.Severity) {
+		return nil, fmt.Errorf("invalid default severity %q", df.Severity)
+	}
+	if !ValidConfidence(df.Confidence) {
+		return nil, fmt.Errorf("invalid default confidence %q", df.Confidence)
+	}
+
+	index := make(map[string]Entry, len(df.Patterns))
+	list := make([]string, 0, len(df.Patterns))
+	for _, e := range df.Patterns {
+		if e.Pattern == "" {
+			return nil, fmt.Errorf("entry %q: missing pattern", e.ID)
+		}
+		if _, dup := index[e.Pattern]; dup {
+			return nil, fmt.Errorf("duplicate pattern %q", e.Pattern)
+		}
+		if e.ID == "" {
+			e.ID = category + ":" + e.Pattern
+		}
+		if e.Severity == "" {
+			e.Severity = df.Severity
+		}
+		if e.Confidence == "" {
+			e.Confidence = df.Confidence
+		}
+		if len(e.In) == 0 {
+			e.In = df.In
+		}
+		if !ValidSeverity(e.Severity) {
+			return nil, fmt.Errorf("entry %q: invalid severity %q", e.ID, e.Severity)
+		}
+		if !ValidConfidence(e.Confidence) {
+			return nil, fmt.Errorf("entry %q: invalid confidence %q", e.ID, e.Confidence)
+		}
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("entry %q: negative weight", e.ID)
+		}
+		index[e.Pattern] = e
+		list = append(list, e.Pattern)
+	}
+	entries[category] = index
+	defaultSeverity[category] = df.Severity
+	defaultConfidence[category] = df.Confidence
+	defaultIn[category] = df.In
+	return list, nil
+}
+
+// Lookup returns the built-in metadata for a pattern, if any
+func Lookup(category, pattern string) (Entry, bool) {
+	e, ok := entries[category][pattern]
+	return e, ok
+}
+
+// DefaultSeverity returns the severity of patterns in a built-in category
+// that carry no metadata of their own, and "medium" for other categories
+func DefaultSeverity(category string) string {
+	if s, ok := defaultSeverity[category]; ok {
+		return s
+	}
+	return "medium"
+}
+
+// DefaultConfidence returns the confidence of patterns in a built-in
+// category that carry no metadata of their own, and "likely" for other
+// categories
+func DefaultConfidence(category string) string {
+	if c, ok := defaultConfidence[category]; ok {
+		return c
+	}
+	return "likely"
+}
+
+// DefaultIn returns the URL components that patterns in a built-in
+// category are matched in, or nil for the whole URL
+func DefaultIn(category string) []string {
+	return defaultIn[category]
+}
+
+// SeverityRank orders severities from 0 (info) upwards; unknown values rank -1
+func SeverityRank(s string) int {
+	for i, v := range Severities {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// SeverityWeight returns the default scoring weight for a severity
+func SeverityWeight(s string) int {
+	return severityWeights[s]
+}
+
+// ValidSeverity reports whether s is one of Severities
+func ValidSeverity(s string) bool {
+	for _, v := range Severities {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfidenceRank orders confidences from 0 (tentative) upwards; unknown
+// values rank -1
+func ConfidenceRank(c string) int {
+	for i, v := range Confidences {
+		if c == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidConfidence reports whether c is one of Confidences
+func ValidConfidence(c string) bool {
+	return ConfidenceRank(c) >= 0
+}
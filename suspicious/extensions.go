@@ -1,13 +0,0 @@
-package suspicious
-
-var Extensions = []string{
-	".php", ".asp", ".aspx", ".jsp", ".inc", ".bak", ".zip", ".gz", ".tar", ".dat", ".json", ".env", ".conf",
-	".xml", ".yml", ".yaml", ".csv", ".log", ".txt", ".sql", ".db", ".backup", ".tar.gz", ".tar.bz2", ".7z",
-	".log", ".md", ".pem", ".key", ".crt", ".cer", ".p12", ".pfx", ".sh", ".pl", ".rb", ".exe", ".dll",
-	".msi", ".apk", ".ipa", ".dat", ".csv", ".html", ".js", ".css", ".scss", ".less", ".h", ".cpp",
-	".c", ".py", ".go", ".jar", ".war", ".ear", ".class", ".swf", ".jsonld", ".sqlite", ".db3", ".sqlite3",
-	".bak", ".orig", ".swp", ".swo", ".lock", ".vbs", ".ps1", ".psm1", ".cmd", ".bat", ".config", ".ini",
-	".plist", ".dmg", ".iso", ".deb", ".rpm", ".bin", ".md5", ".sha256", ".cna", ".pub", ".pfx", ".gpg",
-	".asc", ".sql.gz", ".sql.bz2", ".sql.xz", ".sql.tgz", ".tar.xz", ".tar.zst", ".zipx", ".tar.lzma", ".lzo",
-	".bzip2", ".xz", ".lzma", ".tgz", ".gzip", ".tar.xz", ".tar.zst", ".tar.lz4", ".tgz",
-}
@@ -0,0 +1,48 @@
+package suspicious
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadList reads patterns from a file, one per line.
+// Blank lines and lines starting with '#' are ignored.
+func LoadList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// ApplyFile loads patterns from spec into list. A spec of the form
+// "+path" appends to the existing list; a plain path replaces it.
+func ApplyFile(list *[]string, spec string) error {
+	path, appendMode := strings.CutPrefix(spec, "+")
+	patterns, err := LoadList(path)
+	if err != nil {
+		return err
+	}
+	if appendMode {
+		*list = append(*list, patterns...)
+	} else {
+		*list = patterns
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package suspicious
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestApplyFile covers replace and "+" append semantics
+func TestApplyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.txt")
+	content := "# custom patterns\nfoo\n\n  bar  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	list := []string{"builtin"}
+	if err := ApplyFile(&list, "+"+path); err != nil {
+		t.Fatalf("ApplyFile(+) error: %v", err)
+	}
+	if want := []string{"builtin", "foo", "bar"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("append: got %v; want %v", list, want)
+	}
+
+	list = []string{"builtin"}
+	if err := ApplyFile(&list, path); err != nil {
+		t.Fatalf("ApplyFile error: %v", err)
+	}
+	if want := []string{"foo", "bar"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("replace: got %v; want %v", list, want)
+	}
+
+	if err := ApplyFile(&list, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}